@@ -0,0 +1,55 @@
+// per-project accent colors: each session row starts with a thin bar in
+// its project's color, so sessions from the same repo read as a group
+// even when the sort interleaves them. the color is hashed from the
+// directory (stable across runs) unless config.json pins one.
+
+package main
+
+import (
+	"hash/fnv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// accentPalette holds 256-color codes that stay readable on dark and
+// light backgrounds and avoid the status colors' exact shades.
+var accentPalette = []string{
+	"39", "170", "214", "78", "141", "209", "45", "185", "105", "150", "204", "75",
+}
+
+// accentConfig controls the project accent bar.
+type accentConfig struct {
+	enabled bool
+	colors  map[string]string // directory or project ID -> lipgloss color
+}
+
+const accentMarker = "▎"
+
+// projectAccent returns the accent color for a session's project.
+func projectAccent(cs correlatedSession) lipgloss.Color {
+	dir := cs.process.cwd
+	if cs.session != nil {
+		if c, ok := display.accents.colors[cs.session.projectID]; ok {
+			return lipgloss.Color(c)
+		}
+		if cs.session.directory != "" {
+			dir = cs.session.directory
+		}
+	}
+	if c, ok := display.accents.colors[dir]; ok {
+		return lipgloss.Color(c)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(dir))
+	return lipgloss.Color(accentPalette[h.Sum32()%uint32(len(accentPalette))])
+}
+
+// renderRow renders a session row's text in style at full width,
+// swapping the first column of the indent for the project accent bar.
+func (m model) renderRow(cs correlatedSession, style lipgloss.Style, text string) string {
+	if !display.accents.enabled || m.width < 2 || len(text) == 0 || text[0] != ' ' {
+		return style.Width(m.width).MaxWidth(m.width).Render(text)
+	}
+	marker := lipgloss.NewStyle().Foreground(projectAccent(cs)).Render(accentMarker)
+	return marker + style.Width(m.width-1).MaxWidth(m.width-1).Render(text[1:])
+}
@@ -0,0 +1,151 @@
+// duplicate-correlation detection. each process should own one session,
+// but two can end up resolving to the same one: a stale plugin PID file
+// left by a process whose PID was reused, or two opencodes in one
+// container, which both get the latest session under its mounts. and a
+// guessed match can have runners-up: a container process takes the
+// session under its mounts whose activity best fits its lifetime, but
+// another that scores about as well is as likely to be the one running. rather than showing either as
+// if all were well, those rows get an "ambiguous" badge and the detail
+// view lists the competing processes or sessions.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ambiguousTag prefixes the title of rows that share their session or
+// have interchangeable candidates.
+const ambiguousTag = "[ambiguous] "
+
+// how a guessed session's activity is scored against the lifetime of
+// the process it's guessed for (overlapScore).
+const (
+	predatedScore = 0.1  // all activity predates the process
+	resumedScore  = 0.5  // least for any activity since the process started
+	scoreMargin   = 0.15 // runners-up this close to the best are as likely
+)
+
+// overlapScore rates how well c's activity fits a process started at
+// startMS (0 if unknown), from 0 to 1: the share of its messages written
+// since the process started. a session resumed by a fresh process wrote
+// most of its messages before it, so any activity since scores at least
+// resumedScore; a session whose activity all predates the process
+// can't be what it's running, and scores predatedScore.
+func overlapScore(c sessionCandidate, startMS int64) float64 {
+	switch {
+	case startMS == 0 || c.created >= startMS:
+		return 1
+	case c.recent == 0:
+		return predatedScore
+	}
+	return max(resumedScore, float64(c.recent)/float64(max(c.messages, c.recent)))
+}
+
+// rankCandidates scores cands against a process started at startMS and
+// sorts them best first, the most recently updated first among equals.
+func rankCandidates(cands []sessionCandidate, startMS int64) []sessionCandidate {
+	for i := range cands {
+		cands[i].score = overlapScore(cands[i], startMS)
+	}
+	slices.SortStableFunc(cands, func(a, b sessionCandidate) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.updated, a.updated)
+	})
+	return cands
+}
+
+// interchangeable returns the runners-up in ranked cands (the first one
+// chosen) scoring within scoreMargin of the choice.
+func interchangeable(cands []sessionCandidate) []sessionCandidate {
+	var near []sessionCandidate
+	for _, c := range cands[1:] {
+		if cands[0].score-c.score <= scoreMargin {
+			near = append(near, c)
+		}
+	}
+	return near
+}
+
+// sessionClaims maps each session ID that more than one process resolved
+// to onto those processes.
+func sessionClaims(correlated []correlatedSession) map[string][]processInfo {
+	claims := make(map[string][]processInfo)
+	for _, cs := range correlated {
+		if cs.session == nil || cs.process.isToolProcess {
+			continue
+		}
+		key := cs.process.host + "/" + cs.session.sessionID
+		claims[key] = append(claims[key], cs.process)
+	}
+	for key, procs := range claims {
+		if len(procs) < 2 {
+			delete(claims, key)
+		}
+	}
+	return claims
+}
+
+// competitors returns the other processes resolved to cs's session, or
+// nil if cs is the only one.
+func (m model) competitors(cs correlatedSession) []processInfo {
+	if cs.session == nil {
+		return nil
+	}
+	var others []processInfo
+	for _, p := range m.ambiguous[cs.process.host+"/"+cs.session.sessionID] {
+		if p.pid != cs.process.pid {
+			others = append(others, p)
+		}
+	}
+	return others
+}
+
+// ambiguousPrefix returns ambiguousTag for rows that share their
+// session or could be another one, or "".
+func (m model) ambiguousPrefix(cs correlatedSession) string {
+	if len(m.competitors(cs)) > 0 || len(cs.process.candidates) > 0 {
+		return ambiguousTag
+	}
+	return ""
+}
+
+// competitorLine describes the other claimants for the detail view, or "".
+func (m model) competitorLine(cs correlatedSession) string {
+	others := m.competitors(cs)
+	if len(others) == 0 {
+		return ""
+	}
+	var descs []string
+	for _, p := range others {
+		desc := fmt.Sprintf("pid %d", p.pid)
+		if p.tty != "" && p.tty != "??" {
+			desc += " " + p.tty
+		}
+		if p.container != "" {
+			desc += " [" + p.container + "]"
+		}
+		descs = append(descs, desc)
+	}
+	return "⚠ ambiguous: also resolved for " + strings.Join(descs, ", ")
+}
+
+// candidateLine lists the sessions cs's process could equally be
+// running, or "".
+func candidateLine(cs correlatedSession) string {
+	if len(cs.process.candidates) == 0 {
+		return ""
+	}
+	var descs []string
+	for _, c := range cs.process.candidates {
+		descs = append(descs, fmt.Sprintf("%s %q (score %.2f, updated %s ago)", c.id, c.title,
+			c.score, formatDuration(time.Now().UnixMilli()-c.updated)))
+	}
+	return "⚠ ambiguous: could also be " + strings.Join(descs, ", ")
+}
@@ -0,0 +1,61 @@
+// stale-row archiving (config "archive"). long-lived agents sit idle for
+// hours between uses and push live sessions down the list. once a
+// session has been idle or stale for archive.after, it leaves the list
+// and is counted in a single "N stale sessions" row under it instead;
+// A expands them back in place and collapses them again.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// archiveConfig controls archiving; after 0 turns it off.
+type archiveConfig struct {
+	after time.Duration
+}
+
+// archived reports whether a row is idle past the archive threshold.
+// sessions waiting on something (asking, errors, stuck) never are.
+func archived(cs correlatedSession) bool {
+	if display.archive.after <= 0 || cs.session == nil {
+		return false
+	}
+	switch inferStatus(cs.session, cs.process.cpuPercent) {
+	case "idle", "stale":
+	default:
+		return false
+	}
+	s := cs.session
+	last := max(s.lastMessageTime, s.lastPartTime, s.timeUpdated)
+	return time.Since(time.UnixMilli(last)) > display.archive.after
+}
+
+// archivedCount is the number of listed rows past the threshold, shown
+// collapsed or not.
+func (m model) archivedCount() int {
+	if display.archive.after <= 0 || m.inboxMode {
+		return 0
+	}
+	n := 0
+	for _, cs := range m.filteredSessions() {
+		if archived(cs) {
+			n++
+		}
+	}
+	return n
+}
+
+// renderArchiveRow draws the collapsed (or expanded) stale row.
+func (m model) renderArchiveRow(n int) string {
+	noun := "sessions"
+	if n == 1 {
+		noun = "session"
+	}
+	text := fmt.Sprintf("  ▸ %d stale %s (idle over %s)  A to show", n, noun, formatDuration(display.archive.after.Milliseconds()))
+	if m.showArchived {
+		text = fmt.Sprintf("  ▾ %d stale %s shown  A to collapse", n, noun)
+	}
+	return staleStyle.Render(truncOrPad(text, m.width))
+}
@@ -0,0 +1,135 @@
+// agent backends: the kinds of coding agent otop monitors. a backend
+// finds its agent's processes, resolves their sessions, and sums usage
+// for the header; the TUI only sees the processInfo / sessionInfo /
+// aggStats they return, so another agent (codex CLI, aider, goose) is a
+// new backend here rather than changes to the list or views.
+//
+// opencode is the only backend so far. the deeper views (detail
+// messages, heatmap, retro, export) still read opencode's db directly.
+//
+// config "backends" picks which run; the default is all of them.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// agentBackend is one kind of agent.
+type agentBackend interface {
+	// name identifies the backend in config ("opencode").
+	name() string
+	// listProcesses finds the agent's running processes, with session
+	// IDs filled in where the backend can tell them.
+	listProcesses() []processInfo
+	// getSession loads a session by ID. nil without an error means the
+	// backend doesn't know it.
+	getSession(sessionID string) (*sessionInfo, error)
+	// stats sums usage for today and for all time, in the current scope.
+	stats() (today, global aggStats, err error)
+}
+
+// agentBackends lists every backend in display order.
+var agentBackends = []agentBackend{
+	opencodeBackend{},
+}
+
+// enabledBackends returns the backends config "backends" selects.
+func enabledBackends() []agentBackend {
+	if len(display.backends) == 0 {
+		return agentBackends
+	}
+	var result []agentBackend
+	for _, b := range agentBackends {
+		if slices.Contains(display.backends, b.name()) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// checkBackendNames validates config "backends".
+func checkBackendNames(names []string) error {
+	var known []string
+	for _, b := range agentBackends {
+		known = append(known, b.name())
+	}
+	for _, n := range names {
+		if !slices.Contains(known, n) {
+			return fmt.Errorf("unknown backend %q (have: %s)", n, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// backendStats sums today's and all-time stats over the enabled
+// backends. failures are joined; the backends that answered still count.
+func backendStats() (today, global aggStats, err error) {
+	var errs []error
+	for _, b := range enabledBackends() {
+		t, g, err := b.stats()
+		errs = append(errs, err)
+		today = today.add(t)
+		global = global.add(g)
+	}
+	return today, global, errors.Join(errs...)
+}
+
+// add returns the sum of two stats.
+func (a aggStats) add(b aggStats) aggStats {
+	return aggStats{
+		sessionCount: a.sessionCount + b.sessionCount,
+		messageCount: a.messageCount + b.messageCount,
+		totalInput:   a.totalInput + b.totalInput,
+		totalOutput:  a.totalOutput + b.totalOutput,
+	}
+}
+
+// -- opencode --
+
+type opencodeBackend struct{}
+
+func (opencodeBackend) name() string { return "opencode" }
+
+// listProcesses covers local processes plus, when configured, those in
+// containers (containers.go) and on ssh remotes (remote.go).
+func (opencodeBackend) listProcesses() []processInfo {
+	processes := getOpencodeProcesses()
+	if display.docker {
+		processes = append(processes, containerProcesses(processes)...)
+	}
+	if len(display.remotes) > 0 {
+		processes = append(processes, remoteProcesses()...)
+	}
+	return processes
+}
+
+func (opencodeBackend) getSession(sessionID string) (*sessionInfo, error) {
+	return getSessionInfo(sessionID)
+}
+
+// stats runs the today and all-time queries in parallel, each on its
+// own connections.
+func (opencodeBackend) stats() (today, global aggStats, err error) {
+	var (
+		wg                  sync.WaitGroup
+		todayErr, globalErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer timeStep("db: today stats")()
+		today, todayErr = queryTodayStats()
+	}()
+	go func() {
+		defer wg.Done()
+		defer timeStep("db: global stats")()
+		global, globalErr = queryGlobalStats()
+	}()
+	wg.Wait()
+	return today, global, errors.Join(todayErr, globalErr)
+}
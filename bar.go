@@ -55,7 +55,7 @@ var barGroups = []barGroup{
 	{"asking", "Asking", "A", ansiMagenta, "#ff9500", []string{"asking"}},
 	{"active", "Active", "G", ansiGreen, "#4ec34e", []string{"generating", "tool use", "busy"}},
 	{"thinking", "Thinking", "T", ansiYellow, "#d4a72c", []string{"thinking", "queued"}},
-	{"error", "Error", "X", ansiRed, "#ff3b30", []string{"truncated"}},
+	{"error", "Error", "X", ansiRed, "#ff3b30", []string{"truncated", "stuck", "error"}},
 	{"idle", "Idle", "I", "", "#999999", []string{"idle"}},
 	{"stale", "Stale", "S", ansiDim, "#666666", []string{"stale", "unknown"}},
 }
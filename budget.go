@@ -0,0 +1,133 @@
+// cost budgets (config "budget"). with a daily and/or weekly limit set,
+// a budget line under the stats shows today's and this week's spend as
+// bars that turn yellow past budget.warn of the limit and red past the
+// limit itself. with budget.notify, crossing either mark raises a notice
+// like a status change (see notify.go). spend is the summed cost of
+// assistant messages in the window, across every database, within the
+// scope and watch filters.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const budgetRefresh = 30 * time.Second
+
+// budgetConfig sets the limits, in dollars; 0 means no limit.
+type budgetConfig struct {
+	daily  float64
+	weekly float64
+	warn   float64 // share of a limit that turns its bar yellow
+	notify bool    // raise a notice when a bar turns yellow or red
+}
+
+// budgetLevel is where spend sits against a limit.
+type budgetLevel int
+
+const (
+	budgetOK budgetLevel = iota
+	budgetWarn
+	budgetOver
+)
+
+// budgetMsg carries today's and this week's spend.
+type budgetMsg struct {
+	today, week float64
+	err         error
+}
+
+// budgetEnabled reports whether any limit is configured.
+func budgetEnabled() bool {
+	return display.budget.daily > 0 || display.budget.weekly > 0
+}
+
+func budgetCmd() tea.Msg {
+	today, week := periodStarts(time.Now())
+	costs, err := queryCostSince(today.UnixMilli(), week.UnixMilli())
+	return budgetMsg{today: costs[0], week: costs[1], err: err}
+}
+
+// budgetStale reports whether spend should be reloaded.
+func (m model) budgetStale() bool {
+	return budgetEnabled() && time.Since(m.budgetAt) > budgetRefresh
+}
+
+// levelFor places spent against limit.
+func levelFor(spent, limit float64) budgetLevel {
+	switch {
+	case limit <= 0:
+		return budgetOK
+	case spent >= limit:
+		return budgetOver
+	case spent >= limit*display.budget.warn:
+		return budgetWarn
+	}
+	return budgetOK
+}
+
+// budgetNotices returns notices for limits whose level went up since the
+// last load. the first load (prev unknown) announces nothing, like
+// status transitions.
+func budgetNotices(prev, cur [2]budgetLevel, known bool) []statusNotice {
+	if !known || !display.budget.notify {
+		return nil
+	}
+	labels := [2]string{"daily", "weekly"}
+	limits := [2]float64{display.budget.daily, display.budget.weekly}
+	var notices []statusNotice
+	for i := range cur {
+		if cur[i] <= prev[i] {
+			continue
+		}
+		// renders as "● budget daily 80% of $20.00" / "● over budget daily $20.00"
+		status, text := "budget", fmt.Sprintf("%s %.0f%% of $%.2f", labels[i], display.budget.warn*100, limits[i])
+		if cur[i] == budgetOver {
+			status, text = "over budget", fmt.Sprintf("%s $%.2f", labels[i], limits[i])
+		}
+		notices = append(notices, statusNotice{title: text, status: status, at: time.Now()})
+	}
+	return notices
+}
+
+// budgetStyle colors a bar by level.
+func budgetStyle(level budgetLevel) lipgloss.Style {
+	switch level {
+	case budgetOver:
+		return errorStyle
+	case budgetWarn:
+		return transStyle
+	}
+	return activeStyle
+}
+
+// renderBudgetBar draws one "label $spent/$limit [████░░] 62%" segment.
+func renderBudgetBar(label string, spent, limit float64) string {
+	const barW = 12
+	frac := min(1, spent/limit)
+	filled := int(frac*barW + 0.5)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barW-filled)
+	return dimStyle.Render(fmt.Sprintf("%s $%.2f/$%.2f ", label, spent, limit)) +
+		budgetStyle(levelFor(spent, limit)).Render(fmt.Sprintf("%s %3.0f%%", bar, spent/limit*100))
+}
+
+// renderBudgetLine draws the budget line, one bar per configured limit.
+func (m model) renderBudgetLine() string {
+	if !m.budgetLoaded {
+		return dimStyle.Render(" budget ...")
+	}
+	var parts []string
+	if display.budget.daily > 0 {
+		parts = append(parts, renderBudgetBar("today", m.budgetSpend[0], display.budget.daily))
+	}
+	if display.budget.weekly > 0 {
+		parts = append(parts, renderBudgetBar("week", m.budgetSpend[1], display.budget.weekly))
+	}
+	line := dimStyle.Render(" budget  ") + strings.Join(parts, "   ")
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
@@ -0,0 +1,126 @@
+// internal event bus: fetches, status transitions, and user actions are
+// published here instead of being wired into each consumer by hand.
+// the socket API, bell, and anything added later (hooks, recording)
+// subscribe to what they need, so a new consumer doesn't have to touch
+// the TUI's Update loop or the serve collector.
+//
+// delivery is synchronous on the publisher's goroutine (the TUI's
+// Update, or an HTTP handler); subscribers must return quickly and hand
+// slow work to their own goroutine.
+
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// event is anything published on the bus; subscribers type-switch.
+type event any
+
+// snapshotEvent carries a completed fetch.
+type snapshotEvent struct {
+	result fetchResult
+}
+
+// transitionEvent is a session leaving an active status for a notify
+// status (see notify.go).
+type transitionEvent struct {
+	sessionID string
+	notice    statusNotice
+}
+
+// budgetEvent is spend crossing a budget's warn mark or limit (see
+// budget.go).
+type budgetEvent struct {
+	notices []statusNotice
+}
+
+// actionEvent records a user action on a session and its outcome.
+// source is "tui" or "api".
+type actionEvent struct {
+	source    string
+	action    string // fork, jump, abort, compact, prompt, spawn, ...
+	sessionID string
+	err       error
+	at        time.Time
+}
+
+type eventBus struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]func(event)
+}
+
+var bus = &eventBus{subs: make(map[int]func(event))}
+
+// subscribe registers fn for every event and returns a function that
+// removes it.
+func (b *eventBus) subscribe(fn func(event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}
+}
+
+// publish delivers ev to every subscriber.
+func (b *eventBus) publish(ev event) {
+	b.mu.RLock()
+	subs := make([]func(event), 0, len(b.subs))
+	for _, fn := range b.subs {
+		subs = append(subs, fn)
+	}
+	b.mu.RUnlock()
+	for _, fn := range subs {
+		fn(ev)
+	}
+}
+
+// publishAction is shorthand for an actionEvent stamped now.
+func publishAction(source, action, sessionID string, err error) {
+	bus.publish(actionEvent{source: source, action: action, sessionID: sessionID, err: err, at: time.Now()})
+}
+
+// recentEventsLimit bounds the ring of recent transitions.
+const recentEventsLimit = 50
+
+// the ring of recent transitions, oldest first. /sessions lists it and
+// serve saves it across restarts (serve.go).
+var (
+	recentEventsMu sync.Mutex
+	recentEvents   []statusNotice
+)
+
+// recordTransition is a bus subscriber that keeps the last
+// recentEventsLimit transitions.
+func recordTransition(ev event) {
+	t, ok := ev.(transitionEvent)
+	if !ok {
+		return
+	}
+	recentEventsMu.Lock()
+	defer recentEventsMu.Unlock()
+	recentEvents = append(recentEvents, t.notice)
+	recentEvents = recentEvents[max(0, len(recentEvents)-recentEventsLimit):]
+}
+
+// recentTransitions returns a copy of the ring, oldest first.
+func recentTransitions() []statusNotice {
+	recentEventsMu.Lock()
+	defer recentEventsMu.Unlock()
+	return slices.Clone(recentEvents)
+}
+
+// restoreTransitions refills the ring, e.g. from a previous run.
+func restoreTransitions(notices []statusNotice) {
+	recentEventsMu.Lock()
+	defer recentEventsMu.Unlock()
+	recentEvents = slices.Clone(notices[max(0, len(notices)-recentEventsLimit):])
+}
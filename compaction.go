@@ -0,0 +1,73 @@
+// compaction surfacing. when a session's context fills up, opencode
+// summarizes it: a user message carrying a "compaction" part, answered
+// by an assistant message marked summary: true. afterwards the agent
+// only knows the summary, which explains a lot of sudden forgetfulness,
+// so the count and the time of the newest one are shown in the COMPACT
+// column and the detail view's info bar.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// queryCompactions counts a session's compactions and finds the newest.
+// older builds only wrote the summary message and newer ones also the
+// compaction part; each compaction has at most one of each, so the
+// larger count is the number of compactions.
+func queryCompactions(ctx context.Context, db *sql.DB, sessionID string) (count int, last int64, err error) {
+	var summaries, parts sql.NullInt64
+	var lastSummary, lastPart sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT count(*) FROM message
+				WHERE session_id = ? AND json_extract(data, '$.summary') = 1),
+			(SELECT max(time_created) FROM message
+				WHERE session_id = ? AND json_extract(data, '$.summary') = 1),
+			(SELECT count(*) FROM part
+				WHERE session_id = ? AND json_extract(data, '$.type') = 'compaction'),
+			(SELECT max(time_created) FROM part
+				WHERE session_id = ? AND json_extract(data, '$.type') = 'compaction')
+	`, sessionID, sessionID, sessionID, sessionID).Scan(&summaries, &lastSummary, &parts, &lastPart)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(max(summaries.Int64, parts.Int64)), max(lastSummary.Int64, lastPart.Int64), nil
+}
+
+// compactionAge formats how long ago the session was last compacted,
+// e.g. "12m ago", or "" if it never was.
+func compactionAge(s *sessionInfo) string {
+	if s.compactions == 0 || s.lastCompaction == 0 {
+		return ""
+	}
+	if display.absoluteTimes {
+		return "at " + formatClock(s.lastCompaction)
+	}
+	return formatDuration(time.Now().UnixMilli()-s.lastCompaction) + " ago"
+}
+
+// compactionRecent is how long the full layout flags a compaction on
+// the last output line.
+const compactionRecent = 30 * time.Minute
+
+// compactionPrefix marks a recent compaction ahead of the last output,
+// e.g. "[compacted 4m ago] ", or "".
+func compactionPrefix(s *sessionInfo) string {
+	if s.lastCompaction == 0 || time.Since(time.UnixMilli(s.lastCompaction)) > compactionRecent {
+		return ""
+	}
+	return "[compacted " + compactionAge(s) + "] "
+}
+
+// compactionSummary describes a session's compactions for the detail
+// view, or "" if there were none.
+func compactionSummary(s *sessionInfo) string {
+	if s.compactions == 0 {
+		return ""
+	}
+	return fmt.Sprintf("compacted %d× (last %s)", s.compactions, compactionAge(s))
+}
@@ -3,18 +3,24 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
 
-const refreshInterval = 2 * time.Second
+// refreshInterval is how often data is collected; OTOP_REFRESH overrides it.
+var refreshInterval = 2 * time.Second
+
 const defaultServePort = 8384
 
-// dbPath returns the path to opencode's sqlite database.
+// defaultDBPath returns the path to opencode's sqlite database.
 // respects XDG_DATA_HOME.
-func dbPath() string {
+func defaultDBPath() string {
 	dataHome := os.Getenv("XDG_DATA_HOME")
 	if dataHome == "" {
 		home, _ := os.UserHomeDir()
@@ -23,6 +29,113 @@ func dbPath() string {
 	return filepath.Join(dataHome, "opencode", "opencode.db")
 }
 
+// dbSource is one opencode database. label tags its sessions when
+// several databases are merged (e.g. separate XDG homes per client).
+type dbSource struct {
+	label string
+	path  string
+	host  string // ssh remote the database is mirrored from (remote.go)
+}
+
+// dbSources are the databases from --db / OTOP_DB. empty means the
+// default path only.
+var dbSources []dbSource
+
+// setDBSources configures databases from --db values, falling back to
+// OTOP_DB (a path list, separated like $PATH). each entry is a path or
+// label=path. with more than one database the DB column is enabled.
+func setDBSources(specs []string) {
+	if len(specs) == 0 {
+		specs = filepath.SplitList(os.Getenv("OTOP_DB"))
+	}
+	dbSources = nil
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		label, path, ok := strings.Cut(spec, "=")
+		if !ok {
+			path, label = spec, ""
+		}
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, rest)
+		}
+		if label == "" {
+			label = dbLabel(path)
+		}
+		dbSources = append(dbSources, dbSource{label: label, path: path})
+	}
+	if len(dbSources) > 1 {
+		display.columns.db = true
+	}
+	if len(display.remotes) > 0 {
+		if len(dbSources) == 0 {
+			dbSources = allDBSources()
+		}
+		for _, r := range display.remotes {
+			dbSources = append(dbSources, dbSource{label: r.host, path: remoteMirrorPath(r.host), host: r.host})
+		}
+	}
+}
+
+// dbLabel derives a short label from a db path: the data home it lives
+// in, with $HOME shown as ~ (".../clientA/.local/share/opencode/opencode.db"
+// becomes "~/clientA").
+func dbLabel(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == "opencode" {
+		dir = filepath.Dir(dir)
+	}
+	dir = strings.TrimSuffix(dir, filepath.Join(".local", "share"))
+	dir = strings.TrimSuffix(dir, string(filepath.Separator))
+	if home, err := os.UserHomeDir(); err == nil {
+		if rest, ok := strings.CutPrefix(dir, home); ok {
+			dir = "~" + rest
+		}
+	}
+	return shortPath(dir, 20)
+}
+
+// allDBSources returns the configured databases, or the default one.
+// remote mirrors are left out until their first copy has arrived.
+func allDBSources() []dbSource {
+	if len(dbSources) == 0 {
+		return []dbSource{{label: dbLabel(defaultDBPath()), path: defaultDBPath()}}
+	}
+	var sources []dbSource
+	for _, src := range dbSources {
+		if src.host != "" {
+			if _, err := os.Stat(src.path); err != nil {
+				continue
+			}
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// dbPath returns the primary database: the first --db / OTOP_DB entry,
+// or the default path.
+func dbPath() string {
+	return allDBSources()[0].path
+}
+
+// firstMissingDB returns the first configured database that doesn't
+// exist, or "" if all do. a missing database with the older JSON file
+// layout beside it (storage.go) counts as present.
+func firstMissingDB() string {
+	for _, src := range allDBSources() {
+		if _, ok := storageRoot(src.path); ok {
+			continue
+		}
+		if _, err := os.Stat(src.path); os.IsNotExist(err) {
+			return src.path
+		}
+	}
+	return ""
+}
+
 // configPath returns the path to opencode's global config.
 // respects XDG_CONFIG_HOME.
 func configPath() string {
@@ -34,6 +147,37 @@ func configPath() string {
 	return filepath.Join(configHome, "opencode", "opencode.json")
 }
 
+// otopConfigPath returns the path to otop's own config file.
+// respects XDG_CONFIG_HOME.
+func otopConfigPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "otop", "config.json")
+}
+
+// otopStateDir returns the directory for otop's own persistent state
+// (paired devices, etc). respects XDG_DATA_HOME.
+func otopStateDir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, _ := os.UserHomeDir()
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "otop")
+}
+
+// otopRuntimeDir returns the directory for per-boot files (instance
+// registry, sockets). uses XDG_RUNTIME_DIR, falling back to the state dir.
+func otopRuntimeDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "otop")
+	}
+	return otopStateDir()
+}
+
 // shortModel abbreviates long model names for display.
 func shortModel(model string) string {
 	if model == "" || model == "?" {
@@ -48,7 +192,15 @@ func shortModel(model string) string {
 	return model
 }
 
-var modelReplacements = []struct{ old, short string }{
+// modelAlias maps a substring of a model ID to its abbreviation.
+type modelAlias struct {
+	old   string
+	short string
+}
+
+// modelReplacements are applied in order; user aliases from the config
+// file are prepended so they take precedence over these defaults.
+var modelReplacements = []modelAlias{
 	{"claude-opus-4-5-20251101", "opus-4.5"},
 	{"claude-sonnet-4-5-20250929", "sonnet-4.5"},
 	{"claude-opus-4-6", "opus-4.6"},
@@ -62,6 +214,243 @@ var modelReplacements = []struct{ old, short string }{
 	{"gemini-3-flash", "gem-3f"},
 }
 
+// -- config file --
+// ~/.config/otop/config.json holds settings that change without a rebuild.
+// every field is optional; anything missing keeps the compiled-in default.
+//
+//	{
+//	  "model_aliases": [{"match": "kimi-k2-instruct", "short": "kimi-k2"}],
+//	  "hyperlinks": {"mode": "auto", "session_url": "https://example.com/s/{id}"}
+//	}
+
+// fileConfig is the on-disk format of the config file.
+type fileConfig struct {
+	ModelAliases []struct {
+		Match string `json:"match"`
+		Short string `json:"short"`
+	} `json:"model_aliases"`
+	Pricing []struct {
+		Match      string  `json:"match"`
+		Input      float64 `json:"input"`
+		Output     float64 `json:"output"`
+		CacheRead  float64 `json:"cache_read"`
+		CacheWrite float64 `json:"cache_write"`
+	} `json:"pricing"`
+	Hyperlinks *struct {
+		Mode       string `json:"mode"`
+		SessionURL string `json:"session_url"`
+	} `json:"hyperlinks"`
+	Editor  string   `json:"editor"`
+	Sort    []string `json:"sort"`
+	Filters []struct {
+		Name   string `json:"name"`
+		Filter string `json:"filter"`
+	} `json:"filters"`
+	StatusIcons string `json:"status_icons"`
+	Notify      *struct {
+		Statuses []string `json:"statuses"`
+		Bell     bool     `json:"bell"`
+	} `json:"notify"`
+	History              bool `json:"history"`
+	HistoryDays          *int `json:"history_days"`
+	NumberKeysOpenDetail bool `json:"number_keys_open_detail"`
+	AbsoluteTimes        bool `json:"absolute_times"`
+	Docker               bool `json:"docker"`
+	ProjectColors        *struct {
+		Enabled *bool             `json:"enabled"`
+		Colors  map[string]string `json:"colors"`
+	} `json:"project_colors"`
+	Snapshot *struct {
+		Enabled *bool `json:"enabled"`
+		Every   int   `json:"every"`
+	} `json:"snapshot"`
+	Backends []string `json:"backends"`
+	Remotes  []struct {
+		Host string `json:"host"`
+		DB   string `json:"db"`
+	} `json:"remotes"`
+	Footer *struct {
+		Mode   string `json:"mode"`
+		Format string `json:"format"`
+	} `json:"footer"`
+	Budget *struct {
+		Daily  float64 `json:"daily"`
+		Weekly float64 `json:"weekly"`
+		Warn   float64 `json:"warn"`
+		Notify *bool   `json:"notify"`
+	} `json:"budget"`
+	Archive *struct {
+		After string `json:"after"`
+	} `json:"archive"`
+	PaneIdle *struct {
+		Enabled       *bool  `json:"enabled"`
+		PromptPattern string `json:"prompt_pattern"`
+		Lines         int    `json:"lines"`
+	} `json:"pane_idle"`
+	Watchdog *struct {
+		Enabled   *bool   `json:"enabled"`
+		Threshold string  `json:"threshold"`
+		CPU       float64 `json:"cpu"`
+		Notify    *bool   `json:"notify"`
+	} `json:"watchdog"`
+}
+
+// loadConfigFile applies the config file on top of the defaults.
+// a missing file is fine; a malformed one is an error.
+func loadConfigFile() error {
+	data, err := os.ReadFile(otopConfigPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var cfg fileConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", otopConfigPath(), err)
+	}
+
+	var aliases []modelAlias
+	for _, a := range cfg.ModelAliases {
+		if a.Match != "" {
+			aliases = append(aliases, modelAlias{old: a.Match, short: a.Short})
+		}
+	}
+	modelReplacements = append(aliases, modelReplacements...)
+
+	var prices []modelPrice
+	for _, p := range cfg.Pricing {
+		if p.Match != "" {
+			prices = append(prices, modelPrice{p.Match, p.Input, p.Output, p.CacheRead, p.CacheWrite})
+		}
+	}
+	modelPrices = append(prices, modelPrices...)
+
+	if h := cfg.Hyperlinks; h != nil {
+		if h.Mode != "" {
+			display.hyperlinks.mode = h.Mode
+		}
+		display.hyperlinks.sessionURL = h.SessionURL
+	}
+	if cfg.Editor != "" {
+		display.editorCommand = cfg.Editor
+	}
+	for _, key := range cfg.Sort {
+		if !slices.ContainsFunc(columns, func(c columnDef) bool { return c.key == key }) {
+			return fmt.Errorf("%s: sort: unknown column %q", otopConfigPath(), key)
+		}
+	}
+	if len(cfg.Sort) > 0 {
+		display.defaultSortKey = cfg.Sort[0]
+		display.defaultSortThen = cfg.Sort[1:]
+	}
+	for _, f := range cfg.Filters {
+		if f.Name == "" || f.Filter == "" {
+			return fmt.Errorf("%s: filters: each needs a name and a filter", otopConfigPath())
+		}
+		display.filterPresets = append(display.filterPresets, filterPreset{name: f.Name, filter: f.Filter})
+	}
+	if f := cfg.Footer; f != nil {
+		if err := setFooter(f.Mode, f.Format); err != nil {
+			return fmt.Errorf("%s: footer: %w", otopConfigPath(), err)
+		}
+	}
+	if err := setStatusIcons(cfg.StatusIcons); err != nil {
+		return fmt.Errorf("%s: status_icons: %w", otopConfigPath(), err)
+	}
+	if n := cfg.Notify; n != nil {
+		if n.Statuses != nil {
+			display.notify.statuses = n.Statuses
+		}
+		display.notify.bell = n.Bell
+	}
+	display.history = cfg.History
+	display.historyDays = 30
+	if cfg.HistoryDays != nil {
+		display.historyDays = max(0, *cfg.HistoryDays)
+	}
+	display.numberKeysOpenDetail = cfg.NumberKeysOpenDetail
+	display.absoluteTimes = cfg.AbsoluteTimes
+	display.docker = cfg.Docker
+	if err := checkBackendNames(cfg.Backends); err != nil {
+		return fmt.Errorf("%s: backends: %w", otopConfigPath(), err)
+	}
+	display.backends = cfg.Backends
+	if sn := cfg.Snapshot; sn != nil {
+		display.snapshot.enabled = sn.Enabled == nil || *sn.Enabled
+		display.snapshot.every = max(1, sn.Every)
+	}
+	for _, r := range cfg.Remotes {
+		if r.Host == "" {
+			return fmt.Errorf("%s: remotes: missing host", otopConfigPath())
+		}
+		db := r.DB
+		if db == "" {
+			db = defaultRemoteDB
+		}
+		display.remotes = append(display.remotes, remoteConfig{host: r.Host, db: db})
+	}
+	if len(display.remotes) > 0 {
+		display.columns.host = true
+	}
+	if pc := cfg.ProjectColors; pc != nil {
+		if pc.Enabled != nil {
+			display.accents.enabled = *pc.Enabled
+		}
+		display.accents.colors = pc.Colors
+	}
+	if bc := cfg.Budget; bc != nil {
+		display.budget.daily = bc.Daily
+		display.budget.weekly = bc.Weekly
+		if bc.Warn > 0 {
+			display.budget.warn = bc.Warn
+		}
+		if bc.Notify != nil {
+			display.budget.notify = *bc.Notify
+		}
+	}
+	if a := cfg.Archive; a != nil && a.After != "" {
+		d, err := time.ParseDuration(a.After)
+		if err != nil {
+			return fmt.Errorf("%s: archive.after: %w", otopConfigPath(), err)
+		}
+		display.archive.after = d
+	}
+	if pi := cfg.PaneIdle; pi != nil {
+		display.paneIdle.enabled = pi.Enabled == nil || *pi.Enabled
+		if pi.PromptPattern != "" {
+			re, err := regexp.Compile(pi.PromptPattern)
+			if err != nil {
+				return fmt.Errorf("%s: pane_idle.prompt_pattern: %w", otopConfigPath(), err)
+			}
+			display.paneIdle.prompt = re
+		}
+		if pi.Lines > 0 {
+			display.paneIdle.lines = pi.Lines
+		}
+	}
+	if w := cfg.Watchdog; w != nil {
+		if w.Enabled != nil {
+			display.watchdog.enabled = *w.Enabled
+		}
+		if w.Threshold != "" {
+			d, err := time.ParseDuration(w.Threshold)
+			if err != nil {
+				return fmt.Errorf("%s: watchdog.threshold: %w", otopConfigPath(), err)
+			}
+			display.watchdog.threshold = d
+		}
+		if w.CPU > 0 {
+			display.watchdog.cpu = w.CPU
+		}
+		if w.Notify != nil {
+			display.watchdog.notify = *w.Notify
+		}
+	}
+	return nil
+}
+
 // columnDef defines a sortable column with a key and display label.
 type columnDef struct {
 	key   string
@@ -75,6 +464,8 @@ var columns = []columnDef{
 	{"title", "TITLE"},
 	{"last", "LAST OUTPUT"},
 	{"msgs", "MSGS"},
+	{"rounds", "ROUNDS"},
+	{"tools", "TOOLS"},
 	{"sid", "SID"},
 	{"pid", "PID"},
 	{"uptime", "UPTIME"},
@@ -82,10 +473,30 @@ var columns = []columnDef{
 	{"cpu", "CPU%"},
 	{"mem", "MEM"},
 	{"tokens", "CTX/OUT"},
+	{"cache", "CACHE%"},
+	{"cwrite", "CWRITE"},
+	{"compact", "COMPACT"},
+	{"idle", "IDLE FOR"},
+	{"age", "AGE"},
+	{"updated", "UPDATED"},
+	{"todo", "TODO"},
 	{"model", "MODEL"},
+	{"version", "VERSION"},
 	{"tty", "TTY"},
 	{"tmux", "TMUX"},
 	{"tmuxWin", "WINDOW"},
+	{"db", "DB"},
+	{"host", "HOST"},
+}
+
+// columnLabel returns the label of the sort column with the given key.
+func columnLabel(key string) string {
+	for _, col := range columns {
+		if col.key == key {
+			return col.label
+		}
+	}
+	return key
 }
 
 // grid column widths (content, not including gap)
@@ -99,21 +510,67 @@ const (
 	colGap    = 2  // space between columns
 )
 
+// gridSlot is one fixed column of the two-line layout. the top and bottom
+// rows show different fields in it (e.g. SID over PID) at a shared width.
+type gridSlot struct {
+	top, bottom string // header labels
+	width       int
+}
+
+// gridSlots lists the two-line columns left to right.
+var gridSlots = []gridSlot{
+	{"STATUS", "MSGS", colStatus},
+	{"SID", "PID", colSID},
+	{"UP", "ROUND", colUp},
+	{"CPU", "MEM", colCPU},
+	{"CTX", "OUT", colCtx},
+	{"MODEL", "TTY", colModel},
+}
+
+// gridDropOrder lists the two-line slots (by top label) in the order they're
+// dropped as the terminal narrows. STATUS always stays.
+var gridDropOrder = []string{"SID", "MODEL", "CPU", "UP", "CTX"}
+
+// minTitleWidth is the narrowest TITLE/LAST column before fixed columns
+// start being dropped to make room.
+const minTitleWidth = 24
+
 // -- display configuration --
 // controls which sections and columns are visible.
 // two-line mode ignores the columns config and shows the full layout.
 // one-line mode uses the columns config to pick which columns appear.
 
 type displayConfig struct {
-	showHeader         bool
-	showAggregateStats bool
-	showColumnHeaders  bool
-	oneLine            bool
-	defaultSortKey     string // column key to sort by on startup (e.g. "round", "status")
-	defaultSortReverse bool   // true = descending, false = ascending
-	columns            columnConfig
-	ticker             tickerConfig
-	bar                barConfig
+	showHeader           bool
+	showAggregateStats   bool
+	showColumnHeaders    bool
+	oneLine              bool
+	defaultSortKey       string   // column key to sort by on startup (e.g. "round", "status")
+	defaultSortThen      []string // tie-breaking keys after defaultSortKey, in order
+	defaultSortReverse   bool     // true = descending, false = ascending
+	columns              columnConfig
+	ticker               tickerConfig
+	bar                  barConfig
+	paneIdle             paneIdleConfig
+	columnFormats        map[string]columnFormat // keyed by one-line column key
+	hyperlinks           hyperlinkConfig
+	notify               notifyConfig
+	editorCommand        string // template for the e key; {cwd} is replaced by the directory. empty = $EDITOR
+	history              bool   // record per-fetch samples to history.db (see history.go)
+	historyDays          int    // samples older than this are pruned on open; 0 keeps them all
+	watchdog             watchdogConfig
+	numberKeysOpenDetail bool // 1-9 open the detail view instead of just moving the cursor
+	accents              accentConfig
+	docker               bool           // also look for opencode inside running containers (containers.go)
+	remotes              []remoteConfig // ssh hosts to collect from as well (remote.go)
+	backends             []string       // agent backends to run (backend.go); empty = all
+	snapshot             snapshotConfig // query local copies of the databases (snapshot.go)
+	archive              archiveConfig  // fold long-idle rows into one (archive.go)
+	filterPresets        []filterPreset // named filters for F1-F4 and F (filter.go)
+	statusIcons          string         // "nerd", "unicode" or "ascii" glyphs in the one-line STATUS column (icons.go); "" = words
+	budget               budgetConfig   // daily/weekly cost limits (budget.go)
+	footer               footerConfig   // list footer contents (footer.go)
+	absoluteTimes        bool           // time columns show clock times instead of durations (@)
 }
 
 // columnConfig toggles individual columns in one-line mode.
@@ -122,6 +579,8 @@ type columnConfig struct {
 	last    bool
 	status  bool
 	msgs    bool
+	rounds  bool // user messages, the number of prompts
+	tools   bool // tool calls over the session
 	sid     bool
 	pid     bool
 	uptime  bool
@@ -130,10 +589,20 @@ type columnConfig struct {
 	mem     bool
 	ctx     bool
 	out     bool
+	cache   bool // cache reads as a share of input
+	cwrite  bool // cache write tokens
+	compact bool // time since the last context compaction
+	idle    bool // time since the user last typed a prompt
+	age     bool // time since the session was created
+	updated bool // time since the session was last updated
+	todo    bool // todo progress, done/total
 	model   bool
+	version bool // opencode version, marked when behind the newest running
 	tty     bool
 	tmux    bool
 	tmuxWin bool
+	db      bool // source database; enabled automatically with several
+	host    bool // ssh remote; enabled automatically when remotes are configured
 }
 
 // barConfig controls the SwiftBar menu bar output (otop bar-status).
@@ -150,6 +619,46 @@ type tickerConfig struct {
 	rateMS int
 }
 
+// paneIdleConfig controls the tmux pane idle heuristic. the db write for
+// a finished response often lags the UI, so "generating" lingers. when
+// enabled, panes of still-generating sessions are captured and if one of
+// the last few non-empty lines matches promptPattern the session shows
+// as idle instead.
+type paneIdleConfig struct {
+	enabled bool
+	prompt  *regexp.Regexp // matched against each trailing line
+	lines   int            // how many trailing non-empty lines to inspect
+}
+
+// defaultPanePrompt matches a bare shell or opencode prompt line.
+const defaultPanePrompt = `^\s*[>❯$]\s*$`
+
+// columnFormat tweaks how a one-line column renders. columns without
+// an entry are left-aligned with their default formatting.
+type columnFormat struct {
+	alignRight bool // pad on the left so digits line up
+	noUnits    bool // drop unit suffixes ("%", "M", "K") and show plain numbers
+	precision  int  // fixed decimals for cpu/mem/ctx/out: 0 = column default, -1 = whole numbers
+}
+
+// notifyConfig controls status-change notices (see notify.go).
+// a session going from an active status to one in statuses shows a
+// notice line for hold, and rings the terminal bell if bell is set.
+type notifyConfig struct {
+	statuses []string
+	bell     bool
+	hold     time.Duration
+}
+
+// hyperlinkConfig controls OSC 8 hyperlinks on directory and session
+// ID cells. mode is "auto" (detect terminal support), "on", or "off".
+// sessionURL is a template where {id} is replaced by the session ID;
+// empty means session IDs aren't linked.
+type hyperlinkConfig struct {
+	mode       string
+	sessionURL string
+}
+
 // display is the active layout configuration.
 // edit these fields to customize the layout.
 var display = displayConfig{
@@ -176,6 +685,43 @@ var display = displayConfig{
 		showIcon: false,
 		icon:     "cpu",
 	},
+	paneIdle: paneIdleConfig{
+		enabled: false,
+		prompt:  regexp.MustCompile(defaultPanePrompt),
+		lines:   3,
+	},
+	hyperlinks: hyperlinkConfig{
+		mode:       "auto",
+		sessionURL: "",
+	},
+	notify: notifyConfig{
+		statuses: []string{"idle", "truncated", "error"},
+		bell:     false,
+		hold:     10 * time.Second,
+	},
+	editorCommand: "",
+	watchdog: watchdogConfig{
+		enabled:   true,
+		threshold: 90 * time.Second,
+		cpu:       1.0,
+		notify:    true,
+	},
+	accents: accentConfig{enabled: true},
+	budget: budgetConfig{
+		warn:   0.8,
+		notify: true,
+	},
+	columnFormats: map[string]columnFormat{
+		"msgs":   {alignRight: true},
+		"pid":    {alignRight: true},
+		"cpu":    {alignRight: true},
+		"mem":    {alignRight: true},
+		"ctx":    {alignRight: true},
+		"out":    {alignRight: true},
+		"cache":  {alignRight: true},
+		"cwrite": {alignRight: true},
+		"todo":   {alignRight: true},
+	},
 }
 
 // -- full layout preset (uncomment to switch) --
@@ -187,7 +733,7 @@ var display = displayConfig{
 // 	columns: columnConfig{
 // 		title: true, last: true, status: true, msgs: true,
 // 		sid: true, pid: true, uptime: true, round: true,
-// 		cpu: true, mem: true, ctx: true, out: true,
+// 		cpu: true, mem: true, ctx: true, out: true, cache: true, cwrite: true,
 // 		model: true, tty: true,
 // 	},
 // 	ticker: tickerConfig{width: 0, rateMS: 300},
@@ -203,6 +749,10 @@ func (c columnConfig) isEnabled(key string) bool {
 		return c.status
 	case "msgs":
 		return c.msgs
+	case "rounds":
+		return c.rounds
+	case "tools":
+		return c.tools
 	case "sid":
 		return c.sid
 	case "pid":
@@ -219,18 +769,100 @@ func (c columnConfig) isEnabled(key string) bool {
 		return c.ctx
 	case "out":
 		return c.out
+	case "cache":
+		return c.cache
+	case "cwrite":
+		return c.cwrite
+	case "compact":
+		return c.compact
+	case "idle":
+		return c.idle
+	case "age":
+		return c.age
+	case "updated":
+		return c.updated
+	case "todo":
+		return c.todo
 	case "model":
 		return c.model
+	case "version":
+		return c.version
 	case "tty":
 		return c.tty
 	case "tmux":
 		return c.tmux
 	case "tmuxWin":
 		return c.tmuxWin
+	case "db":
+		return c.db
+	case "host":
+		return c.host
 	}
 	return false
 }
 
+// setEnabled toggles a column by key. used by the column picker (C).
+func (c *columnConfig) setEnabled(key string, on bool) {
+	switch key {
+	case "title":
+		c.title = on
+	case "last":
+		c.last = on
+	case "status":
+		c.status = on
+	case "msgs":
+		c.msgs = on
+	case "rounds":
+		c.rounds = on
+	case "tools":
+		c.tools = on
+	case "sid":
+		c.sid = on
+	case "pid":
+		c.pid = on
+	case "uptime":
+		c.uptime = on
+	case "round":
+		c.round = on
+	case "cpu":
+		c.cpu = on
+	case "mem":
+		c.mem = on
+	case "ctx":
+		c.ctx = on
+	case "out":
+		c.out = on
+	case "cache":
+		c.cache = on
+	case "cwrite":
+		c.cwrite = on
+	case "compact":
+		c.compact = on
+	case "idle":
+		c.idle = on
+	case "age":
+		c.age = on
+	case "updated":
+		c.updated = on
+	case "todo":
+		c.todo = on
+	case "model":
+		c.model = on
+	case "version":
+		c.version = on
+	case "tty":
+		c.tty = on
+	case "tmux":
+		c.tmux = on
+	case "tmuxWin":
+		c.tmuxWin = on
+	case "db":
+		c.db = on
+	case "host":
+		c.host = on
+	}
+}
+
 // oneLineColSpec describes a column in one-line mode.
 type oneLineColSpec struct {
 	key   string
@@ -242,11 +874,15 @@ type oneLineColSpec struct {
 var oneLineColumnOrder = []oneLineColSpec{
 	{"tmux", "TMUX", 12},
 	{"tmuxWin", "WINDOW", 12},
+	{"host", "HOST", 10},
+	{"db", "DB", 10},
 	{"sid", "SID", 30},
 	{"title", "TITLE", 0},
 	{"last", "LAST", 0},
 	{"status", "STATUS", 10},
 	{"msgs", "MSGS", 5},
+	{"rounds", "ROUNDS", 6},
+	{"tools", "TOOLS", 6},
 	{"pid", "PID", 8},
 	{"uptime", "UP", 8},
 	{"round", "ROUND", 8},
@@ -254,10 +890,25 @@ var oneLineColumnOrder = []oneLineColSpec{
 	{"mem", "MEM", 6},
 	{"ctx", "CTX", 8},
 	{"out", "OUT", 8},
+	{"cache", "CACHE", 5},
+	{"cwrite", "CWRITE", 8},
+	{"compact", "COMPACT", 9},
+	{"idle", "IDLE FOR", 8},
+	{"age", "AGE", 8},
+	{"updated", "UPDATED", 8},
+	{"todo", "TODO", 5},
 	{"model", "MODEL", 12},
+	{"version", "VERSION", 10},
 	{"tty", "TTY", 12},
 }
 
+// oneLineDropOrder lists one-line columns in the order they're dropped when
+// the enabled set doesn't fit the terminal. title and status always stay.
+var oneLineDropOrder = []string{
+	"tty", "sid", "version", "compact", "idle", "updated", "age", "rounds", "tools", "cwrite", "cache", "todo", "mem", "pid", "tmuxWin", "db", "host", "cpu", "uptime",
+	"msgs", "out", "ctx", "model", "tmux", "round", "last",
+}
+
 // enabledOneLineColumns returns the enabled columns with widths resolved.
 // the "last" column width comes from ticker.width when set.
 func enabledOneLineColumns() []oneLineColSpec {
@@ -269,6 +920,9 @@ func enabledOneLineColumns() []oneLineColSpec {
 		if col.key == "last" && display.ticker.width > 0 {
 			col.width = display.ticker.width
 		}
+		if col.key == "status" && iconsEnabled() {
+			col.label, col.width = "S", 1 // glyphs are one cell (icons.go)
+		}
 		result = append(result, col)
 	}
 	return result
@@ -0,0 +1,286 @@
+// container sessions (config "docker": true). opencode running inside a
+// container is invisible to host ps on Docker Desktop, and even where
+// it isn't, its cwd and PID file live in the container's filesystem.
+// so each running container is asked for its processes with docker top,
+// and opencode ones become rows labeled with the container name.
+//
+// the container is also asked, from its side, for each opencode
+// process's cwd and the plugin's PID file. when the plugin is loaded in
+// the container its PID file names the session. otherwise the session
+// is guessed through the bind mounts holding the process's cwd: of the
+// recent sessions whose directory is under one of them (by its container
+// path or host path), the one whose messages best fit the process's
+// lifetime is taken to be the one running. others scoring about as well
+// could as well be, so they're kept as candidates and the row is
+// flagged ambiguous (ambiguous.go).
+// either way the container's opencode has to share a database otop
+// reads, e.g. by mounting ~/.local/share/opencode or passing --db.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dockerMount is one bind mount of a container.
+type dockerMount struct {
+	source      string // host path
+	destination string // path inside the container
+}
+
+// containerProc is an opencode process as its container sees it.
+type containerProc struct {
+	pid       int    // in the container's PID namespace
+	cwd       string // container path
+	sessionID string // from the plugin's PID file in the container, "" without one
+}
+
+// containerProcScript lists the container's opencode processes as
+// "pid<TAB>cwd<TAB>session", reading the PID file where the plugin
+// would write it inside the container.
+const containerProcScript = `set -f
+d="${XDG_DATA_HOME:-$HOME/.local/share}/opencode/otop"
+for p in /proc/[0-9]*; do
+	set -- $(tr '\0' ' ' < "$p/cmdline" 2>/dev/null)
+	[ "${1##*/}" = opencode ] || continue
+	n=${p#/proc/}
+	printf '%s\t%s\t%s\n' "$n" "$(readlink "$p/cwd")" "$(cat "$d/$n" 2>/dev/null)"
+done`
+
+// containerProcesses returns the opencode processes inside running
+// containers. processes host ps already found (same PID) are skipped
+// but their host rows get the container label.
+func containerProcesses(host []processInfo) []processInfo {
+	done := timeStep("docker")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "docker", "ps", "--format", "{{.ID}}\t{{.Names}}").Output()
+	if err != nil {
+		return nil
+	}
+
+	hostByPID := make(map[int]*processInfo, len(host))
+	for i := range host {
+		hostByPID[host[i].pid] = &host[i]
+	}
+
+	var found []processInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		id, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		procs := dockerTopOpencode(ctx, id)
+		if len(procs) == 0 {
+			continue
+		}
+		mounts := dockerMounts(ctx, id)
+		// both lists are in PID order, which is start order in either
+		// namespace; pair them up only if they agree on the count
+		inner := dockerContainerProcs(ctx, id)
+		if len(inner) != len(procs) {
+			inner = nil
+		}
+		for i, p := range procs {
+			if h, ok := hostByPID[p.pid]; ok {
+				h.container = name
+				continue
+			}
+			var cp containerProc
+			if inner != nil {
+				cp = inner[i]
+			}
+			p.container = name
+			p.cwd = containerWorkspace(mounts)
+			if host, ok := hostPathIn(mounts, cp.cwd); ok {
+				p.cwd = host
+			}
+			if !p.isToolProcess {
+				if cp.sessionID != "" {
+					p.sessionID = cp.sessionID
+				} else {
+					var dirs []string
+					for _, mnt := range cwdMounts(mounts, cp.cwd) {
+						dirs = append(dirs, mnt.destination, mnt.source)
+					}
+					cands, _ := sessionsUnder(dirs, p.startTimeMS, 10)
+					if len(cands) > 0 {
+						cands = rankCandidates(cands, p.startTimeMS)
+						p.sessionID = cands[0].id
+						p.matchScore = cands[0].score
+						p.candidates = interchangeable(cands)
+						p.guessed = true
+					}
+				}
+			}
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// containerTag prefixes a row title with where the process runs: its
+// remote host, its container, or both.
+func containerTag(p processInfo, title string) string {
+	switch {
+	case p.host != "" && p.container != "":
+		return "[" + p.host + "/" + p.container + "] " + title
+	case p.host != "":
+		return "[" + p.host + "] " + title
+	case p.container != "":
+		return "[" + p.container + "] " + title
+	}
+	return title
+}
+
+// dockerTopOpencode lists the opencode processes in a container. the
+// PIDs are as the docker host sees them (the Docker Desktop VM on macOS).
+func dockerTopOpencode(ctx context.Context, id string) []processInfo {
+	out, err := exec.CommandContext(ctx, "docker", "top", id, "-eo", "pid,pcpu,rss,etime,args").Output()
+	if err != nil {
+		return nil
+	}
+	var procs []processInfo
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for _, line := range lines[1:] {
+		parts := strings.Fields(line)
+		if len(parts) < 5 || filepath.Base(parts[4]) != "opencode" {
+			continue
+		}
+		pid, _ := strconv.Atoi(parts[0])
+		cpu, _ := strconv.ParseFloat(parts[1], 64)
+		rss, _ := strconv.Atoi(parts[2])
+		var startMS int64
+		if up, ok := parseEtime(parts[3]); ok {
+			startMS = time.Now().Add(-up).UnixMilli()
+		}
+		procs = append(procs, processInfo{
+			pid:           pid,
+			cpuPercent:    cpu,
+			memMB:         float64(rss) / 1024,
+			elapsed:       parts[3],
+			startTimeMS:   startMS,
+			tty:           "?",
+			cmdline:       strings.Join(parts[4:], " "),
+			isToolProcess: len(parts) > 5 && parts[5] == "run",
+		})
+	}
+	slices.SortFunc(procs, func(a, b processInfo) int { return cmp.Compare(a.pid, b.pid) })
+	return procs
+}
+
+// parseEtime parses ps's etime format, [[dd-]hh:]mm:ss.
+func parseEtime(s string) (time.Duration, bool) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, false
+		}
+		days, s = n, rest
+	}
+	var secs int
+	for _, f := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(days*86400+secs) * time.Second, true
+}
+
+// dockerContainerProcs asks a container for its opencode processes'
+// cwds and PID files, in PID order. nil if the container can't run sh.
+func dockerContainerProcs(ctx context.Context, id string) []containerProc {
+	out, err := exec.CommandContext(ctx, "docker", "exec", id, "sh", "-c", containerProcScript).Output()
+	if err != nil {
+		return nil
+	}
+	var procs []containerProc
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cp := containerProc{pid: pid, cwd: fields[1]}
+		if sid := strings.TrimSpace(fields[2]); strings.HasPrefix(sid, "ses_") {
+			cp.sessionID = sid
+		}
+		procs = append(procs, cp)
+	}
+	slices.SortFunc(procs, func(a, b containerProc) int { return cmp.Compare(a.pid, b.pid) })
+	return procs
+}
+
+// cwdMounts returns the mounts holding a container path. all of them
+// when the path isn't known, none when it's outside every bind mount.
+func cwdMounts(mounts []dockerMount, cwd string) []dockerMount {
+	if cwd == "" {
+		return mounts
+	}
+	var holding []dockerMount
+	for _, mnt := range mounts {
+		if dirUnder(cwd, mnt.destination) {
+			holding = append(holding, mnt)
+		}
+	}
+	return holding
+}
+
+// hostPathIn translates a container path to the host through the
+// deepest bind mount holding it.
+func hostPathIn(mounts []dockerMount, path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	holding := cwdMounts(mounts, path)
+	if len(holding) == 0 {
+		return "", false
+	}
+	best := slices.MaxFunc(holding, func(a, b dockerMount) int {
+		return cmp.Compare(len(a.destination), len(b.destination))
+	})
+	rel, _ := filepath.Rel(best.destination, path)
+	return filepath.Join(best.source, rel), true
+}
+
+// dockerMounts lists a container's bind mounts.
+func dockerMounts(ctx context.Context, id string) []dockerMount {
+	out, err := exec.CommandContext(ctx, "docker", "inspect", "--format",
+		`{{range .Mounts}}{{if eq .Type "bind"}}{{.Source}}{{"\t"}}{{.Destination}}{{"\n"}}{{end}}{{end}}`, id).Output()
+	if err != nil {
+		return nil
+	}
+	var mounts []dockerMount
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if src, dst, ok := strings.Cut(line, "\t"); ok {
+			mounts = append(mounts, dockerMount{source: src, destination: dst})
+		}
+	}
+	return mounts
+}
+
+// containerWorkspace picks the host directory to show for a container's
+// process: the first bind mount that isn't opencode's own data.
+func containerWorkspace(mounts []dockerMount) string {
+	for _, mnt := range mounts {
+		if !strings.Contains(mnt.source, "opencode") {
+			return mnt.source
+		}
+	}
+	return "?"
+}
@@ -8,28 +8,53 @@
 package main
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// correlateAllSessions pairs each opencode process with its session.
-// session IDs are set on processInfo by readSessionFromPidFile during
-// process discovery; this function just looks up the session data from the db.
-func correlateAllSessions() ([]processInfo, []correlatedSession) {
-	processes := getOpencodeProcesses()
-
-	var correlated []correlatedSession
-	for _, proc := range processes {
-		var session *sessionInfo
-		if proc.sessionID != "" && !proc.isToolProcess {
-			session = getSessionInfo(proc.sessionID)
+// correlateAllSessions pairs each agent process with its session,
+// backend by backend (backend.go). session IDs are set on processInfo
+// during process discovery (for opencode, by readSessionFromPidFile);
+// this function just looks up the session data.
+// db errors don't drop processes; they're joined and returned alongside.
+func correlateAllSessions() ([]processInfo, []correlatedSession, error) {
+	var (
+		processes  []processInfo
+		correlated []correlatedSession
+		errs       []error
+	)
+	for _, backend := range enabledBackends() {
+		procs := backend.listProcesses()
+		processes = append(processes, procs...)
+		for _, proc := range procs {
+			if !watchingSession(proc.sessionID) {
+				continue // --session: don't even look it up
+			}
+			var session *sessionInfo
+			if proc.sessionID != "" && !proc.isToolProcess {
+				var err error
+				done := timeStep("db: session info")
+				session, err = backend.getSession(proc.sessionID)
+				done()
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			cs := correlatedSession{
+				process: proc,
+				session: session,
+			}
+			if watching(cs) {
+				correlated = append(correlated, cs)
+			}
 		}
-		correlated = append(correlated, correlatedSession{
-			process: proc,
-			session: session,
-		})
 	}
 
-	return processes, correlated
+	return processes, correlated, errors.Join(errs...)
 }
 
 // fetchAll runs all data collection concurrently.
@@ -39,39 +64,106 @@ func fetchAll() fetchResult {
 		result fetchResult
 		mu     sync.Mutex
 		wg     sync.WaitGroup
+		errs   []error
+		start  = time.Now()
 	)
+	resetTimings()
+	nextSnapshotCycle()
 
 	wg.Add(3)
 
 	// correlation: ps/lsof + per-session db queries
 	go func() {
 		defer wg.Done()
-		_, correlated := correlateAllSessions()
+		_, correlated, err := correlateAllSessions()
+		if display.paneIdle.enabled {
+			done := timeStep("pane idle check")
+			applyPaneIdle(correlated)
+			done()
+		}
 		mu.Lock()
 		result.correlated = correlated
+		errs = append(errs, err)
 		mu.Unlock()
 	}()
 
 	// stats queries
 	go func() {
 		defer wg.Done()
-		today := queryTodayStats()
-		global := queryGlobalStats()
+		today, global, err := backendStats()
 		mu.Lock()
 		result.todayStats = today
 		result.globalStats = global
+		errs = append(errs, err)
 		mu.Unlock()
 	}()
 
 	// MCP config (file I/O, fast but independent)
 	go func() {
 		defer wg.Done()
+		done := timeStep("mcp config")
 		mcp := readMCPConfig()
+		done()
 		mu.Lock()
 		result.mcpConfig = mcp
 		mu.Unlock()
 	}()
 
 	wg.Wait()
+	result.dbErr = errors.Join(errs...)
+	result.schemaErr = schemaProblem()
+	result.elapsed = time.Since(start)
+	result.timings = takeTimings()
 	return result
 }
+
+// paneIdleTargets holds the PIDs applyPaneIdle may capture: the TUI's
+// on-screen and selected rows, set before each fetch. nil captures none.
+var paneIdleTargets atomic.Pointer[map[int]bool]
+
+// setPaneIdleTargets sets the PIDs the next fetch's pane-idle check covers.
+func setPaneIdleTargets(pids map[int]bool) {
+	paneIdleTargets.Store(&pids)
+}
+
+// applyPaneIdle marks still-generating sessions as pane-idle when their
+// pane already shows the prompt. only rows on screen or selected that
+// the db reports as mid-response are captured, so the extra tmux calls
+// stay rare.
+func applyPaneIdle(correlated []correlatedSession) {
+	targets := paneIdleTargets.Load()
+	if targets == nil {
+		return
+	}
+	for _, cs := range correlated {
+		if cs.session == nil || !(*targets)[cs.process.pid] {
+			continue
+		}
+		status := inferStatus(cs.session, cs.process.cpuPercent)
+		if status != "generating" && status != "busy" && status != "stale" {
+			continue
+		}
+		lines, _ := capturePane(cs.process)
+		if lines == nil {
+			continue
+		}
+		cs.session.paneIdle = paneShowsPrompt(lines, display.paneIdle.prompt, display.paneIdle.lines)
+	}
+}
+
+// paneShowsPrompt reports whether any of the last n non-empty lines
+// of a pane capture match the prompt pattern.
+func paneShowsPrompt(lines []string, re *regexp.Regexp, n int) bool {
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < n; i-- {
+		line := strings.TrimRight(lines[i], " ")
+		if line == "" {
+			continue
+		}
+		checked++
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
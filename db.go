@@ -6,12 +6,18 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	_ "modernc.org/sqlite"
 )
@@ -22,43 +28,112 @@ import (
 var (
 	globalStatsCache    aggStats
 	globalStatsCachedAt time.Time
+	globalStatsScope    string // scopeDir() the cache was computed for
 	globalStatsMu       sync.Mutex
 	globalStatsTTL      = 30 * time.Second
 )
 
-// openDB opens a read-only connection to the opencode sqlite database.
+// queryTimeout bounds every query. a locked or corrupted db then shows
+// up as an error instead of a fetch that never returns.
+const queryTimeout = 3 * time.Second
+
+// queryContext returns a context that expires after queryTimeout.
+func queryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), queryTimeout)
+}
+
+// openDB opens a read-only connection to the primary opencode database.
 func openDB() (*sql.DB, error) {
-	path := dbPath()
+	return openDBAt(dbPath())
+}
+
+// openDBAt opens a read-only connection to the database at path.
+func openDBAt(path string) (*sql.DB, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, err
 	}
+	if display.snapshot.enabled {
+		return openSnapshot(path) // snapshot.go
+	}
 	return sql.Open("sqlite", "file:"+path+"?mode=ro")
 }
 
-// getSessionInfo fetches full session data including message aggregates.
-// returns nil if the session doesn't exist or on any error.
-func getSessionInfo(sessionID string) *sessionInfo {
-	db, err := openDB()
+// getSessionInfo looks a session up in each configured database.
+// session IDs are globally unique, so the first hit wins.
+func getSessionInfo(sessionID string) (*sessionInfo, error) {
+	var errs []error
+	for _, src := range allDBSources() {
+		session, err := getSessionInfoFrom(src.path, sessionID)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if session != nil {
+			session.source = src.label
+			return session, err
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// getSessionInfoFrom fetches full session data including message aggregates.
+// returns nil, nil if the session doesn't exist. if a secondary query
+// fails the session is still returned, partially filled, with the error.
+func getSessionInfoFrom(path, sessionID string) (*sessionInfo, error) {
+	if root, ok := storageRoot(path); ok {
+		return getSessionInfoFromStorage(root, sessionID)
+	}
+	db, err := openDBAt(path)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer db.Close()
 
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	// unchanged idle sessions come from the cache (sessioncache.go)
+	stamp, found, err := readSessionStamp(ctx, db, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", sessionID, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	if cached, ok := cachedSession(path, sessionID, stamp); ok {
+		return cached, nil
+	}
+
+	schema := schemaFor(path)
+	permissionCol := "s.permission"
+	if !schema.hasPermission {
+		permissionCol = "NULL" // older schema: every session is interactive
+	}
+
+	// noteErr keeps the first real failure; missing rows are expected
+	var firstErr error
+	noteErr := func(what string, err error) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", what, err)
+		}
+	}
+
 	var (
 		sid, title, directory, projectID, version sql.NullString
 		permission                                sql.NullString
 		sesCreated, sesUpdated                    sql.NullInt64
-		msgCount                                  sql.NullInt64
+		msgCount, userCount                       sql.NullInt64
 		totalContext, totalOutput, totalCache     sql.NullInt64
+		totalCacheWrite                           sql.NullInt64
 		totalCost                                 sql.NullFloat64
 	)
 
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT
 			s.id, s.title, s.directory, s.project_id, s.version,
-			s.permission,
+			`+permissionCol+`,
 			s.time_created, s.time_updated,
 			count(m.id),
+			sum(CASE WHEN json_extract(m.data, '$.role') = 'user' THEN 1 ELSE 0 END),
 			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
 				THEN coalesce(json_extract(m.data, '$.tokens.input'), 0)
 				   + coalesce(json_extract(m.data, '$.tokens.cache.read'), 0)
@@ -68,6 +143,9 @@ func getSessionInfo(sessionID string) *sessionInfo {
 			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
 				THEN coalesce(json_extract(m.data, '$.tokens.cache.read'), 0)
 				ELSE 0 END),
+			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+				THEN coalesce(json_extract(m.data, '$.tokens.cache.write'), 0)
+				ELSE 0 END),
 			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
 				THEN json_extract(m.data, '$.cost') ELSE 0 END)
 		FROM session s
@@ -78,11 +156,14 @@ func getSessionInfo(sessionID string) *sessionInfo {
 		&sid, &title, &directory, &projectID, &version,
 		&permission,
 		&sesCreated, &sesUpdated,
-		&msgCount,
-		&totalContext, &totalOutput, &totalCache, &totalCost,
+		&msgCount, &userCount,
+		&totalContext, &totalOutput, &totalCache, &totalCacheWrite, &totalCost,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("session %s: %w", sessionID, err)
 	}
 
 	titleStr := title.String
@@ -100,27 +181,35 @@ func getSessionInfo(sessionID string) *sessionInfo {
 		timeCreated:       sesCreated.Int64,
 		timeUpdated:       sesUpdated.Int64,
 		messageCount:      int(msgCount.Int64),
+		roundCount:        int(userCount.Int64),
 		totalInputTokens:  totalContext.Int64,
 		totalOutputTokens: totalOutput.Int64,
 		totalCacheRead:    totalCache.Int64,
+		totalCacheWrite:   totalCacheWrite.Int64,
 		totalCost:         totalCost.Float64,
 	}
 
 	// last message: determines current state (role, finish, model, agent)
-	var lastRole, lastFinish, lastModel, lastAgent sql.NullString
+	var lastRole, lastFinish, lastModel, lastProvider, lastAgent sql.NullString
+	var lastErrName, lastErrMessage sql.NullString
 	var lastMsgTime sql.NullInt64
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT
 			json_extract(data, '$.role'),
 			json_extract(data, '$.finish'),
 			json_extract(data, '$.modelID'),
+			json_extract(data, '$.providerID'),
 			json_extract(data, '$.agent'),
+			json_extract(data, '$.error.name'),
+			json_extract(data, '$.error.data.message'),
 			time_created
 		FROM message
 		WHERE session_id = ?
 		ORDER BY time_created DESC
 		LIMIT 1
-	`, sessionID).Scan(&lastRole, &lastFinish, &lastModel, &lastAgent, &lastMsgTime)
+	`, sessionID).Scan(&lastRole, &lastFinish, &lastModel, &lastProvider, &lastAgent,
+		&lastErrName, &lastErrMessage, &lastMsgTime)
+	noteErr("last message", err)
 	if err == nil {
 		session.lastMessageRole = lastRole.String
 		if session.lastMessageRole == "" {
@@ -135,28 +224,58 @@ func getSessionInfo(sessionID string) *sessionInfo {
 		} else {
 			session.model = "?"
 		}
+		session.provider = lastProvider.String
 		if lastAgent.Valid && lastAgent.String != "" {
 			session.agent = lastAgent.String
 		} else {
 			session.agent = "?"
 		}
 		session.lastMessageTime = lastMsgTime.Int64
+		if session.lastMessageRole == "assistant" {
+			session.lastError = formatMessageError(lastErrName.String, lastErrMessage.String)
+		}
 	}
 
+	// newest part write: streaming activity for the stuck watchdog. a
+	// streaming text part is one row updated as it grows, so its
+	// time_updated moves while time_created stays put
+	var lastPart sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT max(max(time_created, coalesce(time_updated, 0))) FROM part WHERE session_id = ?
+	`, sessionID).Scan(&lastPart)
+	noteErr("last part", err)
+	session.lastPartTime = lastPart.Int64
+
 	// round start: most recent user message timestamp
 	var roundTime sql.NullInt64
-	_ = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT time_created FROM message
 		WHERE session_id = ?
 		  AND json_extract(data, '$.role') = 'user'
 		ORDER BY time_created DESC
 		LIMIT 1
 	`, sessionID).Scan(&roundTime)
+	noteErr("round start", err)
 	session.roundStartTime = roundTime.Int64
 
+	// last user input: newest user message with text the user typed.
+	// compaction and auto-continue prompts carry only synthetic text
+	var inputTime sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT max(m.time_created) FROM message m
+		WHERE m.session_id = ?
+		  AND json_extract(m.data, '$.role') = 'user'
+		  AND EXISTS (SELECT 1 FROM part p
+			WHERE p.message_id = m.id
+			  AND json_extract(p.data, '$.type') = 'text'
+			  AND coalesce(json_extract(p.data, '$.synthetic'), 0) = 0)
+	`, sessionID).Scan(&inputTime)
+	noteErr("last input", err)
+	session.lastUserInput = inputTime.Int64
+
 	// last output: last non-empty line from the most recent assistant text part
 	var lastPartData sql.NullString
-	_ = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT p.data
 		FROM part p
 		JOIN message m ON p.message_id = m.id
@@ -166,11 +285,13 @@ func getSessionInfo(sessionID string) *sessionInfo {
 		ORDER BY p.time_created DESC
 		LIMIT 1
 	`, sessionID).Scan(&lastPartData)
+	noteErr("last output", err)
 	if lastPartData.Valid {
 		var partObj map[string]any
 		if json.Unmarshal([]byte(lastPartData.String), &partObj) == nil {
 			if text, ok := partObj["text"].(string); ok {
 				text = strings.TrimSpace(text)
+				session.lastOutputFull = text
 				for _, line := range reverseLines(text) {
 					line = strings.TrimSpace(line)
 					if line != "" {
@@ -185,7 +306,7 @@ func getSessionInfo(sessionID string) *sessionInfo {
 	// pending tool: most recent tool part with status=running
 	// used to detect "asking" state (mcp_question tool waiting for input)
 	var pendingToolName sql.NullString
-	_ = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT json_extract(data, '$.tool')
 		FROM part
 		WHERE session_id = ?
@@ -194,32 +315,82 @@ func getSessionInfo(sessionID string) *sessionInfo {
 		ORDER BY time_created DESC
 		LIMIT 1
 	`, sessionID).Scan(&pendingToolName)
+	noteErr("pending tool", err)
 	if pendingToolName.Valid {
 		session.pendingTool = pendingToolName.String
 	}
 
-	// todos for the 't' panel
-	todoRows, err := db.Query(`
-		SELECT content, status, priority
-		FROM todo
+	// context compactions (see compaction.go)
+	session.compactions, session.lastCompaction, err = queryCompactions(ctx, db, sessionID)
+	noteErr("compactions", err)
+
+	// tool calls by tool (see tools.go)
+	session.toolUsage, err = queryToolUsage(ctx, db, path, sessionID, session.messageCount)
+	noteErr("tools", err)
+	session.toolCalls = totalToolCalls(session.toolUsage)
+
+	// cost of a round still running (see forecast.go)
+	if roundInFlight(session) {
+		session.roundForecast, err = queryRoundForecast(ctx, db, sessionID, session.roundStartTime)
+		noteErr("forecast", err)
+	}
+
+	// recent rounds for latency and tokens/sec (see throughput.go)
+	roundRows, err := db.QueryContext(ctx, `
+		SELECT
+			json_extract(data, '$.role'),
+			time_created,
+			coalesce(json_extract(data, '$.time.completed'), 0),
+			coalesce(json_extract(data, '$.tokens.output'), 0)
+		FROM message
 		WHERE session_id = ?
-		ORDER BY position
+		ORDER BY time_created DESC
+		LIMIT 100
 	`, sessionID)
+	noteErr("throughput", err)
 	if err == nil {
-		defer todoRows.Close()
-		for todoRows.Next() {
-			var content, status, priority string
-			if todoRows.Scan(&content, &status, &priority) == nil {
-				session.activeTodos = append(session.activeTodos, todoItem{
-					content:  content,
-					status:   status,
-					priority: priority,
-				})
+		var msgs []roundMessage
+		for roundRows.Next() {
+			var msg roundMessage
+			var role sql.NullString
+			if roundRows.Scan(&role, &msg.created, &msg.completed, &msg.tokensOut) == nil {
+				msg.role = role.String
+				msgs = append(msgs, msg)
+			}
+		}
+		roundRows.Close()
+		slices.Reverse(msgs)
+		session.avgLatencyMS, session.tokensPerSec, session.throughputRounds = roundThroughput(msgs)
+	}
+
+	// todos for the 't' panel (older schemas have no todo table)
+	if schema.hasTodo {
+		todoRows, err := db.QueryContext(ctx, `
+			SELECT content, status, priority
+			FROM todo
+			WHERE session_id = ?
+			ORDER BY position
+		`, sessionID)
+		noteErr("todos", err)
+		if err == nil {
+			defer todoRows.Close()
+			for todoRows.Next() {
+				var content, status, priority string
+				if todoRows.Scan(&content, &status, &priority) == nil {
+					session.activeTodos = append(session.activeTodos, todoItem{
+						content:  content,
+						status:   status,
+						priority: priority,
+					})
+				}
 			}
 		}
 	}
 
-	return session
+	if firstErr == nil {
+		cacheSession(path, stamp, session)
+	}
+	return session, firstErr
 }
 
 // reverseLines splits text into lines and returns them last-to-first.
@@ -231,21 +402,58 @@ func reverseLines(text string) []string {
 	return lines
 }
 
-// queryTodayStats fetches aggregate stats for sessions active today.
-func queryTodayStats() aggStats {
-	db, err := openDB()
+// queryTodayStats sums today's stats across all configured databases.
+func queryTodayStats() (aggStats, error) {
+	return sumStats(queryTodayStatsFrom)
+}
+
+// sumStats adds up a stats query over every database. failures are
+// joined; the databases that answered still count.
+func sumStats(query func(path string) (aggStats, error)) (aggStats, error) {
+	var (
+		total aggStats
+		errs  []error
+	)
+	for _, src := range allDBSources() {
+		stats, err := query(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total.sessionCount += stats.sessionCount
+		total.messageCount += stats.messageCount
+		total.totalInput += stats.totalInput
+		total.totalOutput += stats.totalOutput
+	}
+	return total, errors.Join(errs...)
+}
+
+// queryTodayStatsFrom fetches aggregate stats for sessions active today.
+func queryTodayStatsFrom(path string) (aggStats, error) {
+	if root, ok := storageRoot(path); ok {
+		return storageTodayStats(root), nil
+	}
+	db, err := openDBAt(path)
 	if err != nil {
-		return aggStats{}
+		return aggStats{}, err
 	}
 	defer db.Close()
 
+	ctx, cancel := queryContext()
+	defer cancel()
+
 	today := time.Now().Truncate(24 * time.Hour)
 	todayMS := today.UnixMilli()
 
 	var sessionCount, messageCount sql.NullInt64
 	var totalIn, totalOut sql.NullInt64
 
-	err = db.QueryRow(`
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " AND " + scopeClause
+	}
+
+	err = db.QueryRowContext(ctx, `
 		SELECT
 			count(DISTINCT s.id),
 			count(m.id),
@@ -257,10 +465,9 @@ func queryTodayStats() aggStats {
 				THEN json_extract(m.data, '$.tokens.output') ELSE 0 END)
 		FROM session s
 		LEFT JOIN message m ON m.session_id = s.id
-		WHERE s.time_updated > ?
-	`, todayMS).Scan(&sessionCount, &messageCount, &totalIn, &totalOut)
+		WHERE s.time_updated > ?`+scopeClause, append([]any{todayMS}, scopeArgs...)...).Scan(&sessionCount, &messageCount, &totalIn, &totalOut)
 	if err != nil {
-		return aggStats{}
+		return aggStats{}, fmt.Errorf("today stats: %w", err)
 	}
 
 	return aggStats{
@@ -268,39 +475,56 @@ func queryTodayStats() aggStats {
 		messageCount: int(messageCount.Int64),
 		totalInput:   totalIn.Int64,
 		totalOutput:  totalOut.Int64,
-	}
+	}, nil
 }
 
 // queryGlobalStats returns cached aggregate stats across all sessions.
 // the underlying query scans all 76k+ messages with json_extract, taking ~1.6s.
 // results are cached for 30 seconds since historical totals barely change.
-func queryGlobalStats() aggStats {
+// failures aren't cached, so the next poll retries.
+func queryGlobalStats() (aggStats, error) {
 	globalStatsMu.Lock()
 	defer globalStatsMu.Unlock()
 
-	if time.Since(globalStatsCachedAt) < globalStatsTTL {
-		return globalStatsCache
+	if time.Since(globalStatsCachedAt) < globalStatsTTL && globalStatsScope == scopeDir() {
+		return globalStatsCache, nil
 	}
 
-	result := queryGlobalStatsUncached()
+	result, err := sumStats(queryGlobalStatsUncached)
+	if err != nil {
+		return globalStatsCache, err
+	}
 	globalStatsCache = result
 	globalStatsCachedAt = time.Now()
-	return result
+	globalStatsScope = scopeDir()
+	return result, nil
 }
 
 // queryGlobalStatsUncached runs the actual expensive full-table scan.
 // caller must hold globalStatsMu.
-func queryGlobalStatsUncached() aggStats {
-	db, err := openDB()
+func queryGlobalStatsUncached(path string) (aggStats, error) {
+	if root, ok := storageRoot(path); ok {
+		return storageStats(root, 0), nil
+	}
+	db, err := openDBAt(path)
 	if err != nil {
-		return aggStats{}
+		return aggStats{}, err
 	}
 	defer db.Close()
 
+	// the full scan legitimately takes a while; allow it more headroom
+	ctx, cancel := context.WithTimeout(context.Background(), 4*queryTimeout)
+	defer cancel()
+
 	var sessionCount, messageCount sql.NullInt64
 	var totalIn, totalOut sql.NullInt64
 
-	err = db.QueryRow(`
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " WHERE " + scopeClause
+	}
+
+	err = db.QueryRowContext(ctx, `
 		SELECT
 			count(DISTINCT s.id),
 			count(m.id),
@@ -311,10 +535,9 @@ func queryGlobalStatsUncached() aggStats {
 			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
 				THEN json_extract(m.data, '$.tokens.output') ELSE 0 END)
 		FROM session s
-		LEFT JOIN message m ON m.session_id = s.id
-	`).Scan(&sessionCount, &messageCount, &totalIn, &totalOut)
+		LEFT JOIN message m ON m.session_id = s.id`+scopeClause, scopeArgs...).Scan(&sessionCount, &messageCount, &totalIn, &totalOut)
 	if err != nil {
-		return aggStats{}
+		return aggStats{}, fmt.Errorf("global stats: %w", err)
 	}
 
 	return aggStats{
@@ -322,7 +545,288 @@ func queryGlobalStatsUncached() aggStats {
 		messageCount: int(messageCount.Int64),
 		totalInput:   totalIn.Int64,
 		totalOutput:  totalOut.Int64,
+	}, nil
+}
+
+// queryHourlyActivity counts messages per hour over the last days days
+// across all databases, keyed by the hour's start in epoch ms.
+func queryHourlyActivity(days int) (map[int64]int, error) {
+	since := time.Now().AddDate(0, 0, -days).UnixMilli()
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " AND " + scopeClause
+	}
+
+	counts := make(map[int64]int)
+	var errs []error
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ctx, cancel := queryContext()
+		rows, err := db.QueryContext(ctx, `
+			SELECT m.time_created / 3600000, count(*)
+			FROM message m
+			JOIN session s ON s.id = m.session_id
+			WHERE m.time_created >= ?`+scopeClause+`
+			GROUP BY 1
+		`, append([]any{since}, scopeArgs...)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hourly activity: %w", err))
+			cancel()
+			db.Close()
+			continue
+		}
+		for rows.Next() {
+			var hour int64
+			var n int
+			if rows.Scan(&hour, &n) == nil {
+				counts[hour*3600000] += n
+			}
+		}
+		rows.Close()
+		cancel()
+		db.Close()
+	}
+	return counts, errors.Join(errs...)
+}
+
+// queryCostSince returns the cost of assistant messages since each
+// cutoff (unix ms), summed over every database, in cutoff order.
+func queryCostSince(cutoffs ...int64) ([]float64, error) {
+	costs := make([]float64, len(cutoffs))
+	if len(cutoffs) == 0 {
+		return costs, nil
+	}
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " AND " + scopeClause
+	}
+	sums := ""
+	var args []any
+	for i, c := range cutoffs {
+		if i > 0 {
+			sums += ", "
+		}
+		sums += "sum(CASE WHEN m.time_created >= ? THEN coalesce(json_extract(m.data, '$.cost'), 0) ELSE 0 END)"
+		args = append(args, c)
+	}
+	args = append(args, slices.Min(cutoffs))
+	args = append(args, scopeArgs...)
+
+	var errs []error
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ctx, cancel := queryContext()
+		vals := make([]sql.NullFloat64, len(cutoffs))
+		dest := make([]any, len(vals))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		err = db.QueryRowContext(ctx, `
+			SELECT `+sums+`
+			FROM message m
+			JOIN session s ON s.id = m.session_id
+			WHERE m.time_created >= ?
+			  AND json_extract(m.data, '$.role') = 'assistant'`+scopeClause,
+			args...).Scan(dest...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cost: %w", err))
+		}
+		for i, v := range vals {
+			costs[i] += v.Float64
+		}
+		cancel()
+		db.Close()
+	}
+	return costs, errors.Join(errs...)
+}
+
+// queryModelUsage groups assistant messages since sinceMS by model,
+// summed over every database, most expensive first.
+func queryModelUsage(sinceMS int64) ([]modelUsage, error) {
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " AND " + scopeClause
+	}
+
+	byModel := make(map[string]*modelUsage)
+	var errs []error
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ctx, cancel := queryContext()
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				coalesce(json_extract(m.data, '$.modelID'), '?'),
+				count(DISTINCT m.session_id),
+				count(m.id),
+				sum(coalesce(json_extract(m.data, '$.tokens.input'), 0)),
+				sum(coalesce(json_extract(m.data, '$.tokens.output'), 0)),
+				sum(coalesce(json_extract(m.data, '$.tokens.cache.read'), 0)),
+				sum(coalesce(json_extract(m.data, '$.cost'), 0))
+			FROM message m
+			JOIN session s ON s.id = m.session_id
+			WHERE m.time_created >= ?
+			  AND json_extract(m.data, '$.role') = 'assistant'`+scopeClause+`
+			GROUP BY 1
+		`, append([]any{sinceMS}, scopeArgs...)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("model usage: %w", err))
+			cancel()
+			db.Close()
+			continue
+		}
+		for rows.Next() {
+			var r modelUsage
+			if rows.Scan(&r.model, &r.sessions, &r.messages, &r.tokensIn, &r.tokensOut, &r.cacheRead, &r.cost) != nil {
+				continue
+			}
+			total, ok := byModel[r.model]
+			if !ok {
+				total = &modelUsage{model: r.model}
+				byModel[r.model] = total
+			}
+			total.sessions += r.sessions
+			total.messages += r.messages
+			total.tokensIn += r.tokensIn
+			total.tokensOut += r.tokensOut
+			total.cacheRead += r.cacheRead
+			total.cost += r.cost
+		}
+		rows.Close()
+		cancel()
+		db.Close()
+	}
+
+	result := make([]modelUsage, 0, len(byModel))
+	for _, r := range byModel {
+		result = append(result, *r)
+	}
+	slices.SortFunc(result, func(a, b modelUsage) int {
+		if c := cmp.Compare(b.cost, a.cost); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.tokensOut, a.tokensOut)
+	})
+	return result, errors.Join(errs...)
+}
+
+// queryPeriodStats returns usage totals for messages since each of the
+// given cutoffs (unix ms), newest cutoff first, plus an all-time total as
+// the last element. message sums come from one pass grouped by window;
+// distinct session counts need their own pass since they don't add up.
+func queryPeriodStats(cutoffs ...int64) ([]periodStats, error) {
+	scopeClause, scopeArgs := scopeSQL()
+	if scopeClause != "" {
+		scopeClause = " WHERE " + scopeClause
+	}
+
+	// bucket i holds messages newer than cutoffs[i] but not cutoffs[i-1]
+	bucket := "CASE"
+	var bucketArgs []any
+	for i, c := range cutoffs {
+		bucket += fmt.Sprintf(" WHEN m.time_created >= ? THEN %d", i)
+		bucketArgs = append(bucketArgs, c)
 	}
+	bucket += fmt.Sprintf(" ELSE %d END", len(cutoffs))
+	distinct := ""
+	for range cutoffs {
+		distinct += "count(DISTINCT CASE WHEN m.time_created >= ? THEN m.session_id END), "
+	}
+
+	buckets := make([]periodStats, len(cutoffs)+1)
+	sessions := make([]int, len(cutoffs)+1)
+	var errs []error
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// scans every message, like the global stats
+		ctx, cancel := context.WithTimeout(context.Background(), 4*queryTimeout)
+		rows, err := db.QueryContext(ctx, `
+			SELECT `+bucket+`,
+				count(m.id),
+				sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+					THEN coalesce(json_extract(m.data, '$.tokens.input'), 0) ELSE 0 END),
+				sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+					THEN coalesce(json_extract(m.data, '$.tokens.output'), 0) ELSE 0 END),
+				sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+					THEN coalesce(json_extract(m.data, '$.tokens.cache.read'), 0) ELSE 0 END),
+				sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+					THEN coalesce(json_extract(m.data, '$.tokens.cache.write'), 0) ELSE 0 END),
+				sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+					THEN coalesce(json_extract(m.data, '$.cost'), 0) ELSE 0 END)
+			FROM message m
+			JOIN session s ON s.id = m.session_id`+scopeClause+`
+			GROUP BY 1
+		`, append(bucketArgs, scopeArgs...)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("period stats: %w", err))
+			cancel()
+			db.Close()
+			continue
+		}
+		for rows.Next() {
+			var i int
+			var p periodStats
+			if rows.Scan(&i, &p.messages, &p.tokensIn, &p.tokensOut, &p.cacheRead, &p.cacheWrite, &p.cost) == nil && i < len(buckets) {
+				b := &buckets[i]
+				b.messages += p.messages
+				b.tokensIn += p.tokensIn
+				b.tokensOut += p.tokensOut
+				b.cacheRead += p.cacheRead
+				b.cacheWrite += p.cacheWrite
+				b.cost += p.cost
+			}
+		}
+		rows.Close()
+
+		counts := make([]any, len(sessions))
+		vals := make([]sql.NullInt64, len(sessions))
+		for i := range vals {
+			counts[i] = &vals[i]
+		}
+		err = db.QueryRowContext(ctx, `
+			SELECT `+distinct+`count(DISTINCT m.session_id)
+			FROM message m
+			JOIN session s ON s.id = m.session_id`+scopeClause,
+			append(bucketArgs, scopeArgs...)...).Scan(counts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("period sessions: %w", err))
+		}
+		for i, v := range vals {
+			sessions[i] += int(v.Int64)
+		}
+		cancel()
+		db.Close()
+	}
+
+	// windows are cumulative: "this week" includes today
+	result := make([]periodStats, len(buckets))
+	var running periodStats
+	for i, b := range buckets {
+		running.messages += b.messages
+		running.tokensIn += b.tokensIn
+		running.tokensOut += b.tokensOut
+		running.cacheRead += b.cacheRead
+		running.cacheWrite += b.cacheWrite
+		running.cost += b.cost
+		result[i] = running
+		result[i].sessions = sessions[i]
+	}
+	return result, errors.Join(errs...)
 }
 
 // readMCPConfig reads MCP server definitions from global opencode.json.
@@ -341,22 +845,54 @@ func readMCPConfig() map[string]any {
 	return nil
 }
 
-// getRecentMessages fetches recent messages for the detail view.
-// returns messages in chronological order (oldest first).
-func getRecentMessages(sessionID string, limit int) []messageDetail {
-	db, err := openDB()
+// getRecentMessages fetches a page of messages for the detail view from
+// the first database that has any: limit messages, skipping the offset
+// newest. returns them oldest first.
+func getRecentMessages(sessionID string, limit, offset int) []messageDetail {
+	for _, src := range allDBSources() {
+		if messages := getRecentMessagesFrom(src.path, sessionID, limit, offset); len(messages) > 0 {
+			return messages
+		}
+	}
+	return nil
+}
+
+// getRecentMessagesFrom reads a page of messages with the first text part
+// of each, in one query: a window function picks each message's first
+// text part instead of a lookup per message.
+func getRecentMessagesFrom(path, sessionID string, limit, offset int) []messageDetail {
+	if root, ok := storageRoot(path); ok {
+		return getRecentMessagesFromStorage(root, sessionID, limit, offset)
+	}
+	db, err := openDBAt(path)
 	if err != nil {
 		return nil
 	}
 	defer db.Close()
 
-	rows, err := db.Query(`
-		SELECT data, time_created
-		FROM message
-		WHERE session_id = ?
-		ORDER BY time_created DESC
-		LIMIT ?
-	`, sessionID, limit)
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		WITH recent AS (
+			SELECT id, data, time_created
+			FROM message
+			WHERE session_id = ?
+			ORDER BY time_created DESC
+			LIMIT ? OFFSET ?
+		),
+		first_text AS (
+			SELECT p.message_id, json_extract(p.data, '$.text') AS text,
+				row_number() OVER (PARTITION BY p.message_id ORDER BY p.time_created, p.id) AS n
+			FROM part p
+			JOIN recent r ON p.message_id = r.id
+			WHERE json_extract(p.data, '$.type') = 'text'
+		)
+		SELECT r.data, r.time_created, f.text
+		FROM recent r
+		LEFT JOIN first_text f ON f.message_id = r.id AND f.n = 1
+		ORDER BY r.time_created ASC
+	`, sessionID, limit, offset)
 	if err != nil {
 		return nil
 	}
@@ -366,7 +902,8 @@ func getRecentMessages(sessionID string, limit int) []messageDetail {
 	for rows.Next() {
 		var dataStr string
 		var timeCreated int64
-		if rows.Scan(&dataStr, &timeCreated) != nil {
+		var text sql.NullString
+		if rows.Scan(&dataStr, &timeCreated, &text) != nil {
 			continue
 		}
 		var d map[string]any
@@ -374,7 +911,11 @@ func getRecentMessages(sessionID string, limit int) []messageDetail {
 			continue
 		}
 
-		msg := messageDetail{
+		preview := text.String
+		if len(preview) > 200 {
+			preview = preview[:200]
+		}
+		messages = append(messages, messageDetail{
 			role:        jsonStr(d, "role"),
 			finish:      jsonStr(d, "finish"),
 			model:       jsonStr(d, "modelID"),
@@ -382,40 +923,428 @@ func getRecentMessages(sessionID string, limit int) []messageDetail {
 			tokensOut:   jsonInt(d, "tokens", "output"),
 			cacheRead:   jsonInt(d, "tokens", "cache", "read"),
 			timeCreated: timeCreated,
+			textPreview: preview,
+		})
+	}
+	return messages
+}
+
+// getSessionMessages fetches every message in a session for export
+// from the first database that has any. returns them oldest first.
+func getSessionMessages(sessionID string) ([]messageRecord, error) {
+	var errs []error
+	for _, src := range allDBSources() {
+		messages, err := getSessionMessagesFrom(src.path, sessionID)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(messages) > 0 {
+			return messages, nil
 		}
+	}
+	return nil, errors.Join(errs...)
+}
 
-		// fetch first text part for preview
-		var partData sql.NullString
-		err := db.QueryRow(`
-			SELECT p.data FROM part p
-			JOIN message m ON p.message_id = m.id
-			WHERE p.session_id = ?
-			  AND m.time_created = ?
-			  AND json_extract(p.data, '$.type') = 'text'
-			ORDER BY p.time_created ASC
-			LIMIT 1
-		`, sessionID, timeCreated).Scan(&partData)
-		if err == nil && partData.Valid {
-			var partObj map[string]any
-			if json.Unmarshal([]byte(partData.String), &partObj) == nil {
-				if text, ok := partObj["text"].(string); ok {
-					if len(text) > 200 {
-						text = text[:200]
-					}
-					msg.textPreview = text
+func getSessionMessagesFrom(path, sessionID string) ([]messageRecord, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, data, time_created
+		FROM message
+		WHERE session_id = ?
+		ORDER BY time_created ASC
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []messageRecord
+	for rows.Next() {
+		var id, dataStr string
+		var timeCreated int64
+		if rows.Scan(&id, &dataStr, &timeCreated) != nil {
+			continue
+		}
+		var d map[string]any
+		if json.Unmarshal([]byte(dataStr), &d) != nil {
+			continue
+		}
+		messages = append(messages, messageRecord{
+			id:            id,
+			role:          jsonStr(d, "role"),
+			agent:         jsonStr(d, "agent"),
+			provider:      jsonStr(d, "providerID"),
+			model:         jsonStr(d, "modelID"),
+			finish:        jsonStr(d, "finish"),
+			tokensIn:      jsonInt(d, "tokens", "input"),
+			tokensOut:     jsonInt(d, "tokens", "output"),
+			reasoning:     jsonInt(d, "tokens", "reasoning"),
+			cacheRead:     jsonInt(d, "tokens", "cache", "read"),
+			cacheWrite:    jsonInt(d, "tokens", "cache", "write"),
+			cost:          jsonFloat(d, "cost"),
+			timeCreated:   timeCreated,
+			timeCompleted: jsonInt(d, "time", "completed"),
+		})
+	}
+	return messages, rows.Err()
+}
+
+// sessionsUnder returns the most recently updated sessions whose
+// directory is one of dirs or below one of them, across all databases,
+// newest first, at most limit, with how many of their messages were
+// written since sinceMS. used for container processes, which have no
+// PID file the host can read (containers.go).
+func sessionsUnder(dirs []string, sinceMS int64, limit int) ([]sessionCandidate, error) {
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	var conds []string
+	args := []any{sinceMS}
+	for _, d := range dirs {
+		prefix := strings.TrimSuffix(d, "/") + "/"
+		conds = append(conds, "s.directory = ? OR substr(s.directory, 1, ?) = ?")
+		args = append(args, strings.TrimSuffix(d, "/"), utf8.RuneCountInString(prefix), prefix)
+	}
+	args = append(args, limit)
+
+	var (
+		found []sessionCandidate
+		errs  []error
+	)
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ctx, cancel := queryContext()
+		rows, err := db.QueryContext(ctx, `
+			SELECT s.id, s.title, s.time_created, s.time_updated,
+				count(m.id), count(CASE WHEN m.time_created >= ? THEN 1 END)
+			FROM session s
+			LEFT JOIN message m ON m.session_id = s.id
+			WHERE `+strings.Join(conds, " OR ")+`
+			GROUP BY s.id
+			ORDER BY s.time_updated DESC
+			LIMIT ?
+		`, args...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("container session: %w", err))
+		} else {
+			for rows.Next() {
+				var c sessionCandidate
+				if rows.Scan(&c.id, &c.title, &c.created, &c.updated, &c.messages, &c.recent) == nil {
+					found = append(found, c)
 				}
 			}
+			rows.Close()
+		}
+		cancel()
+		db.Close()
+	}
+	slices.SortFunc(found, func(a, b sessionCandidate) int { return cmp.Compare(b.updated, a.updated) })
+	return found[:min(limit, len(found))], errors.Join(errs...)
+}
+
+// queryProjectDirs lists session directories across all databases,
+// most recently active first.
+func queryProjectDirs() ([]string, error) {
+	type dirTime struct {
+		dir     string
+		updated int64
+	}
+	var (
+		all  []dirTime
+		seen = make(map[string]int)
+		errs []error
+	)
+	for _, src := range allDBSources() {
+		db, err := openDBAt(src.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ctx, cancel := queryContext()
+		rows, err := db.QueryContext(ctx, `
+			SELECT directory, max(time_updated)
+			FROM session
+			WHERE directory != ''
+			GROUP BY directory
+		`)
+		if err != nil {
+			errs = append(errs, err)
+			cancel()
+			db.Close()
+			continue
+		}
+		for rows.Next() {
+			var dir string
+			var updated int64
+			if rows.Scan(&dir, &updated) != nil {
+				continue
+			}
+			if i, ok := seen[dir]; ok {
+				all[i].updated = max(all[i].updated, updated)
+				continue
+			}
+			seen[dir] = len(all)
+			all = append(all, dirTime{dir, updated})
 		}
+		rows.Close()
+		cancel()
+		db.Close()
+	}
+	slices.SortFunc(all, func(a, b dirTime) int {
+		return cmp.Compare(b.updated, a.updated)
+	})
+	dirs := make([]string, len(all))
+	for i, d := range all {
+		dirs[i] = d.dir
+	}
+	return dirs, errors.Join(errs...)
+}
 
-		messages = append(messages, msg)
+// queryRetroSessions returns per-session activity across all databases.
+func queryRetroSessions(sinceMS int64) ([]retroSession, error) {
+	var (
+		result []retroSession
+		errs   []error
+	)
+	for _, src := range allDBSources() {
+		sessions, err := queryRetroSessionsFrom(src.path, sinceMS)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		result = append(result, sessions...)
 	}
+	return result, errors.Join(errs...)
+}
 
-	// reverse for chronological order
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+// queryRetroSessionsFrom returns per-session activity for messages created
+// after sinceMS. error count is assistant messages carrying an error field.
+func queryRetroSessionsFrom(path string, sinceMS int64) ([]retroSession, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, err
 	}
+	defer db.Close()
 
-	return messages
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			s.id, s.title, s.directory,
+			count(m.id),
+			sum(CASE WHEN json_extract(m.data, '$.error') IS NOT NULL THEN 1 ELSE 0 END),
+			sum(coalesce(json_extract(m.data, '$.cost'), 0)),
+			max(m.time_created) - min(m.time_created)
+		FROM session s
+		JOIN message m ON m.session_id = s.id
+		WHERE m.time_created > ?
+		GROUP BY s.id
+	`, sinceMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []retroSession
+	for rows.Next() {
+		var (
+			sid, title, directory sql.NullString
+			msgs, errs, duration  sql.NullInt64
+			cost                  sql.NullFloat64
+		)
+		if rows.Scan(&sid, &title, &directory, &msgs, &errs, &cost, &duration) != nil {
+			continue
+		}
+		titleStr := title.String
+		if titleStr == "" {
+			titleStr = "(untitled)"
+		}
+		result = append(result, retroSession{
+			sessionID:  sid.String,
+			title:      titleStr,
+			directory:  directory.String,
+			messages:   int(msgs.Int64),
+			errors:     int(errs.Int64),
+			cost:       cost.Float64,
+			durationMS: duration.Int64,
+		})
+	}
+	return result, rows.Err()
+}
+
+// queryRetroProjects merges per-directory activity across all databases,
+// ranked by message volume.
+func queryRetroProjects(sinceMS int64) ([]retroProject, error) {
+	var (
+		result []retroProject
+		index  = make(map[string]int)
+		errs   []error
+	)
+	for _, src := range allDBSources() {
+		projects, err := queryRetroProjectsFrom(src.path, sinceMS)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, p := range projects {
+			i, ok := index[p.directory]
+			if !ok {
+				index[p.directory] = len(result)
+				result = append(result, p)
+				continue
+			}
+			result[i].sessions += p.sessions
+			result[i].messages += p.messages
+			result[i].cost += p.cost
+		}
+	}
+	slices.SortStableFunc(result, func(a, b retroProject) int {
+		return cmp.Compare(b.messages, a.messages)
+	})
+	return result, errors.Join(errs...)
+}
+
+// queryRetroProjectsFrom ranks directories by message volume since sinceMS.
+func queryRetroProjectsFrom(path string, sinceMS int64) ([]retroProject, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			s.directory,
+			count(DISTINCT s.id),
+			count(m.id),
+			sum(coalesce(json_extract(m.data, '$.cost'), 0))
+		FROM session s
+		JOIN message m ON m.session_id = s.id
+		WHERE m.time_created > ?
+		GROUP BY s.directory
+		ORDER BY count(m.id) DESC
+	`, sinceMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []retroProject
+	for rows.Next() {
+		var directory sql.NullString
+		var sessions, msgs sql.NullInt64
+		var cost sql.NullFloat64
+		if rows.Scan(&directory, &sessions, &msgs, &cost) != nil {
+			continue
+		}
+		result = append(result, retroProject{
+			directory: directory.String,
+			sessions:  int(sessions.Int64),
+			messages:  int(msgs.Int64),
+			cost:      cost.Float64,
+		})
+	}
+	return result, rows.Err()
+}
+
+// queryRetroDays merges per-day totals across all databases, oldest first.
+func queryRetroDays(sinceMS int64) ([]retroDay, error) {
+	var (
+		result []retroDay
+		index  = make(map[string]int)
+		errs   []error
+	)
+	for _, src := range allDBSources() {
+		days, err := queryRetroDaysFrom(src.path, sinceMS)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, d := range days {
+			i, ok := index[d.day]
+			if !ok {
+				index[d.day] = len(result)
+				result = append(result, d)
+				continue
+			}
+			result[i].sessions += d.sessions
+			result[i].messages += d.messages
+			result[i].tokensIn += d.tokensIn
+			result[i].tokensOut += d.tokensOut
+			result[i].cost += d.cost
+		}
+	}
+	slices.SortFunc(result, func(a, b retroDay) int {
+		return cmp.Compare(a.day, b.day)
+	})
+	return result, errors.Join(errs...)
+}
+
+// queryRetroDaysFrom returns per-day totals since sinceMS, oldest first.
+// days are bucketed in local time so they line up with the calendar.
+func queryRetroDaysFrom(path string, sinceMS int64) ([]retroDay, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			date(m.time_created / 1000, 'unixepoch', 'localtime') AS day,
+			count(DISTINCT m.session_id),
+			count(m.id),
+			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+				THEN coalesce(json_extract(m.data, '$.tokens.input'), 0)
+				   + coalesce(json_extract(m.data, '$.tokens.cache.read'), 0)
+				ELSE 0 END),
+			sum(CASE WHEN json_extract(m.data, '$.role') = 'assistant'
+				THEN coalesce(json_extract(m.data, '$.tokens.output'), 0) ELSE 0 END),
+			sum(coalesce(json_extract(m.data, '$.cost'), 0))
+		FROM message m
+		WHERE m.time_created > ?
+		GROUP BY day
+		ORDER BY day ASC
+	`, sinceMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []retroDay
+	for rows.Next() {
+		var day sql.NullString
+		var sessions, msgs, tokIn, tokOut sql.NullInt64
+		var cost sql.NullFloat64
+		if rows.Scan(&day, &sessions, &msgs, &tokIn, &tokOut, &cost) != nil {
+			continue
+		}
+		result = append(result, retroDay{
+			day:       day.String,
+			sessions:  int(sessions.Int64),
+			messages:  int(msgs.Int64),
+			tokensIn:  tokIn.Int64,
+			tokensOut: tokOut.Int64,
+			cost:      cost.Float64,
+		})
+	}
+	return result, rows.Err()
 }
 
 // -- json helpers --
@@ -446,3 +1375,22 @@ func jsonInt(m map[string]any, keys ...string) int64 {
 	}
 	return 0
 }
+
+// jsonFloat extracts a float64 from a nested JSON map path.
+func jsonFloat(m map[string]any, keys ...string) float64 {
+	current := m
+	for i, key := range keys {
+		if i == len(keys)-1 {
+			if v, ok := current[key].(float64); ok {
+				return v
+			}
+			return 0
+		}
+		if sub, ok := current[key].(map[string]any); ok {
+			current = sub
+		} else {
+			return 0
+		}
+	}
+	return 0
+}
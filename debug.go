@@ -0,0 +1,128 @@
+// debug overlay (D): where the last fetch spent its time and how
+// processes correlated, for diagnosing slow or lagging refreshes.
+//
+// collection steps record their wall time with timeStep; repeated steps
+// (one db lookup per session) are summed with a count. fetchAll resets
+// the recorder at the start of a fetch and snapshots it into the result.
+// fetches don't overlap (see requestFetch), so one recorder suffices.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stepTiming is the accumulated time of one collection step.
+type stepTiming struct {
+	name  string
+	total time.Duration
+	count int
+}
+
+var (
+	timingsMu sync.Mutex
+	timings   []stepTiming
+)
+
+// timeStep starts timing a step; call the result when it ends:
+//
+//	defer timeStep("lsof")()
+func timeStep(name string) func() {
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		timingsMu.Lock()
+		defer timingsMu.Unlock()
+		for i := range timings {
+			if timings[i].name == name {
+				timings[i].total += d
+				timings[i].count++
+				return
+			}
+		}
+		timings = append(timings, stepTiming{name: name, total: d, count: 1})
+	}
+}
+
+// resetTimings clears the recorder before a fetch.
+func resetTimings() {
+	timingsMu.Lock()
+	timings = nil
+	timingsMu.Unlock()
+}
+
+// takeTimings returns a copy of what the current fetch recorded.
+func takeTimings() []stepTiming {
+	timingsMu.Lock()
+	defer timingsMu.Unlock()
+	return append([]stepTiming(nil), timings...)
+}
+
+// correlationTiers counts processes by how they correlated.
+func correlationTiers(correlated []correlatedSession) (matched, dbMiss, noPidFile, tools int) {
+	for _, cs := range correlated {
+		switch {
+		case cs.process.isToolProcess:
+			tools++
+		case cs.session != nil:
+			matched++
+		case cs.process.sessionID != "":
+			dbMiss++
+		default:
+			noPidFile++
+		}
+	}
+	return
+}
+
+// renderDebugView shows the last fetch's step timings and tier counts.
+func (m model) renderDebugView() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(truncOrPad(" opencode > sessions > debug", m.width)))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(" steps run concurrently; per-step times overlap and don't add up to the total"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	b.WriteString(panelStyle.Render(" LAST FETCH"))
+	b.WriteString("\n")
+	for _, t := range m.lastTimings {
+		line := fmt.Sprintf("  %-22s %8s", t.name, t.total.Round(time.Millisecond))
+		if t.count > 1 {
+			line += dimStyle.Render(fmt.Sprintf("  %d calls, %s avg", t.count, (t.total / time.Duration(t.count)).Round(time.Millisecond)))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	total := fmt.Sprintf("  %-22s %8s", "total", m.lastFetchDur.Round(time.Millisecond))
+	if m.lastFetchDur > refreshInterval {
+		total = errorStyle.Render(total) + dimStyle.Render(fmt.Sprintf("  longer than the %s refresh interval", refreshInterval))
+	}
+	b.WriteString(total)
+	b.WriteString("\n\n")
+
+	matched, dbMiss, noPidFile, tools := correlationTiers(m.sessions)
+	b.WriteString(panelStyle.Render(" CORRELATION"))
+	b.WriteString("\n")
+	for _, tier := range []struct {
+		label string
+		count int
+		note  string
+	}{
+		{"pid file + db", matched, "session resolved"},
+		{"pid file, no db row", dbMiss, "session missing from every database"},
+		{"no pid file", noPidFile, "plugin not loaded in that process"},
+		{"tool processes", tools, "opencode run (LSPs, wrappers)"},
+	} {
+		fmt.Fprintf(&b, "  %-22s %8d  %s\n", tier.label, tier.count, dimStyle.Render(tier.note))
+	}
+	fmt.Fprintf(&b, "  %-22s %8d\n\n", "processes", len(m.sessions))
+
+	b.WriteString(" " + keyStyle.Render("esc") + " " + helpStyle.Render("close"))
+	return b.String()
+}
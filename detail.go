@@ -1,7 +1,8 @@
-// detail view: tmux pane capture and db message display.
+// detail view: pane capture and db message display.
 //
 // pressing enter on a session opens a full-screen detail view.
-// primary: captures the live terminal via tmux. fallback: db messages.
+// primary: captures the live terminal via a pane provider (tmux, zellij).
+// fallback: db messages.
 
 package main
 
@@ -11,34 +12,35 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
 // -- tmux integration --
 
 // tmuxPaneForTTY maps a TTY name (e.g. "ttys005") to a tmux pane target.
+// it lists the panes fresh rather than using the per-cycle cache, so a
+// command never goes to a pane that has closed since.
 func tmuxPaneForTTY(tty string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	out, err := exec.CommandContext(ctx, "tmux", "list-panes", "-a", "-F",
-		"#{pane_tty} #{session_name}:#{window_index}.#{pane_index}").Output()
-	if err != nil {
+	if !tmuxInstalled() {
 		return ""
 	}
-
-	devTTY := "/dev/" + tty
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) == 2 && parts[0] == devTTY {
-			return parts[1]
-		}
-	}
-	return ""
+	return listTmuxPanes()[tty].paneID
 }
 
 // captureTmuxPane captures the screen content of a tmux pane by TTY.
 // returns nil if tmux isn't available or the TTY isn't in a pane.
 func captureTmuxPane(tty string) []string {
+	return captureTmuxPaneWith(tty)
+}
+
+// captureTmuxPaneANSI is captureTmuxPane with color and attribute
+// escape sequences kept (capture-pane -e).
+func captureTmuxPaneANSI(tty string) []string {
+	return captureTmuxPaneWith(tty, "-e")
+}
+
+func captureTmuxPaneWith(tty string, flags ...string) []string {
 	target := tmuxPaneForTTY(tty)
 	if target == "" {
 		return nil
@@ -46,7 +48,8 @@ func captureTmuxPane(tty string) []string {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	out, err := exec.CommandContext(ctx, "tmux", "capture-pane", "-t", target, "-p").Output()
+	args := append([]string{"capture-pane", "-t", target, "-p"}, flags...)
+	out, err := exec.CommandContext(ctx, "tmux", args...).Output()
 	if err != nil {
 		return nil
 	}
@@ -95,6 +98,81 @@ func formatDBMessages(msgs []messageDetail) []string {
 	return lines
 }
 
+// -- preview pane (split view) --
+
+// previewHeight is the number of lines the preview split takes,
+// including its separator and title line.
+func (m model) previewHeight() int {
+	return max(4, m.height/2)
+}
+
+// renderPreviewPane renders the bottom half of the split view: the tail
+// of the selected session's pane capture or recent db messages.
+func (m model) renderPreviewPane() string {
+	var b strings.Builder
+	height := m.previewHeight()
+
+	b.WriteString(dimStyle.Render(strings.Repeat("\u2500", m.width)))
+	b.WriteString("\n")
+
+	title := " preview"
+	if cs, ok := m.selectedSession(); ok && cs.session != nil {
+		title += " > " + sessionTitle(cs.session)
+	}
+	if m.previewSource != "" {
+		title += " [" + m.previewSource + "]"
+	}
+	if len(title) > m.width && m.width > 0 {
+		title = title[:m.width]
+	}
+	b.WriteString(panelStyle.Render(title))
+	b.WriteString("\n")
+
+	// show the tail: the bottom of a pane is where the action is
+	lines := m.previewLines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	contentRows := height - 2
+	start := max(0, len(lines)-contentRows)
+	for i := start; i < start+contentRows; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		if len(line) > m.width && m.width > 0 {
+			line = line[:m.width]
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// detailChildRows caps the child process breakdown so the content
+// below it keeps most of the screen.
+const detailChildRows = 8
+
+// childProcessLines renders proc's descendants as an indented tree with
+// a totals line on top.
+func (m model) childProcessLines(proc processInfo) []string {
+	lines := []string{fmt.Sprintf(" children: %d  cpu %s  mem %s  (self %s, %s)",
+		len(proc.children),
+		formatCPU(proc.totalCPU()-proc.cpuPercent), formatMem(proc.totalMemMB()-proc.memMB),
+		formatCPU(proc.cpuPercent), formatMem(proc.memMB))}
+	for i, c := range proc.children {
+		if i == detailChildRows-1 && len(proc.children) > detailChildRows {
+			lines = append(lines, fmt.Sprintf("   ... %d more", len(proc.children)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf(" %7d %6s %6s %s%s",
+			c.pid, formatCPU(c.cpuPercent), formatMem(c.memMB),
+			strings.Repeat("  ", c.depth-1), c.args))
+	}
+	return lines
+}
+
 // -- detail view rendering --
 
 func (m model) renderDetailView() string {
@@ -108,7 +186,7 @@ func (m model) renderDetailView() string {
 	sid := "-"
 	status := "?"
 	if session != nil {
-		title = session.title
+		title = sessionLabel(session)
 		sid = session.sessionID
 		status = inferStatus(session, proc.cpuPercent)
 	}
@@ -119,7 +197,10 @@ func (m model) renderDetailView() string {
 
 	crumb := fmt.Sprintf(" opencode > sessions > %s %s", sid, sourceTag)
 	right := status + " "
-	pad := max(0, m.width-len(crumb)-len(right))
+	if f := forecastLabel(session); f != "" {
+		right = f + "  " + right
+	}
+	pad := max(0, m.width-lipgloss.Width(crumb)-lipgloss.Width(right))
 	headerLine := crumb + strings.Repeat(" ", pad) + right
 	if len(headerLine) > m.width && m.width > 0 {
 		headerLine = headerLine[:m.width]
@@ -138,6 +219,21 @@ func (m model) renderDetailView() string {
 		infoParts = append(infoParts, fmt.Sprintf("pid:%d", proc.pid))
 		infoParts = append(infoParts, fmt.Sprintf("tty:%s", proc.tty))
 		infoParts = append(infoParts, shortPath(proc.cwd, 30))
+		if active := max(session.lastMessageTime, session.lastPartTime); active > 0 {
+			infoParts = append(infoParts, "active "+formatClock(active))
+		}
+		if session.throughputRounds > 0 {
+			infoParts = append(infoParts, fmt.Sprintf("latency:%s", formatDuration(session.avgLatencyMS)))
+			if session.tokensPerSec > 0 {
+				infoParts = append(infoParts, fmt.Sprintf("%.0f tok/s", session.tokensPerSec))
+			}
+		}
+		if c := compactionSummary(session); c != "" {
+			infoParts = append(infoParts, c)
+		}
+		if t := toolSummary(session, 3); t != "" {
+			infoParts = append(infoParts, "tools "+t)
+		}
 	}
 	infoLine := " " + strings.Join(infoParts, "  ")
 	if len(infoLine) > m.width && m.width > 0 {
@@ -146,28 +242,106 @@ func (m model) renderDetailView() string {
 	b.WriteString(dimStyle.Render(infoLine))
 	b.WriteString("\n")
 
+	// invocation flags, with warnings where they disagree with the db
+	invocParts := m.detailInvoc.parts()
+	warnings := m.detailInvoc.mismatches(session)
+	hasInvoc := len(invocParts) > 0 || len(warnings) > 0
+	if hasInvoc {
+		line := " " + dimStyle.Render(strings.Join(invocParts, "  "))
+		for _, w := range warnings {
+			line += "  " + errorStyle.Render("! "+w)
+		}
+		b.WriteString(lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+		b.WriteString("\n")
+	}
+
+	// errors: the failed message's error, then the log's newest ERROR line
+	var errLines []string
+	if session != nil && session.lastError != "" && !messageAborted(session.lastError) {
+		errLines = append(errLines, "✗ error: "+session.lastError)
+	}
+	if m.detailLogErr != "" {
+		errLines = append(errLines, "✗ log: "+m.detailLogErr)
+	}
+	if line := m.competitorLine(*m.detailSession); line != "" {
+		errLines = append(errLines, line)
+	}
+	if line := candidateLine(*m.detailSession); line != "" {
+		errLines = append(errLines, line)
+	}
+	for _, line := range errLines {
+		b.WriteString(errorStyle.Render(truncOrPad(" "+line, m.width)))
+		b.WriteString("\n")
+	}
+
+	// newest tool call's change, if it edited a file (see editpreview.go)
+	editLines := m.editPreviewLines()
+	joinLines(&b, editLines)
+
+	// session metadata (I)
+	var metaLines []string
+	if m.detailMeta {
+		metaLines = m.metadataLines()
+		for _, line := range metaLines {
+			b.WriteString(dimStyle.Render(truncOrPad(line, m.width)))
+			b.WriteString("\n")
+		}
+	}
+
+	// child processes (P), in tree order
+	var procLines []string
+	if m.detailProcs {
+		procLines = m.childProcessLines(proc)
+		for _, line := range procLines {
+			b.WriteString(dimStyle.Render(truncOrPad(line, m.width)))
+			b.WriteString("\n")
+		}
+	}
+
 	// separator
 	b.WriteString(dimStyle.Render(strings.Repeat("\u2500", m.width)))
 	b.WriteString("\n")
 
 	// scrollable content
 	contentRows := max(1, m.height-4) // header + info + sep + footer
+	if hasInvoc {
+		contentRows = max(1, contentRows-1)
+	}
+	contentRows = max(1, contentRows-len(errLines)-len(editLines)-len(metaLines)-len(procLines))
 	end := min(m.detailScroll+contentRows, len(m.detailLines))
+	var content []string
 	for i := m.detailScroll; i < end; i++ {
 		line := m.detailLines[i]
 		if len(line) > m.width && m.width > 0 {
 			line = line[:m.width]
 		}
-		b.WriteString(line)
-		b.WriteString("\n")
+		content = append(content, line)
 	}
+	bar := scrollbar(m.detailScroll, contentRows, len(m.detailLines), len(content))
+	joinLines(&b, withScrollbar(content, bar, m.width))
 
 	// footer
 	footer := " " +
 		keyStyle.Render("esc") + " " + helpStyle.Render("back") + "  " +
 		keyStyle.Render("r") + " " + helpStyle.Render("refresh") + "  " +
 		keyStyle.Render("j/k") + " " + helpStyle.Render("scroll") + "  " +
-		keyStyle.Render("tab") + " " + helpStyle.Render("toggle tmux/db")
+		keyStyle.Render("h/l") + " " + helpStyle.Render("prev/next") + "  " +
+		keyStyle.Render("P") + " " + helpStyle.Render("children") + "  " +
+		keyStyle.Render("I") + " " + helpStyle.Render("info")
+	if paneCaptureAvailable() {
+		footer += "  " + keyStyle.Render("tab") + " " + helpStyle.Render("toggle pane/db")
+	}
+	if m.detailSource == "db" {
+		footer += "  " + keyStyle.Render("O") + " " + helpStyle.Render("older")
+	}
+	footer += "  " + keyStyle.Render("R") + " " + helpStyle.Render("rounds")
+	footer += "  " + keyStyle.Render(":") + " " + helpStyle.Render("prompt")
+	if pos := m.detailPosition(contentRows); pos != "" {
+		footer += "  " + dimStyle.Render(pos)
+	}
+	if m.promptActive {
+		footer = m.renderPromptBar()
+	}
 	b.WriteString(footer)
 
 	return b.String()
@@ -0,0 +1,78 @@
+// `otop doctor`: reports which data sources and integrations work on
+// this machine, so a blank column or missing pane capture can be traced
+// to its cause (no tmux, plugin not installed, unreadable db, ...).
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// doctorCommand prints one line per capability check.
+func doctorCommand() {
+	ok := func(name, detail string) {
+		fmt.Printf("  %s %-14s %s\n", activeStyle.Render("ok"), name, dimStyle.Render(detail))
+	}
+	missing := func(name, detail string) {
+		fmt.Printf("  %s %-14s %s\n", errorStyle.Render("--"), name, detail)
+	}
+
+	fmt.Println(panelStyle.Render(" DATA"))
+	for _, src := range allDBSources() {
+		if _, err := os.Stat(src.path); err == nil {
+			ok("opencode db", src.path)
+			if info := schemaFor(src.path); len(info.missing) > 0 {
+				missing("schema", fmt.Sprintf("v%d unsupported, missing %s", info.version, strings.Join(info.missing, ", ")))
+			} else {
+				ok("schema", fmt.Sprintf("v%d", info.version))
+			}
+		} else if root, found := storageRoot(src.path); found {
+			ok("opencode db", "JSON files (older layout) in "+root)
+		} else {
+			missing("opencode db", "not found at "+src.path)
+		}
+	}
+	if entries, err := os.ReadDir(otopPidDir()); err == nil {
+		ok("otop plugin", fmt.Sprintf("%d pid files in %s", len(entries), otopPidDir()))
+	} else {
+		missing("otop plugin", "no pid dir at "+otopPidDir()+" (install plugin/index.ts)")
+	}
+	if _, err := os.Stat(otopConfigPath()); err == nil {
+		ok("config", otopConfigPath())
+	} else {
+		ok("config", "none (defaults)")
+	}
+
+	fmt.Println(panelStyle.Render(" TOOLS"))
+	for _, tool := range []struct{ bin, purpose string }{
+		{"ps", "process discovery"},
+		{"lsof", "cwd + uptime"},
+		{"pbcopy", "yank to clipboard"},
+	} {
+		if path, err := exec.LookPath(tool.bin); err == nil {
+			ok(tool.bin, path)
+		} else {
+			missing(tool.bin, "not installed: no "+tool.purpose)
+		}
+	}
+
+	fmt.Println(panelStyle.Render(" PANES"))
+	for _, p := range paneProviders {
+		if p.available() {
+			if path, err := exec.LookPath(p.name()); err == nil {
+				ok(p.name(), filepath.Clean(path))
+			} else {
+				ok(p.name(), "available")
+			}
+		} else {
+			missing(p.name(), "not installed")
+		}
+	}
+	if !paneCaptureAvailable() {
+		fmt.Println(dimStyle.Render("  no pane provider: detail view uses db messages, wall mode is disabled"))
+	}
+}
@@ -0,0 +1,74 @@
+// open a session's working directory in an editor (e key).
+//
+// the command is display.editorCommand with {cwd} substituted, or
+// $EDITOR followed by the directory. inside tmux it runs in a new
+// window named after the directory; otherwise a configured template
+// (usually a GUI editor like `code {cwd}`) is started in the background
+// and a bare $EDITOR takes over the terminal until it exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorResultMsg reports the outcome of launching the editor.
+type editorResultMsg struct {
+	dir string
+	err error
+}
+
+// shellQuote wraps s in single quotes for sh -c.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// editorShellCommand builds the shell command line for dir.
+// returns "" if neither a template nor $EDITOR is set.
+func editorShellCommand(dir string) string {
+	if display.editorCommand != "" {
+		return strings.ReplaceAll(display.editorCommand, "{cwd}", shellQuote(dir))
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor + " " + shellQuote(dir)
+	}
+	return ""
+}
+
+// openEditorCmd launches the editor for dir.
+func openEditorCmd(dir string) tea.Cmd {
+	line := editorShellCommand(dir)
+	if line == "" {
+		return func() tea.Msg {
+			return editorResultMsg{dir: dir, err: fmt.Errorf("no editor: set $EDITOR or \"editor\" in %s", otopConfigPath())}
+		}
+	}
+
+	if os.Getenv("TMUX") != "" && tmuxInstalled() {
+		return func() tea.Msg {
+			err := exec.Command("tmux", "new-window", "-c", dir, "-n", filepath.Base(dir), "sh", "-c", line).Run()
+			return editorResultMsg{dir: dir, err: err}
+		}
+	}
+
+	cmd := exec.Command("sh", "-c", line)
+	cmd.Dir = dir
+	if display.editorCommand != "" {
+		return func() tea.Msg {
+			err := cmd.Start()
+			if err == nil {
+				go cmd.Wait()
+			}
+			return editorResultMsg{dir: dir, err: err}
+		}
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorResultMsg{dir: dir, err: err}
+	})
+}
@@ -0,0 +1,193 @@
+// edit preview for the detail view. when a session's newest tool call
+// edited or wrote a file, the first lines of the change are shown under
+// the info bar, colored like a diff, so what the agent just wrote can be
+// sanity-checked without switching to its pane. the preview goes away as
+// soon as the agent calls something else.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// editPreviewRows caps the diff lines shown; the rest are counted.
+const editPreviewRows = 8
+
+// editTools are the tools whose calls get a preview.
+var editTools = []string{"edit", "multiedit", "write", "patch"}
+
+// editPreview is the change made by a session's newest tool call.
+type editPreview struct {
+	tool   string
+	status string
+	file   string
+	lines  []string // diff lines, each starting with '+', '-', ' ' or '@'
+}
+
+// lastEditPreview finds the session's newest tool call in the first
+// database that has the session, and returns its change if it was an
+// edit. nil otherwise.
+func lastEditPreview(sessionID string) *editPreview {
+	for _, src := range allDBSources() {
+		if d, ok := lastToolPartFrom(src.path, sessionID); ok {
+			return editPreviewFromPart(d)
+		}
+	}
+	return nil
+}
+
+// lastToolPartFrom reads the session's newest tool part from one
+// database. ok is false if it has none.
+func lastToolPartFrom(path, sessionID string) (map[string]any, bool) {
+	if root, ok := storageRoot(path); ok {
+		msgs := storageMessages(root, sessionID)
+		for i := len(msgs) - 1; i >= max(0, len(msgs)-5); i-- {
+			parts := storageParts(root, jsonStr(msgs[i], "id"))
+			for j := len(parts) - 1; j >= 0; j-- {
+				if jsonStr(parts[j], "type") == "tool" {
+					return parts[j], true
+				}
+			}
+		}
+		return nil, false
+	}
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, false
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+	var data sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT data FROM part
+		WHERE session_id = ?
+		  AND json_extract(data, '$.type') = 'tool'
+		ORDER BY time_created DESC, id DESC
+		LIMIT 1
+	`, sessionID).Scan(&data)
+	if err != nil || !data.Valid {
+		return nil, false
+	}
+	var d map[string]any
+	if json.Unmarshal([]byte(data.String), &d) != nil {
+		return nil, false
+	}
+	return d, true
+}
+
+// editPreviewFromPart builds the preview for a tool part, or nil if the
+// tool doesn't edit files or its input isn't there yet. the diff opencode
+// stores in the metadata is preferred; failing that, one is made from
+// the input, which is all a running call has.
+func editPreviewFromPart(d map[string]any) *editPreview {
+	tool := jsonStr(d, "tool")
+	if !slices.Contains(editTools, tool) {
+		return nil
+	}
+	state, _ := d["state"].(map[string]any)
+	input, _ := state["input"].(map[string]any)
+	metadata, _ := state["metadata"].(map[string]any)
+	p := &editPreview{tool: tool, status: jsonStr(state, "status"), file: jsonStr(input, "filePath")}
+
+	if diff := jsonStr(metadata, "diff"); diff != "" {
+		p.lines = unifiedDiffLines(diff)
+	} else {
+		switch tool {
+		case "edit":
+			p.lines = replacementLines(jsonStr(input, "oldString"), jsonStr(input, "newString"))
+		case "multiedit":
+			edits, _ := input["edits"].([]any)
+			for _, e := range edits {
+				e, _ := e.(map[string]any)
+				p.lines = append(p.lines, replacementLines(jsonStr(e, "oldString"), jsonStr(e, "newString"))...)
+			}
+		case "write":
+			p.lines = prefixLines("+", jsonStr(input, "content"))
+		case "patch":
+			p.lines = unifiedDiffLines(jsonStr(input, "patchText"))
+		}
+	}
+	if p.file == "" && len(p.lines) == 0 {
+		return nil
+	}
+	return p
+}
+
+// unifiedDiffLines keeps the hunks of a unified diff, dropping the file
+// headers.
+func unifiedDiffLines(diff string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"),
+			strings.HasPrefix(line, "Index:"), strings.HasPrefix(line, "==="),
+			strings.HasPrefix(line, "***"):
+			continue
+		case line == "":
+			line = " "
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// replacementLines renders a string replacement as removed and added
+// lines.
+func replacementLines(before, after string) []string {
+	return append(prefixLines("-", before), prefixLines("+", after)...)
+}
+
+// prefixLines prefixes every line of text, or returns nil for "".
+func prefixLines(prefix, text string) []string {
+	if text == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		lines = append(lines, prefix+line)
+	}
+	return lines
+}
+
+// editPreviewLines renders the detail view's edit preview: a header
+// naming the file, then up to editPreviewRows diff lines. nil when the
+// newest tool call wasn't an edit.
+func (m model) editPreviewLines() []string {
+	p := m.detailEdit
+	if p == nil {
+		return nil
+	}
+	file := p.file
+	if s := m.detailSession.session; s != nil && s.directory != "" {
+		if rel, err := filepath.Rel(s.directory, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	header := fmt.Sprintf(" ✎ %s %s", p.tool, shortPath(file, max(20, m.width/2)))
+	if p.status != "" && p.status != "completed" {
+		header += " (" + p.status + ")"
+	}
+	lines := []string{panelStyle.Render(truncOrPad(header, m.width))}
+
+	for _, line := range p.lines[:min(editPreviewRows, len(p.lines))] {
+		style := dimStyle
+		switch line[0] {
+		case '+':
+			style = activeStyle
+		case '-':
+			style = errorStyle
+		}
+		lines = append(lines, style.Render(truncOrPad(" "+strings.ReplaceAll(line, "\t", "    "), m.width)))
+	}
+	if rest := len(p.lines) - editPreviewRows; rest > 0 {
+		lines = append(lines, dimStyle.Render(truncOrPad(fmt.Sprintf("   … %d more lines", rest), m.width)))
+	}
+	return lines
+}
@@ -0,0 +1,148 @@
+// environment overrides. OTOP_-prefixed variables take precedence over
+// the config file and the saved UI state, so a shell or tmux window can
+// run otop its own way without editing files:
+//
+//	OTOP_DB        databases, a path list like $PATH (see setDBSources)
+//	OTOP_REFRESH   collection interval: a duration ("500ms", "5s") or seconds
+//	OTOP_ONE_LINE  start in the one-line (true) or two-line (false) layout
+//	OTOP_SORT      sort keys, comma-separated, like the config "sort" list
+//	OTOP_COLUMNS   one-line columns to show, comma-separated, in order
+//
+// command-line flags still win over the environment.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minRefreshInterval keeps OTOP_REFRESH and serve --interval from turning
+// collection into a busy loop.
+const minRefreshInterval = 250 * time.Millisecond
+
+// envConfig holds the overrides that apply to UI state restored later,
+// and the values they replaced, which are what gets saved on quit: an
+// override is for this otop only, not the next plain one.
+var envConfig struct {
+	oneLine *bool
+	sort    []string
+
+	shadowedOneLine bool
+	shadowedSort    uiState // SortKey, SortReverse and SortThen
+}
+
+// loadEnvConfig applies the OTOP_ variables. call after loadConfigFile.
+func loadEnvConfig() error {
+	if v := os.Getenv("OTOP_REFRESH"); v != "" {
+		d, err := parseRefresh(v)
+		if err != nil {
+			return fmt.Errorf("OTOP_REFRESH: %w", err)
+		}
+		refreshInterval = d
+	}
+	if v := os.Getenv("OTOP_ONE_LINE"); v != "" {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("OTOP_ONE_LINE: %q is not a boolean", v)
+		}
+		envConfig.oneLine = &on
+		display.oneLine = on
+	}
+	if v := os.Getenv("OTOP_SORT"); v != "" {
+		keys := splitList(v)
+		for _, key := range keys {
+			if !slices.ContainsFunc(columns, func(c columnDef) bool { return c.key == key }) {
+				return fmt.Errorf("OTOP_SORT: unknown column %q", key)
+			}
+		}
+		if len(keys) > 0 {
+			envConfig.sort = keys
+			display.defaultSortKey = keys[0]
+			display.defaultSortThen = keys[1:]
+		}
+	}
+	if v := os.Getenv("OTOP_COLUMNS"); v != "" {
+		if err := setOneLineColumns(splitList(v)); err != nil {
+			return fmt.Errorf("OTOP_COLUMNS: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseRefresh reads a refresh interval: a Go duration or a number of
+// seconds.
+func parseRefresh(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		secs, ferr := strconv.ParseFloat(v, 64)
+		if ferr != nil {
+			return 0, fmt.Errorf("%q is not a duration", v)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	if d < minRefreshInterval {
+		return 0, fmt.Errorf("%s is below the %s minimum", d, minRefreshInterval)
+	}
+	return d, nil
+}
+
+// splitList splits a comma-separated value, dropping blanks.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setOneLineColumns shows exactly the given one-line columns, left to
+// right in the given order; the rest are hidden and keep their order
+// after them, so the column picker can bring them back.
+func setOneLineColumns(keys []string) error {
+	var shown []oneLineColSpec
+	for _, key := range keys {
+		i := slices.IndexFunc(oneLineColumnOrder, func(c oneLineColSpec) bool { return c.key == key })
+		if i < 0 {
+			return fmt.Errorf("unknown column %q", key)
+		}
+		if !slices.ContainsFunc(shown, func(c oneLineColSpec) bool { return c.key == key }) {
+			shown = append(shown, oneLineColumnOrder[i])
+		}
+	}
+	var hidden []oneLineColSpec
+	for _, col := range oneLineColumnOrder {
+		on := slices.ContainsFunc(shown, func(c oneLineColSpec) bool { return c.key == col.key })
+		display.columns.setEnabled(col.key, on)
+		if !on {
+			hidden = append(hidden, col)
+		}
+	}
+	oneLineColumnOrder = append(shown, hidden...)
+	return nil
+}
+
+// applyEnvState re-applies the overrides that restoring the saved UI
+// state would otherwise undo.
+func (m *model) applyEnvState() {
+	if envConfig.oneLine != nil {
+		envConfig.shadowedOneLine = display.oneLine
+		display.oneLine = *envConfig.oneLine
+	}
+	if len(envConfig.sort) > 0 {
+		envConfig.shadowedSort = uiState{
+			SortKey:     columns[m.sortColIdx].key,
+			SortReverse: m.sortReverse,
+			SortThen:    m.sortThen,
+		}
+		m.sortColIdx = slices.IndexFunc(columns, func(c columnDef) bool { return c.key == envConfig.sort[0] })
+		m.sortReverse = display.defaultSortReverse
+		m.sortThen = envConfig.sort[1:]
+	}
+}
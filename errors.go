@@ -0,0 +1,78 @@
+// error surfacing: provider failures otherwise read as "stale".
+//
+// two sources: the error object opencode stores on a failed assistant
+// message (name plus data.message, read with the last message in
+// db.go), and ERROR lines in the process's opencode log, whose path
+// lsof already finds. the db error drives the "error" status; the log
+// tail is only read for the detail view.
+
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// logTailBytes is how much of the end of a log is searched.
+const logTailBytes = 64 << 10
+
+// formatMessageError joins an assistant message's error name and text.
+func formatMessageError(name, message string) string {
+	switch {
+	case name != "" && message != "":
+		return name + ": " + message
+	case message != "":
+		return message
+	default:
+		return name
+	}
+}
+
+// messageAborted reports whether a message error is just the user
+// interrupting the round (esc in the TUI), which opencode also stores
+// as an error but which leaves the session idle, not failed.
+func messageAborted(lastError string) bool {
+	return strings.HasPrefix(lastError, "MessageAbortedError")
+}
+
+// lastLogError returns the newest ERROR line in the tail of an opencode
+// log, without the level and timestamp, or "" if there is none.
+// rotated-away logs that lsof still sees simply fail to open.
+func lastLogError(path string) string {
+	if path == "" {
+		return ""
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > logTailBytes {
+		if _, err := f.Seek(-logTailBytes, io.SeekEnd); err != nil {
+			return ""
+		}
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range reverseLines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "ERROR" {
+			continue
+		}
+		fields = fields[1:]
+		// "ERROR 2026-02-20T14:56:58 +12ms service=... msg"
+		if len(fields) > 0 && strings.Contains(fields[0], "T") && strings.ContainsAny(fields[0], "0123456789") {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "+") {
+			fields = fields[1:]
+		}
+		return strings.Join(fields, " ")
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+// message-level export for notebook analysis.
+//
+// `otop export-messages --session <id> --format csv|jsonl` writes one
+// row per message to stdout: role, model, tokens, cost, latency, finish.
+// pipe into a file and load with pandas/polars/duckdb.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// exportColumns is the column order for both csv headers and jsonl keys.
+var exportColumns = []string{
+	"message_id", "time_created", "time_completed", "latency_ms",
+	"role", "agent", "provider", "model", "finish",
+	"tokens_input", "tokens_output", "tokens_reasoning",
+	"cache_read", "cache_write", "cost",
+}
+
+// exportMessagesCommand writes every message of a session to stdout.
+func exportMessagesCommand(sessionID, format string) {
+	if format != "csv" && format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want csv or jsonl)\n", format)
+		os.Exit(1)
+	}
+
+	messages, err := getSessionMessages(sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(messages) == 0 {
+		fmt.Fprintf(os.Stderr, "error: no messages for session %s\n", sessionID)
+		os.Exit(1)
+	}
+
+	if format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write(exportColumns)
+		for _, msg := range messages {
+			_ = w.Write(exportCSVRow(msg))
+		}
+		w.Flush()
+		return
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, msg := range messages {
+		_ = enc.Encode(exportJSONRow(msg))
+	}
+}
+
+// messageLatency returns how long a message took to complete, in ms.
+// 0 for messages still streaming (or user messages, which complete instantly).
+func messageLatency(msg messageRecord) int64 {
+	if msg.timeCompleted <= 0 || msg.timeCompleted < msg.timeCreated {
+		return 0
+	}
+	return msg.timeCompleted - msg.timeCreated
+}
+
+func exportCSVRow(msg messageRecord) []string {
+	return []string{
+		msg.id,
+		strconv.FormatInt(msg.timeCreated, 10),
+		strconv.FormatInt(msg.timeCompleted, 10),
+		strconv.FormatInt(messageLatency(msg), 10),
+		msg.role,
+		msg.agent,
+		msg.provider,
+		msg.model,
+		msg.finish,
+		strconv.FormatInt(msg.tokensIn, 10),
+		strconv.FormatInt(msg.tokensOut, 10),
+		strconv.FormatInt(msg.reasoning, 10),
+		strconv.FormatInt(msg.cacheRead, 10),
+		strconv.FormatInt(msg.cacheWrite, 10),
+		strconv.FormatFloat(msg.cost, 'f', -1, 64),
+	}
+}
+
+func exportJSONRow(msg messageRecord) map[string]any {
+	return map[string]any{
+		"message_id":       msg.id,
+		"time_created":     msg.timeCreated,
+		"time_completed":   msg.timeCompleted,
+		"latency_ms":       messageLatency(msg),
+		"role":             msg.role,
+		"agent":            msg.agent,
+		"provider":         msg.provider,
+		"model":            msg.model,
+		"finish":           msg.finish,
+		"tokens_input":     msg.tokensIn,
+		"tokens_output":    msg.tokensOut,
+		"tokens_reasoning": msg.reasoning,
+		"cache_read":       msg.cacheRead,
+		"cache_write":      msg.cacheWrite,
+		"cost":             msg.cost,
+	}
+}
@@ -0,0 +1,272 @@
+// list filter. the / filter text is matched against each row; besides
+// plain text it takes qualified terms, all of which must hold:
+//
+//	dir:~/src/work     session directory (or cwd) at or below the path
+//	status:generating  status starts with the value
+//	model:opus         model ID contains the value
+//	title:refactor     title or alias contains the value
+//
+// the remaining words are matched against title, alias, model, session
+// ID, status, cwd, and tty, in one of three modes:
+//
+//	text        one case-insensitive substring (the default)
+//	re:<expr>   a case-insensitive regular expression
+//	fz:<chars>  fuzzy: the characters in order, scored like fzf, and the
+//	            list ranked by score instead of the sort column
+//
+// what matched is highlighted in the title, model, and cwd cells. named
+// presets (config "filters") fill in the filter text; F1-F4 apply the
+// first four and F cycles through all of them.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// filterPreset is a named filter from the config file.
+type filterPreset struct {
+	name   string
+	filter string
+}
+
+// filterTerm is one qualified term of a filter.
+type filterTerm struct {
+	field, value string
+}
+
+// filterFields are the qualifiers a term can use.
+var filterFields = []string{"dir", "status", "model", "title"}
+
+// filterQuery is parsed filter text.
+type filterQuery struct {
+	terms []filterTerm
+	mode  string         // "text", "re", or "fz"
+	text  string         // free text, lowercased (text and fz modes)
+	re    *regexp.Regexp // re mode
+}
+
+var (
+	filterCacheMu sync.Mutex
+	filterCache   struct {
+		text  string
+		query filterQuery
+	}
+)
+
+// parseFilter splits filter text into qualified terms and the free
+// text, and picks the match mode. the last parse is cached since every
+// row and cell of a render asks for it.
+func parseFilter(text string) filterQuery {
+	filterCacheMu.Lock()
+	defer filterCacheMu.Unlock()
+	if filterCache.text == text && text != "" {
+		return filterCache.query
+	}
+
+	var q filterQuery
+	var words []string
+	for _, word := range strings.Fields(text) {
+		field, value, ok := strings.Cut(word, ":")
+		if ok && value != "" && slices.Contains(filterFields, field) {
+			q.terms = append(q.terms, filterTerm{field, value})
+			continue
+		}
+		words = append(words, word)
+	}
+	free := strings.Join(words, " ")
+	switch {
+	case strings.HasPrefix(free, "re:"):
+		q.mode = "re"
+		// a pattern still being typed may not compile yet; match it literally
+		expr := strings.TrimPrefix(free, "re:")
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(expr))
+		}
+		if expr != "" {
+			q.re = re
+		}
+	case strings.HasPrefix(free, "fz:"):
+		q.mode = "fz"
+		q.text = strings.ToLower(strings.TrimPrefix(free, "fz:"))
+	default:
+		q.mode = "text"
+		q.text = strings.ToLower(free)
+	}
+	filterCache.text, filterCache.query = text, q
+	return q
+}
+
+// filterMatches reports whether a row passes the filter text.
+func filterMatches(text string, cs correlatedSession) bool {
+	q := parseFilter(text)
+	for _, t := range q.terms {
+		if !termMatches(t, cs) {
+			return false
+		}
+	}
+	switch q.mode {
+	case "re":
+		return q.re == nil || slices.ContainsFunc(matchFields(cs), q.re.MatchString)
+	case "fz":
+		return q.text == "" || fuzzyRowScore(q.text, cs) > 0
+	}
+	return q.text == "" || textMatches(q.text, cs)
+}
+
+// matchFields are the row values the free text is matched against.
+func matchFields(cs correlatedSession) []string {
+	fields := []string{cs.process.cwd, cs.process.tty}
+	if s := cs.session; s != nil {
+		fields = append(fields, s.title, sessionLabel(s), s.model, s.sessionID,
+			inferStatus(s, cs.process.cpuPercent))
+	}
+	return fields
+}
+
+// fuzzyRowScore is a row's best fuzzy score over its fields, 0 if none
+// matches.
+func fuzzyRowScore(pattern string, cs correlatedSession) int {
+	best := 0
+	for _, f := range matchFields(cs) {
+		if score, _, ok := fuzzyScore(pattern, f); ok {
+			best = max(best, score)
+		}
+	}
+	return best
+}
+
+// highlightCell marks what the filter matched in a title, model, or cwd
+// cell (field), so it's clear why a row is listed: the free text in any
+// of them, and title:, model: and dir: terms in their own.
+func (m model) highlightCell(field, cell string) string {
+	if m.filterText == "" || !highlightEnabled() {
+		return cell
+	}
+	q := parseFilter(m.filterText)
+	var spans [][]int
+	switch {
+	case q.mode == "re" && q.re != nil:
+		spans = q.re.FindAllStringIndex(cell, -1)
+	case q.mode == "fz" && q.text != "":
+		if _, pos, ok := fuzzyScore(q.text, cell); ok {
+			return highlightRunes(cell, pos)
+		}
+	case q.mode == "text":
+		spans = substringSpans(cell, q.text)
+	}
+	for _, t := range q.terms {
+		switch {
+		case t.field == field && (field == "title" || field == "model"):
+			spans = append(spans, substringSpans(cell, strings.ToLower(t.value))...)
+		case t.field == "dir" && field == "cwd":
+			if prefix := shortPath(expandHome(t.value), 1<<10); strings.HasPrefix(cell, prefix) {
+				spans = append(spans, []int{0, len(prefix)})
+			}
+		}
+	}
+	slices.SortFunc(spans, func(a, b []int) int { return a[0] - b[0] })
+	return highlightSpans(cell, spans)
+}
+
+// substringSpans finds the case-insensitive occurrences of needle
+// (lowercase) in s, as byte ranges.
+func substringSpans(s, needle string) [][]int {
+	lower := strings.ToLower(s)
+	if needle == "" || len(lower) != len(s) {
+		return nil
+	}
+	var spans [][]int
+	for off := 0; ; {
+		i := strings.Index(lower[off:], needle)
+		if i < 0 {
+			return spans
+		}
+		spans = append(spans, []int{off + i, off + i + len(needle)})
+		off += i + len(needle)
+	}
+}
+
+// termMatches checks one qualified term.
+func termMatches(t filterTerm, cs correlatedSession) bool {
+	value := strings.ToLower(t.value)
+	switch t.field {
+	case "dir":
+		dir := cs.process.cwd
+		if cs.session != nil && cs.session.directory != "" {
+			dir = cs.session.directory
+		}
+		return dirUnder(dir, t.value)
+	case "status":
+		status := "no-session"
+		if cs.session != nil {
+			status = inferStatus(cs.session, cs.process.cpuPercent)
+		}
+		return strings.HasPrefix(status, value)
+	case "model":
+		return cs.session != nil && strings.Contains(strings.ToLower(cs.session.model), value)
+	case "title":
+		return cs.session != nil && (strings.Contains(strings.ToLower(cs.session.title), value) ||
+			strings.Contains(strings.ToLower(sessionLabel(cs.session)), value))
+	}
+	return true
+}
+
+// dirUnder reports whether dir is root or below it; root may start with ~.
+func dirUnder(dir, root string) bool {
+	root = filepath.Clean(expandHome(root))
+	dir = filepath.Clean(dir)
+	return dir == root || strings.HasPrefix(dir, root+string(filepath.Separator))
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// textMatches is the plain substring match over a row's fields.
+func textMatches(needle string, cs correlatedSession) bool {
+	return slices.ContainsFunc(matchFields(cs), func(f string) bool {
+		return strings.Contains(strings.ToLower(f), needle)
+	})
+}
+
+// applyPreset switches to preset i, or clears it if it's already active.
+func (m *model) applyPreset(i int) {
+	if i < 0 || i >= len(display.filterPresets) {
+		return
+	}
+	p := display.filterPresets[i]
+	if m.presetName == p.name {
+		m.presetName, m.filterText = "", ""
+	} else {
+		m.presetName, m.filterText = p.name, p.filter
+	}
+	m.cursor, m.scrollOffset = 0, 0
+}
+
+// cyclePreset moves to the next preset, then back to no filter.
+func (m *model) cyclePreset() {
+	next := 0
+	for i, p := range display.filterPresets {
+		if p.name == m.presetName {
+			next = i + 1
+		}
+	}
+	if next >= len(display.filterPresets) {
+		m.presetName, m.filterText = "", ""
+		m.cursor, m.scrollOffset = 0, 0
+		return
+	}
+	m.applyPreset(next)
+}
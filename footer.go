@@ -0,0 +1,117 @@
+// footer customization (config "footer"). the default footer lists the
+// keybindings, which stops being news after a while. "mode": "minimal"
+// drops it, keeping only the flash message and the select indicator;
+// "format" replaces it with a template:
+//
+//	{visible} {total}      sessions listed, and running
+//	{gen} {wait} {idle}    counts by group, like `otop status`
+//	{generating} ...       counts for any single status
+//	{sort}                 the sort keys, e.g. ROUND>STATUS
+//	{filter}               the filter text
+//	{position}             the visible range when the list overflows
+//	{clock}                the time, HH:MM
+//
+// the template only replaces the key list; mode indicators stay. an
+// unknown placeholder is a config error rather than a silent 0.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// footerConfig controls the list view's footer.
+type footerConfig struct {
+	mode   string // "keys" (default), "minimal" or "format"
+	format string // template for mode "format"
+}
+
+// setFooter validates and applies the footer config.
+func setFooter(mode, format string) error {
+	switch {
+	case mode == "" && format != "":
+		mode = "format"
+	case mode == "":
+		mode = "keys"
+	}
+	if !slices.Contains([]string{"keys", "minimal", "format"}, mode) {
+		return fmt.Errorf("unknown mode %q (want keys, minimal or format)", mode)
+	}
+	if mode == "format" && format == "" {
+		return fmt.Errorf("mode format needs a format")
+	}
+	if mode == "format" {
+		var unknown []string
+		expandPlaceholders(format, func(key string) string {
+			if !footerPlaceholder(key) {
+				unknown = append(unknown, "{"+key+"}")
+			}
+			return ""
+		})
+		if len(unknown) > 0 {
+			return fmt.Errorf("unknown placeholder %s in format", strings.Join(unknown, ", "))
+		}
+	}
+	display.footer = footerConfig{mode: mode, format: format}
+	return nil
+}
+
+// footerPlaceholder reports whether key is a placeholder the format
+// can use: a fixed one, a status group, or a status.
+func footerPlaceholder(key string) bool {
+	if slices.Contains([]string{"visible", "total", "sort", "filter", "position", "clock", "unknown"}, key) {
+		return true
+	}
+	if _, ok := statusGlyphs[key]; ok {
+		return true
+	}
+	for _, g := range statusLineGroups {
+		if g.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// footerMinimal reports whether the footer shows indicators only.
+func footerMinimal() bool {
+	return display.footer.mode == "minimal"
+}
+
+// renderFooterTemplate expands the footer format for the current list.
+func (m model) renderFooterTemplate() string {
+	counts := make(map[string]int)
+	for _, cs := range m.sessions {
+		if cs.session == nil || cs.process.isToolProcess {
+			continue
+		}
+		status := inferStatus(cs.session, cs.process.cpuPercent)
+		counts[status]++
+		for _, g := range statusLineGroups {
+			if slices.Contains(g.statuses, status) {
+				counts[g.key]++
+			}
+		}
+		counts["total"]++
+	}
+
+	return " " + expandPlaceholders(display.footer.format, func(key string) string {
+		switch key {
+		case "visible":
+			return strconv.Itoa(len(m.getVisibleSessions()))
+		case "sort":
+			return m.sortLabel()
+		case "filter":
+			return m.filterText
+		case "position":
+			return m.listScrollPosition()
+		case "clock":
+			return time.Now().Format("15:04")
+		}
+		return strconv.Itoa(counts[key])
+	})
+}
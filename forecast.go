@@ -0,0 +1,126 @@
+// cost forecast for rounds in flight. opencode adds a message's cost as
+// each step finishes, so a long generating turn reads as cheap until it
+// ends. for a session whose round is still running, the round's cost so
+// far is estimated instead: recorded cost where there is one, otherwise
+// the message's token counts priced from modelPrices, plus the text
+// streamed since the last finished step at about four characters per
+// output token. the detail header shows it as "≈$0.38".
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// modelPrice is a model's pricing, in dollars per million tokens.
+type modelPrice struct {
+	match      string // substring of the model ID
+	input      float64
+	output     float64
+	cacheRead  float64
+	cacheWrite float64
+}
+
+// modelPrices are matched in order, first hit wins; entries from the
+// config file's "pricing" are prepended so they take precedence.
+var modelPrices = []modelPrice{
+	{"claude-opus-4-5", 5, 25, 0.5, 6.25},
+	{"claude-opus-4-6", 5, 25, 0.5, 6.25},
+	{"claude-opus-4", 15, 75, 1.5, 18.75},
+	{"claude-sonnet-4", 3, 15, 0.3, 3.75},
+	{"claude-haiku-4", 1, 5, 0.1, 1.25},
+	{"gpt-5", 1.25, 10, 0.125, 0},
+	{"gpt-4o-mini", 0.15, 0.6, 0.075, 0},
+	{"gpt-4o", 2.5, 10, 1.25, 0},
+	{"gemini-3-pro", 2, 12, 0.2, 0},
+	{"gemini-3-flash", 0.5, 3, 0.05, 0},
+}
+
+// priceFor finds the pricing for a model ID.
+func priceFor(model string) (modelPrice, bool) {
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.match) {
+			return p, true
+		}
+	}
+	return modelPrice{}, false
+}
+
+// cost prices a token count.
+func (p modelPrice) cost(input, output, cacheRead, cacheWrite int64) float64 {
+	return (float64(input)*p.input + float64(output)*p.output +
+		float64(cacheRead)*p.cacheRead + float64(cacheWrite)*p.cacheWrite) / 1e6
+}
+
+// roundInFlight reports whether the session's round is still running:
+// the prompt is unanswered, or the last reply hasn't finished or is
+// handing off to a tool.
+func roundInFlight(s *sessionInfo) bool {
+	switch s.lastMessageRole {
+	case "user":
+		return true
+	case "assistant":
+		return s.lastError == "" && (s.lastFinish == nil || *s.lastFinish == "tool-calls")
+	}
+	return false
+}
+
+// queryRoundForecast estimates the cost of the round that started at
+// roundStart, from the assistant messages written since.
+func queryRoundForecast(ctx context.Context, db *sql.DB, sessionID string, roundStart int64) (float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			json_extract(m.data, '$.modelID'),
+			coalesce(json_extract(m.data, '$.time.completed'), 0),
+			coalesce(json_extract(m.data, '$.cost'), 0),
+			coalesce(json_extract(m.data, '$.tokens.input'), 0),
+			coalesce(json_extract(m.data, '$.tokens.output'), 0),
+			coalesce(json_extract(m.data, '$.tokens.cache.read'), 0),
+			coalesce(json_extract(m.data, '$.tokens.cache.write'), 0),
+			(SELECT coalesce(sum(length(json_extract(p.data, '$.text'))), 0)
+				FROM part p
+				WHERE p.message_id = m.id
+				  AND json_extract(p.data, '$.type') IN ('text', 'reasoning')
+				  AND p.time_created > coalesce((SELECT max(q.time_created) FROM part q
+					WHERE q.message_id = m.id AND json_extract(q.data, '$.type') = 'step-finish'), 0))
+		FROM message m
+		WHERE m.session_id = ?
+		  AND m.time_created >= ?
+		  AND json_extract(m.data, '$.role') = 'assistant'
+	`, sessionID, roundStart)
+	if err != nil {
+		return 0, fmt.Errorf("forecast: %w", err)
+	}
+	defer rows.Close()
+
+	var total float64
+	for rows.Next() {
+		var model sql.NullString
+		var completed, input, output, cacheRead, cacheWrite, streamed int64
+		var cost float64
+		if rows.Scan(&model, &completed, &cost, &input, &output, &cacheRead, &cacheWrite, &streamed) != nil {
+			continue
+		}
+		price, ok := priceFor(model.String)
+		if cost == 0 && ok {
+			cost = price.cost(input, output, cacheRead, cacheWrite)
+		}
+		if completed == 0 && ok {
+			cost += float64(streamed) / 4 * price.output / 1e6
+		}
+		total += cost
+	}
+	return total, rows.Err()
+}
+
+// forecastLabel formats a session's in-flight round estimate, e.g.
+// "≈$0.38", or "" when no round is running.
+func forecastLabel(s *sessionInfo) string {
+	if s == nil || !roundInFlight(s) || s.roundForecast == 0 {
+		return ""
+	}
+	return fmt.Sprintf("≈$%.2f", s.roundForecast)
+}
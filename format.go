@@ -9,6 +9,9 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // -- formatting --
@@ -23,6 +26,13 @@ func formatTokens(n int64) string {
 	return fmt.Sprintf("%d", n)
 }
 
+func formatCost(c float64) string {
+	if c >= 100 {
+		return fmt.Sprintf("$%.0f", c)
+	}
+	return fmt.Sprintf("$%.2f", c)
+}
+
 func formatDuration(ms int64) string {
 	if ms <= 0 {
 		return "-"
@@ -46,6 +56,29 @@ func formatDuration(ms int64) string {
 	return fmt.Sprintf("%dd%dh", days, hours)
 }
 
+// formatSince renders the time since ms as a duration, or as the clock
+// time at ms when absolute times are on (@).
+func formatSince(ms, nowMS int64) string {
+	if display.absoluteTimes {
+		return formatClock(ms)
+	}
+	return formatDuration(nowMS - ms)
+}
+
+// formatClock renders ms as a local time that fits the 8-column time
+// columns: "15:04:05" today, "Mon 15h" in the last week, "Jan 2" before.
+func formatClock(ms int64) string {
+	t := time.UnixMilli(ms)
+	now := time.Now()
+	if y, m, d := now.Date(); t.Year() == y && t.Month() == m && t.Day() == d {
+		return t.Format("15:04:05")
+	}
+	if now.Sub(t) < 6*24*time.Hour {
+		return t.Format("Mon 15h")
+	}
+	return t.Format("Jan 2")
+}
+
 func shortPath(path string, maxLen int) string {
 	home, _ := os.UserHomeDir()
 	if strings.HasPrefix(path, home) {
@@ -59,15 +92,94 @@ func shortPath(path string, maxLen int) string {
 
 // truncOrPad truncates or right-pads a string to exactly width characters.
 func truncOrPad(s string, width int) string {
-	if len(s) > width {
-		return s[:width]
+	w := lipgloss.Width(s)
+	if w > width {
+		return ansi.Truncate(s, width, "")
 	}
-	if len(s) < width {
-		return s + strings.Repeat(" ", width-len(s))
+	if w < width {
+		return s + strings.Repeat(" ", width-w)
 	}
 	return s
 }
 
+// alignPad pads s to width like truncOrPad, but on the left when
+// alignRight is set so numbers line up on their last digit.
+func alignPad(s string, width int, alignRight bool) string {
+	w := lipgloss.Width(s)
+	if !alignRight || w >= width {
+		return truncOrPad(s, width)
+	}
+	return strings.Repeat(" ", width-w) + s
+}
+
+// precisionOr resolves a columnFormat precision against a column default.
+func precisionOr(f columnFormat, def int) int {
+	switch {
+	case f.precision < 0:
+		return 0
+	case f.precision > 0:
+		return f.precision
+	}
+	return def
+}
+
+// formatCPU formats a CPU percentage per the cpu column format.
+func formatCPU(pct float64) string {
+	f := display.columnFormats["cpu"]
+	s := fmt.Sprintf("%.*f", precisionOr(f, 1), pct)
+	if f.noUnits {
+		return s
+	}
+	return s + "%"
+}
+
+// formatMem formats a memory size in MB per the mem column format.
+func formatMem(mb float64) string {
+	f := display.columnFormats["mem"]
+	s := fmt.Sprintf("%.*f", precisionOr(f, 0), mb)
+	if f.noUnits {
+		return s
+	}
+	return s + "M"
+}
+
+// formatTokenColumn formats a token count per a ctx/out column format.
+// noUnits prints the raw count instead of K/M abbreviations.
+func formatTokenColumn(key string, n int64) string {
+	f := display.columnFormats[key]
+	if f.noUnits {
+		return fmt.Sprintf("%d", n)
+	}
+	prec := precisionOr(f, 1)
+	if n >= 1_000_000 {
+		return fmt.Sprintf("%.*fM", prec, float64(n)/1_000_000)
+	}
+	if n >= 1_000 {
+		return fmt.Sprintf("%.*fK", prec, float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// cacheHitPercent is the share of a session's input tokens (fresh input,
+// cache reads, and cache writes) that were served from the prompt cache.
+func cacheHitPercent(s *sessionInfo) float64 {
+	total := s.totalInputTokens + s.totalCacheWrite // input already counts cache reads
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(s.totalCacheRead) / float64(total)
+}
+
+// todoFraction is the share of a session's todos that are done, or -1
+// without any, so sessions with no list sort below an unstarted one.
+func todoFraction(s *sessionInfo) float64 {
+	done, total := todoProgress(s.activeTodos)
+	if total == 0 {
+		return -1
+	}
+	return float64(done) / float64(total)
+}
+
 // toASCII replaces non-ASCII bytes with '?' so that byte-level slicing
 // in tickerSlice and truncOrPad doesn't break column alignment.
 func toASCII(s string) string {
@@ -106,62 +218,106 @@ func columnValue(key string, cs correlatedSession) string {
 	if cs.session == nil {
 		switch key {
 		case "title":
-			return cs.process.cmdline
+			return containerTag(cs.process, cs.process.cmdline)
 		case "last":
 			return cs.process.cwd
 		case "status":
-			return "no-session"
+			return statusLabel("no-session")
 		case "pid":
 			return fmt.Sprintf("%d", cs.process.pid)
 		case "tty":
 			return cs.process.tty
+		case "host":
+			return cs.process.host
 		case "cpu":
-			return fmt.Sprintf("%.1f%%", cs.process.cpuPercent)
+			return formatCPU(cs.process.totalCPU())
 		case "mem":
-			return fmt.Sprintf("%.0fM", cs.process.memMB)
+			return formatMem(cs.process.totalMemMB())
 		}
 		return ""
 	}
 
 	switch key {
 	case "title":
-		return cs.session.title
+		return containerTag(cs.process, sessionLabel(cs.session))
 	case "last":
 		return cs.session.lastOutput
 	case "status":
-		return inferStatus(cs.session, cs.process.cpuPercent)
+		return statusLabel(inferStatus(cs.session, cs.process.cpuPercent))
 	case "msgs":
 		return fmt.Sprintf("%d", cs.session.messageCount)
+	case "rounds":
+		return fmt.Sprintf("%d", cs.session.roundCount)
+	case "tools":
+		return fmt.Sprintf("%d", cs.session.toolCalls)
 	case "sid":
 		return cs.session.sessionID
 	case "pid":
 		return fmt.Sprintf("%d", cs.process.pid)
 	case "uptime":
 		if cs.process.startTimeMS > 0 {
-			return formatDuration(nowMS - cs.process.startTimeMS)
+			return formatSince(cs.process.startTimeMS, nowMS)
 		}
 		return "-"
 	case "round":
 		if cs.session.roundStartTime > 0 {
-			return formatDuration(nowMS - cs.session.roundStartTime)
+			return formatSince(cs.session.roundStartTime, nowMS)
 		}
 		return "-"
 	case "cpu":
-		return fmt.Sprintf("%.1f%%", cs.process.cpuPercent)
+		return formatCPU(cs.process.totalCPU())
 	case "mem":
-		return fmt.Sprintf("%.0fM", cs.process.memMB)
+		return formatMem(cs.process.totalMemMB())
 	case "ctx":
-		return formatTokens(cs.session.totalInputTokens)
+		return formatTokenColumn("ctx", cs.session.totalInputTokens)
 	case "out":
-		return formatTokens(cs.session.totalOutputTokens)
+		return formatTokenColumn("out", cs.session.totalOutputTokens)
+	case "cache":
+		if cs.session.totalInputTokens+cs.session.totalCacheWrite == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%%", cacheHitPercent(cs.session))
+	case "cwrite":
+		return formatTokenColumn("cwrite", cs.session.totalCacheWrite)
+	case "compact":
+		if age := compactionAge(cs.session); age != "" {
+			return age
+		}
+		return "-"
+	case "idle":
+		if cs.session.lastUserInput > 0 {
+			return formatSince(cs.session.lastUserInput, nowMS)
+		}
+		return "-"
+	case "age":
+		if cs.session.timeCreated > 0 {
+			return formatSince(cs.session.timeCreated, nowMS)
+		}
+		return "-"
+	case "updated":
+		if cs.session.timeUpdated > 0 {
+			return formatSince(cs.session.timeUpdated, nowMS)
+		}
+		return "-"
+	case "todo":
+		if done, total := todoProgress(cs.session.activeTodos); total > 0 {
+			return fmt.Sprintf("%d/%d", done, total)
+		}
+		return "-"
 	case "model":
 		return shortModel(cs.session.model)
+	case "version":
+		return opencodeVersion(cs)
 	case "tty":
 		return cs.process.tty
 	case "tmux":
 		return cs.process.tmuxSession
 	case "tmuxWin":
 		return cs.process.tmuxWindow
+	case "db":
+		return cs.session.source
+	case "host":
+		return cs.process.host
 	}
 	return ""
 }
@@ -191,12 +347,27 @@ func inferStatus(session *sessionInfo, cpuPercent float64) string {
 	cpuActive := cpuPercent > 5.0
 
 	if session.lastMessageRole == "assistant" {
+		// a failed request ends the round without a finish; without this
+		// it would age into "stale" and hide the provider failure. an
+		// interrupted round is stored the same way but isn't a failure
+		if messageAborted(session.lastError) {
+			return "idle"
+		}
+		if session.lastError != "" {
+			return "error"
+		}
 		finish := ""
 		if session.lastFinish != nil {
 			finish = *session.lastFinish
 		}
 
 		if finish == "" {
+			if session.paneIdle {
+				return "idle"
+			}
+			if sessionStuck(session, cpuPercent) {
+				return "stuck"
+			}
 			if ageSeconds < 120 {
 				return "generating"
 			}
@@ -241,11 +412,12 @@ func inferStatus(session *sessionInfo, cpuPercent float64) string {
 
 // -- sorting --
 
-// compareSessions compares two sessions by the given sort key.
-// returns -1, 0, or 1. sessions without a match sort to bottom.
-// title is used as a secondary key for stability (prevents bounce
-// between refreshes when primary values are equal).
-func compareSessions(key string, a, b correlatedSession) int {
+// compareSessions compares two sessions by the given sort keys in
+// order, each one breaking ties in the one before. returns -1, 0, or 1.
+// sessions without a match sort to bottom. title is used as a final key
+// for stability (prevents bounce between refreshes when the keys are
+// equal).
+func compareSessions(keys []string, a, b correlatedSession) int {
 	// no-session rows sort to bottom
 	aHas, bHas := 0, 0
 	if a.session == nil {
@@ -261,6 +433,20 @@ func compareSessions(key string, a, b correlatedSession) int {
 		return 0
 	}
 
+	for _, key := range keys {
+		if result := compareByKey(key, a, b); result != 0 {
+			return result
+		}
+	}
+
+	// final sort by title for stability
+	return cmp.Compare(
+		strings.ToLower(a.session.title),
+		strings.ToLower(b.session.title))
+}
+
+// compareByKey compares two sessions (both non-nil) by one sort key.
+func compareByKey(key string, a, b correlatedSession) int {
 	nowMS := time.Now().UnixMilli()
 	var result int
 
@@ -271,12 +457,16 @@ func compareSessions(key string, a, b correlatedSession) int {
 			inferStatus(b.session, b.process.cpuPercent))
 	case "title":
 		result = cmp.Compare(
-			strings.ToLower(a.session.title),
-			strings.ToLower(b.session.title))
+			strings.ToLower(sessionTitle(a.session)),
+			strings.ToLower(sessionTitle(b.session)))
 	case "last":
 		result = cmp.Compare(a.session.lastOutput, b.session.lastOutput)
 	case "msgs":
 		result = cmp.Compare(a.session.messageCount, b.session.messageCount)
+	case "rounds":
+		result = cmp.Compare(a.session.roundCount, b.session.roundCount)
+	case "tools":
+		result = cmp.Compare(a.session.toolCalls, b.session.toolCalls)
 	case "sid":
 		result = cmp.Compare(a.session.sessionID, b.session.sessionID)
 	case "pid":
@@ -302,26 +492,41 @@ func compareSessions(key string, a, b correlatedSession) int {
 		}
 		result = cmp.Compare(aRound, bRound)
 	case "cpu":
-		result = cmp.Compare(a.process.cpuPercent, b.process.cpuPercent)
+		result = cmp.Compare(a.process.totalCPU(), b.process.totalCPU())
 	case "mem":
-		result = cmp.Compare(a.process.memMB, b.process.memMB)
+		result = cmp.Compare(a.process.totalMemMB(), b.process.totalMemMB())
 	case "tokens":
 		result = cmp.Compare(a.session.totalInputTokens, b.session.totalInputTokens)
+	case "cache":
+		result = cmp.Compare(cacheHitPercent(a.session), cacheHitPercent(b.session))
+	case "cwrite":
+		result = cmp.Compare(a.session.totalCacheWrite, b.session.totalCacheWrite)
+	case "compact":
+		result = cmp.Compare(a.session.lastCompaction, b.session.lastCompaction)
+	case "idle":
+		// longer idle = older input; never-prompted sessions count as longest
+		result = cmp.Compare(b.session.lastUserInput, a.session.lastUserInput)
+	case "age":
+		// older session = greater age, like uptime
+		result = cmp.Compare(b.session.timeCreated, a.session.timeCreated)
+	case "updated":
+		result = cmp.Compare(b.session.timeUpdated, a.session.timeUpdated)
+	case "todo":
+		result = cmp.Compare(todoFraction(a.session), todoFraction(b.session))
 	case "model":
 		result = cmp.Compare(a.session.model, b.session.model)
+	case "version":
+		result = compareVersions(opencodeVersion(a), opencodeVersion(b))
 	case "tty":
 		result = cmp.Compare(a.process.tty, b.process.tty)
 	case "tmux":
 		result = cmp.Compare(a.process.tmuxSession, b.process.tmuxSession)
 	case "tmuxWin":
 		result = cmp.Compare(a.process.tmuxWindow, b.process.tmuxWindow)
-	}
-
-	// secondary sort by title for stability
-	if result == 0 {
-		result = cmp.Compare(
-			strings.ToLower(a.session.title),
-			strings.ToLower(b.session.title))
+	case "db":
+		result = cmp.Compare(a.session.source, b.session.source)
+	case "host":
+		result = cmp.Compare(a.process.host, b.process.host)
 	}
 	return result
 }
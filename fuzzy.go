@@ -0,0 +1,125 @@
+// fuzzy matching for the fz: filter mode, scored roughly the way fzf's
+// v1 algorithm does: find the pattern as a subsequence, shrink the match
+// window from the end backwards, then score matched characters with
+// bonuses for runs and word starts and a penalty for gaps. match
+// highlighting wraps matched characters in bold and underline, turned
+// off again without a full reset so the row's color carries on.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	fuzzyMatchScore    = 16
+	fuzzyRunBonus      = 8 // matched right after the previous match
+	fuzzyBoundaryBonus = 8 // matched at the start of a word or path segment
+	fuzzyGapPenalty    = 1 // per unmatched character inside the window
+
+	matchOn  = "\x1b[1;4m"
+	matchOff = "\x1b[22;24m"
+)
+
+// fuzzyScore matches pattern (lowercase) against s as a subsequence.
+// positions are rune indexes into s.
+func fuzzyScore(pattern, s string) (score int, positions []int, ok bool) {
+	p := []rune(pattern)
+	r := []rune(strings.ToLower(s))
+	if len(p) == 0 || len(r) != len([]rune(s)) {
+		return 0, nil, false
+	}
+
+	// forward: first window end
+	pi, end := 0, -1
+	for i, c := range r {
+		if c == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	// backward: tightest start for that end
+	pi, start := len(p)-1, end
+	for i := end; i >= 0; i-- {
+		if r[i] == p[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	pi = 0
+	for i := start; i <= end && pi < len(p); i++ {
+		if r[i] != p[pi] {
+			score -= fuzzyGapPenalty
+			continue
+		}
+		score += fuzzyMatchScore
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += fuzzyRunBonus
+		}
+		if i == 0 || strings.ContainsRune(" /-_.:", r[i-1]) {
+			score += fuzzyBoundaryBonus
+		}
+		positions = append(positions, i)
+		pi++
+	}
+	return max(1, score), positions, true
+}
+
+// highlightEnabled reports whether match highlighting can be drawn:
+// not when output has no colors (piped --once).
+func highlightEnabled() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
+
+// highlightRunes marks the runes of s at the given indexes.
+func highlightRunes(s string, positions []int) string {
+	if len(positions) == 0 {
+		return s
+	}
+	var b strings.Builder
+	pi, on := 0, false
+	for i, c := range []rune(s) {
+		hit := pi < len(positions) && positions[pi] == i
+		if hit {
+			pi++
+		}
+		if hit != on {
+			b.WriteString(map[bool]string{true: matchOn, false: matchOff}[hit])
+			on = hit
+		}
+		b.WriteRune(c)
+	}
+	if on {
+		b.WriteString(matchOff)
+	}
+	return b.String()
+}
+
+// highlightSpans marks the byte ranges [start, end) of s.
+func highlightSpans(s string, spans [][]int) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span[0] < last || span[1] <= span[0] {
+			continue
+		}
+		b.WriteString(s[last:span[0]])
+		b.WriteString(matchOn + s[span[0]:span[1]] + matchOff)
+		last = span[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
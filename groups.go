@@ -0,0 +1,84 @@
+// tmux grouping (b). with one tmux session per project, the list reads
+// better grouped the same way: rows are ordered by tmux session (the
+// sort column still orders rows within a group) and each group starts
+// with a header naming the tmux session, how many agents it holds, and
+// how many are in each status. rows outside tmux group last.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// noTmuxGroup labels rows without a tmux session.
+const noTmuxGroup = "(no tmux)"
+
+// groupStatusOrder lists statuses in the order a header counts them,
+// the ones needing attention first.
+var groupStatusOrder = []string{
+	"asking", "error", "stuck", "truncated",
+	"generating", "tool use", "busy", "thinking", "queued",
+	"idle", "stale", "unknown", "no-session",
+}
+
+// tmuxGroup returns the group a row belongs to.
+func tmuxGroup(cs correlatedSession) string {
+	if cs.process.tmuxSession == "" {
+		return noTmuxGroup
+	}
+	return cs.process.tmuxSession
+}
+
+// groupByTmux reorders rows by tmux session, keeping their order within
+// each group.
+func groupByTmux(rows []correlatedSession) {
+	slices.SortStableFunc(rows, func(a, b correlatedSession) int {
+		ga, gb := tmuxGroup(a), tmuxGroup(b)
+		if (ga == noTmuxGroup) != (gb == noTmuxGroup) {
+			if ga == noTmuxGroup {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(ga, gb)
+	})
+}
+
+// grouping reports whether the list is drawn grouped; the inbox keeps
+// its own order.
+func (m model) grouping() bool {
+	return m.groupTmux && !m.inboxMode
+}
+
+// rowStatus is a row's status, or "no-session".
+func rowStatus(cs correlatedSession) string {
+	if cs.session == nil {
+		return "no-session"
+	}
+	return inferStatus(cs.session, cs.process.cpuPercent)
+}
+
+// renderGroupHeader draws the header line for a group, aggregating the
+// statuses of all its rows (not just those on the page).
+func (m model) renderGroupHeader(group string, rows []correlatedSession) string {
+	counts := make(map[string]int)
+	total := 0
+	for _, cs := range rows {
+		if tmuxGroup(cs) == group {
+			counts[rowStatus(cs)]++
+			total++
+		}
+	}
+	var parts []string
+	for _, status := range groupStatusOrder {
+		if n := counts[status]; n > 0 {
+			parts = append(parts, statusStyleFor(status).Render(fmt.Sprintf("%d %s", n, status)))
+		}
+	}
+	line := panelStyle.Render(" ▸ "+group) + dimStyle.Render(fmt.Sprintf(" (%d)  ", total)) +
+		strings.Join(parts, dimStyle.Render(" · "))
+	return truncOrPad(line, m.width)
+}
@@ -0,0 +1,107 @@
+// hourly activity heatmap (H): message volume per hour for the last
+// seven days, one row per day (today at the bottom), one cell per hour.
+// shades are relative to the busiest hour in the window.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	heatmapDays    = 7
+	heatmapRefresh = time.Minute // history barely moves; no need to rescan every tick
+	heatmapRows    = heatmapDays + 3
+)
+
+// heatmapShades go from no messages to the busiest hour.
+var heatmapShades = []string{"·", "░", "▒", "▓", "█"}
+
+// heatmapMsg carries counts per [days ago][local hour]; row 0 is today.
+type heatmapMsg struct {
+	counts [heatmapDays][24]int
+	err    error
+}
+
+func heatmapCmd() tea.Msg {
+	hourly, err := queryHourlyActivity(heatmapDays)
+	var msg heatmapMsg
+	msg.err = err
+	today := time.Now()
+	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	for hourMS, n := range hourly {
+		t := time.UnixMilli(hourMS).Local()
+		dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		ago := int(todayStart.Sub(dayStart).Hours()/24 + 0.5)
+		if ago >= 0 && ago < heatmapDays {
+			msg.counts[ago][t.Hour()] += n
+		}
+	}
+	return msg
+}
+
+// heatmapStale reports whether the panel's data should be reloaded.
+func (m model) heatmapStale() bool {
+	return m.showHeatmap && time.Since(m.heatmapAt) > heatmapRefresh
+}
+
+// renderHeatmapPanel draws the grid, heatmapRows lines.
+func (m model) renderHeatmapPanel() string {
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	peak, total := 0, 0
+	for _, day := range m.heatmap {
+		for _, n := range day {
+			peak = max(peak, n)
+			total += n
+		}
+	}
+	b.WriteString(panelStyle.Render(" ACTIVITY (messages per hour, last 7 days)"))
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  %d total, peak %d/h", total, peak)))
+	b.WriteString("\n")
+
+	// two cells per hour when there's room, otherwise one
+	cell := 2
+	if m.width < 5+24*2 {
+		cell = 1
+	}
+	now := time.Now()
+	for ago := heatmapDays - 1; ago >= 0; ago-- {
+		day := now.AddDate(0, 0, -ago)
+		label := day.Format("Mon")
+		if ago == 0 {
+			label = "tdy"
+		}
+		line := " " + dimStyle.Render(label) + " "
+		for hour, n := range m.heatmap[ago] {
+			shade := heatmapShades[0]
+			if n > 0 && peak > 0 {
+				shade = heatmapShades[1+min(len(heatmapShades)-2, (n*(len(heatmapShades)-1)-1)/peak)]
+			}
+			style := activeStyle
+			if n == 0 {
+				style = dimStyle
+			}
+			if ago == 0 && hour == now.Hour() {
+				style = transStyle
+			}
+			line += style.Render(strings.Repeat(shade, cell))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	axis := "     "
+	for hour := 0; hour < 24; hour += 6 {
+		axis += fmt.Sprintf("%-*d", 6*cell, hour)
+	}
+	b.WriteString(dimStyle.Render(axis))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,148 @@
+// history recorder: with "history": true in the config, every fetch
+// appends one sample per session (status, tokens, cost, message count)
+// to otop's own sqlite file, $XDG_DATA_HOME/otop/history.db. opencode's
+// db stays read-only and untouched; timelines and usage graphs can be
+// built from the samples later.
+//
+// samples are written on a separate goroutine so a slow disk never
+// stalls the refresh loop; when the writer falls behind, the newest
+// fetch replaces the pending one. samples older than history_days
+// (default 30) are pruned each time the file is opened.
+
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS sample (
+	time          INTEGER NOT NULL, -- epoch ms of the fetch
+	session_id    TEXT    NOT NULL,
+	source        TEXT    NOT NULL,
+	status        TEXT    NOT NULL,
+	model         TEXT    NOT NULL,
+	directory     TEXT    NOT NULL,
+	messages      INTEGER NOT NULL,
+	input_tokens  INTEGER NOT NULL,
+	output_tokens INTEGER NOT NULL,
+	cache_read    INTEGER NOT NULL,
+	cost          REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sample_session_time ON sample (session_id, time);
+CREATE INDEX IF NOT EXISTS sample_time ON sample (time);
+`
+
+func historyDBPath() string {
+	return filepath.Join(otopStateDir(), "history.db")
+}
+
+// openHistoryDB opens (creating if needed) the writable history db and
+// prunes samples past the retention.
+func openHistoryDB() (*sql.DB, error) {
+	if err := os.MkdirAll(otopStateDir(), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+historyDBPath()+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(3000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if days := display.historyDays; days > 0 {
+		cutoff := time.Now().AddDate(0, 0, -days).UnixMilli()
+		if _, err := db.Exec(`DELETE FROM sample WHERE time < ?`, cutoff); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// startHistory subscribes the recorder to snapshot events. returns a
+// function that writes the pending sample and closes the db.
+func startHistory() (stop func(), err error) {
+	db, err := openHistoryDB()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(chan fetchResult, 1)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case result := <-pending:
+				_ = writeHistorySample(db, time.Now(), result)
+			case <-quit:
+				select {
+				case result := <-pending:
+					_ = writeHistorySample(db, time.Now(), result)
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	unsubscribe := bus.subscribe(func(ev event) {
+		snap, ok := ev.(snapshotEvent)
+		if !ok {
+			return
+		}
+		select {
+		case <-pending:
+		default:
+		}
+		select {
+		case pending <- snap.result:
+		default: // another publisher refilled it first
+		}
+	})
+
+	return func() {
+		unsubscribe()
+		close(quit)
+		<-done
+		db.Close()
+	}, nil
+}
+
+// writeHistorySample inserts one row per session in a single transaction.
+func writeHistorySample(db *sql.DB, at time.Time, result fetchResult) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO sample (time, session_id, source, status, model, directory,
+			messages, input_tokens, output_tokens, cache_read, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, cs := range result.correlated {
+		s := cs.session
+		if s == nil || cs.process.isToolProcess {
+			continue
+		}
+		if _, err := stmt.Exec(at.UnixMilli(), s.sessionID, s.source,
+			inferStatus(s, cs.process.cpuPercent), s.model, s.directory,
+			s.messageCount, s.totalInputTokens, s.totalOutputTokens, s.totalCacheRead, s.totalCost); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
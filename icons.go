@@ -0,0 +1,119 @@
+// status icons (config "status_icons", --icons). the one-line STATUS
+// column spends ten characters on a word; in icon mode it shows a
+// single glyph instead, with a spinner while generating:
+//
+//	status      nerd  unicode  ascii
+//	generating  ⠋⠙⠹…  ⠋⠙⠹…     |/-\
+//	tool use         ●        T
+//	thinking         ◐        ~
+//	busy             ●        *
+//	queued           ◐        :
+//	asking           ?        ?
+//	idle             ○        .
+//	truncated        !        !
+//	stuck            !        #
+//	error            ✗        X
+//	stale            ·        z
+//	unknown          -        _
+//
+// "unicode" is the tiny layout's set (statusGlyphs in tiny.go), which
+// the tiny layout also follows when another mode is picked. "nerd"
+// needs a Nerd Font; "ascii" works in any terminal.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// spinnerFrames animate the generating glyph, by mode.
+var spinnerFrames = map[string][]string{
+	"nerd":    brailleSpinner,
+	"unicode": brailleSpinner,
+	"ascii":   {"|", "/", "-", "\\"},
+}
+
+var brailleSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// statusIcons maps statuses to glyphs, by mode.
+var statusIcons = map[string]map[string]string{
+	"unicode": statusGlyphs,
+	"nerd": {
+		"tool use":   "", // wrench
+		"thinking":   "", // hourglass
+		"busy":       "", // cog
+		"queued":     "", // clock
+		"asking":     "", // question
+		"idle":       "", // check
+		"truncated":  "", // warning
+		"stuck":      "", // broken link
+		"error":      "", // times circle
+		"stale":      "", // moon
+		"unknown":    "", // empty circle
+		"no-session": "", // minus
+	},
+	"ascii": {
+		"tool use":   "T",
+		"thinking":   "~",
+		"busy":       "*",
+		"queued":     ":",
+		"asking":     "?",
+		"idle":       ".",
+		"truncated":  "!",
+		"stuck":      "#",
+		"error":      "X",
+		"stale":      "z",
+		"unknown":    "_",
+		"no-session": "-",
+	},
+}
+
+// setStatusIcons sets the icon mode from a config or flag value.
+func setStatusIcons(mode string) error {
+	switch mode {
+	case "", "off":
+		display.statusIcons = ""
+	case "nerd", "unicode", "ascii":
+		display.statusIcons = mode
+	default:
+		return fmt.Errorf("unknown mode %q (want nerd, unicode, ascii or off)", mode)
+	}
+	return nil
+}
+
+// iconsEnabled reports whether the STATUS column shows glyphs.
+func iconsEnabled() bool {
+	_, ok := statusIcons[display.statusIcons]
+	return ok
+}
+
+// statusLabel returns what the STATUS column shows for a status: the
+// word, or its glyph in icon mode.
+func statusLabel(status string) string {
+	icons, ok := statusIcons[display.statusIcons]
+	if !ok {
+		return status
+	}
+	if status == "generating" {
+		frames := spinnerFrames[display.statusIcons]
+		return frames[time.Now().UnixMilli()/120%int64(len(frames))]
+	}
+	if icon, ok := icons[status]; ok {
+		return icon
+	}
+	return "?"
+}
+
+// statusGlyph returns a status's glyph for layouts that always show
+// one: from the icon mode if one is set, else statusGlyphs. no spinner.
+func statusGlyph(status string) string {
+	icons, ok := statusIcons[display.statusIcons]
+	if !ok {
+		icons = statusGlyphs
+	}
+	if glyph, ok := icons[status]; ok {
+		return glyph
+	}
+	return statusGlyphs["unknown"]
+}
@@ -0,0 +1,43 @@
+// needs-reply queue (W). narrows the list to sessions that are idle with
+// the assistant's answer as the last message, i.e. waiting on the user,
+// oldest first, so a backlog of agents can be worked through like an
+// inbox. it's a mode of the list, so enter, o, : and the rest still work
+// on the rows; W again returns to the normal list and sort.
+
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// needsReply reports whether a session is waiting on the user.
+func needsReply(cs correlatedSession) bool {
+	s := cs.session
+	if s == nil || cs.process.isToolProcess || s.lastMessageRole != "assistant" {
+		return false
+	}
+	return inferStatus(s, cs.process.cpuPercent) == "idle"
+}
+
+// waitingSince is when a session started waiting: its newest message or
+// part, whichever came later (the answer's last part lands after the
+// message row is created).
+func waitingSince(s *sessionInfo) int64 {
+	return max(s.lastMessageTime, s.lastPartTime)
+}
+
+// inboxSessions keeps the sessions waiting on the user, longest waiting
+// first.
+func inboxSessions(sessions []correlatedSession) []correlatedSession {
+	var waiting []correlatedSession
+	for _, cs := range sessions {
+		if needsReply(cs) {
+			waiting = append(waiting, cs)
+		}
+	}
+	slices.SortStableFunc(waiting, func(a, b correlatedSession) int {
+		return cmp.Compare(waitingSince(a.session), waitingSince(b.session))
+	})
+	return waiting
+}
@@ -0,0 +1,55 @@
+// running-instance registry.
+//
+// every TUI writes its PID to a file under otopRuntimeDir()/instances.
+// on startup the others are listed (pruning files whose process is
+// gone) so a second otop can warn that it's doubling the ps/lsof/sqlite
+// load instead of silently competing with the first.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+func instancesDir() string {
+	return filepath.Join(otopRuntimeDir(), "instances")
+}
+
+// processAlive reports whether pid exists (signal 0 probes without sending).
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// registerInstance records this process and returns the PIDs of other
+// live instances. the returned cleanup removes this process's entry.
+func registerInstance() (others []int, cleanup func()) {
+	dir := instancesDir()
+	self := os.Getpid()
+	cleanup = func() {}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		if processAlive(pid) {
+			others = append(others, pid)
+		} else {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return others, cleanup
+	}
+	path := filepath.Join(dir, strconv.Itoa(self))
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		return others, cleanup
+	}
+	return others, func() { _ = os.Remove(path) }
+}
@@ -0,0 +1,116 @@
+// invocation inspection: the interesting parts of how an opencode
+// process was started, parsed from its cmdline and environment.
+//
+// shown under the detail view's info bar. flags that pin a model or
+// agent are compared against what the db says the session is actually
+// using, since a session resumed with -s keeps its own settings and a
+// stale --model flag is easy to misread.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// invocation holds the flags otop cares about. empty fields weren't set.
+type invocation struct {
+	model    string // --model/-m, "provider/model"
+	agent    string // --agent
+	session  string // --session/-s
+	port     string // --port
+	share    bool   // --share
+	cont     bool   // --continue/-c
+	profile  string // OPENCODE_CONFIG from the environment
+	override bool   // OPENCODE_CONFIG_CONTENT is set (inline config)
+}
+
+// parseInvocation extracts known flags from a cmdline and environment.
+// both "--flag value" and "--flag=value" forms are accepted.
+func parseInvocation(cmdline string, env map[string]string) invocation {
+	var inv invocation
+	args := strings.Fields(cmdline)
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		next := func() string {
+			if hasValue {
+				return value
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		switch name {
+		case "--model", "-m":
+			inv.model = next()
+		case "--agent":
+			inv.agent = next()
+		case "--session", "-s":
+			inv.session = next()
+		case "--port":
+			inv.port = next()
+		case "--share":
+			inv.share = true
+		case "--continue", "-c":
+			inv.cont = true
+		}
+	}
+	inv.profile = env["OPENCODE_CONFIG"]
+	inv.override = env["OPENCODE_CONFIG_CONTENT"] != ""
+	return inv
+}
+
+// parts returns the set flags as short "key:value" labels.
+func (inv invocation) parts() []string {
+	var parts []string
+	if inv.model != "" {
+		parts = append(parts, "model:"+inv.model)
+	}
+	if inv.agent != "" {
+		parts = append(parts, "agent:"+inv.agent)
+	}
+	if inv.session != "" {
+		parts = append(parts, "session:"+inv.session)
+	}
+	if inv.cont {
+		parts = append(parts, "continue")
+	}
+	if inv.share {
+		parts = append(parts, "share")
+	}
+	if inv.port != "" {
+		parts = append(parts, "port:"+inv.port)
+	}
+	if inv.profile != "" {
+		parts = append(parts, "config:"+shortPath(inv.profile, 30))
+	}
+	if inv.override {
+		parts = append(parts, "inline-config")
+	}
+	return parts
+}
+
+// mismatches describes flags that disagree with the session's db state.
+// the db stores modelID without the provider prefix, so only the part
+// after the last "/" of --model is compared.
+func (inv invocation) mismatches(session *sessionInfo) []string {
+	if session == nil {
+		return nil
+	}
+	var warnings []string
+	if inv.model != "" && session.model != "" && session.model != "?" {
+		flagModel := inv.model[strings.LastIndex(inv.model, "/")+1:]
+		if flagModel != session.model {
+			warnings = append(warnings, fmt.Sprintf("--model %s but session uses %s", flagModel, session.model))
+		}
+	}
+	if inv.agent != "" && session.agent != "" && session.agent != "?" && inv.agent != session.agent {
+		warnings = append(warnings, fmt.Sprintf("--agent %s but session uses %s", inv.agent, session.agent))
+	}
+	if inv.session != "" && !strings.HasPrefix(session.sessionID, inv.session) {
+		warnings = append(warnings, fmt.Sprintf("--session %s but process is on %s", inv.session, session.sessionID))
+	}
+	return warnings
+}
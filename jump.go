@@ -0,0 +1,216 @@
+// `otop jump`: hop to an agent's pane from outside the TUI, meant to be
+// bound to a tmux key (bind j display-popup -E "otop jump").
+//
+// with no arguments the running sessions are piped through fzf and the
+// chosen one is focused. `otop jump <query>` skips the picker: an exact
+// session ID wins, otherwise the best fuzzy match on title and directory.
+// `--list` prints the picker lines for other pickers; the session ID is
+// the first tab-separated field. like `otop project`, the running TUI is
+// asked over its socket first and a direct collection is the fallback.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// jumpEntry is one session the picker offers.
+type jumpEntry struct {
+	SessionID       string `json:"session_id"`
+	Status          string `json:"status"`
+	Title           string `json:"title"`
+	Directory       string `json:"directory"`
+	Interactive     bool   `json:"interactive"`
+	LastMessageTime int64  `json:"last_message_time"`
+}
+
+// line renders the entry for a picker: ID, status, title, directory.
+func (e jumpEntry) line() string {
+	title := strings.ReplaceAll(e.Title, "\t", " ")
+	return strings.Join([]string{e.SessionID, e.Status, title, shortPath(e.Directory, 60)}, "\t")
+}
+
+// jumpCommand runs `otop jump`.
+func jumpCommand(query string, list bool) error {
+	entries := jumpEntries()
+	if list {
+		for _, e := range entries {
+			fmt.Println(e.line())
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		return errors.New("no running sessions")
+	}
+
+	var sessionID string
+	if query != "" {
+		e, ok := matchJumpEntry(entries, query)
+		if !ok {
+			return fmt.Errorf("no session matches %q", query)
+		}
+		sessionID = e.SessionID
+	} else {
+		picked, err := pickWithFzf(entries)
+		if err != nil || picked == "" {
+			return err
+		}
+		sessionID = picked
+	}
+	return jumpTo(sessionID)
+}
+
+// jumpEntries lists running interactive sessions, most recently active
+// first.
+func jumpEntries() []jumpEntry {
+	entries, ok := jumpEntriesFromSocket()
+	if !ok {
+		entries = jumpEntriesFromCollection()
+	}
+	entries = slices.DeleteFunc(entries, func(e jumpEntry) bool {
+		return !e.Interactive || !dirInScope(e.Directory)
+	})
+	slices.SortStableFunc(entries, func(a, b jumpEntry) int {
+		return cmp.Compare(b.LastMessageTime, a.LastMessageTime)
+	})
+	return entries
+}
+
+// jumpEntriesFromSocket reads sessions from a running TUI. ok is false if
+// none answered.
+func jumpEntriesFromSocket() ([]jumpEntry, bool) {
+	resp, err := socketClient().Get("http://otop/sessions")
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	var payload struct {
+		Sessions []jumpEntry `json:"sessions"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&payload) != nil {
+		return nil, false
+	}
+	return payload.Sessions, true
+}
+
+// jumpEntriesFromCollection collects directly.
+func jumpEntriesFromCollection() []jumpEntry {
+	if firstMissingDB() != "" {
+		return nil
+	}
+	_, correlated, err := correlateAllSessions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: db error: %v\n", err)
+	}
+	var entries []jumpEntry
+	for _, cs := range correlated {
+		if cs.process.isToolProcess || cs.session == nil {
+			continue
+		}
+		entries = append(entries, jumpEntry{
+			SessionID:       cs.session.sessionID,
+			Status:          inferStatus(cs.session, cs.process.cpuPercent),
+			Title:           cs.session.title,
+			Directory:       cs.session.directory,
+			Interactive:     cs.session.interactive,
+			LastMessageTime: cs.session.lastMessageTime,
+		})
+	}
+	return entries
+}
+
+// matchJumpEntry finds the session a query names: an exact or prefix
+// session ID, else the best fuzzy match on title and directory.
+func matchJumpEntry(entries []jumpEntry, query string) (jumpEntry, bool) {
+	for _, e := range entries {
+		if e.SessionID == query {
+			return e, true
+		}
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.SessionID, query) {
+			return e, true
+		}
+	}
+	var (
+		best      jumpEntry
+		bestScore int
+		found     bool
+	)
+	// fuzzyScore wants the pattern lowercased already
+	pattern := strings.ToLower(query)
+	for _, e := range entries {
+		score := 0
+		for _, field := range []string{e.Title, e.Directory} {
+			if s, _, ok := fuzzyScore(pattern, field); ok {
+				score = max(score, s)
+			}
+		}
+		if score > 0 && (!found || score > bestScore) {
+			best, bestScore, found = e, score, true
+		}
+	}
+	return best, found
+}
+
+// pickWithFzf runs fzf over the entries and returns the chosen session
+// ID, or "" if the picker was cancelled.
+func pickWithFzf(entries []jumpEntry) (string, error) {
+	if _, err := exec.LookPath("fzf"); err != nil {
+		return "", errors.New("fzf not found; pass a query, or pipe `otop jump --list` into a picker")
+	}
+	var input bytes.Buffer
+	for _, e := range entries {
+		input.WriteString(e.line() + "\n")
+	}
+	cmd := exec.Command("fzf", "--delimiter=\t", "--with-nth=2..", "--prompt=jump> ", "--no-sort")
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// 130 is fzf's exit code for esc / ctrl-c, 1 for no match
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 130 || exitErr.ExitCode() == 1) {
+			return "", nil
+		}
+		return "", fmt.Errorf("fzf: %w", err)
+	}
+	sessionID, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	return sessionID, nil
+}
+
+// jumpTo focuses a session's pane, through the running TUI if there is
+// one.
+func jumpTo(sessionID string) error {
+	resp, err := socketClient().Post("http://otop/sessions/"+sessionID+"/jump", "application/json", nil)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+	}
+
+	_, correlated, _ := correlateAllSessions()
+	for _, cs := range correlated {
+		if cs.session == nil || cs.process.isToolProcess || cs.session.sessionID != sessionID {
+			continue
+		}
+		if focusPane(cs.process) == "" {
+			return errors.New("no pane provider could focus the session")
+		}
+		return nil
+	}
+	return fmt.Errorf("session %s is not running", sessionID)
+}
@@ -6,24 +6,51 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	if err := loadConfigFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadEnvConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	loadSessionNotes()
+
+	// --db and --cwd work with every subcommand, so they're pulled out before dispatch
+	args, dbFlags, cwdOnly := extractGlobalFlags(os.Args[1:])
+	os.Args = append(os.Args[:1], args...)
+	setDBSources(dbFlags)
+	if cwdOnly {
+		scopeToCwd()
+	}
+
 	// `otop sessions` subcommand — JSON output for scripting
 	if len(os.Args) > 1 && os.Args[1] == "sessions" {
 		fs := flag.NewFlagSet("sessions", flag.ExitOnError)
 		all := fs.Bool("all", false, "include tool processes and unmatched")
 		fs.BoolVar(all, "a", false, "include tool processes and unmatched")
 		noninteractive := fs.Bool("include-noninteractive", false, "include non-interactive sessions")
+		var dirs, sessions listFlag
+		fs.Var(&dirs, "dir", "only sessions in this directory or glob, or below it (repeatable)")
+		fs.Var(&sessions, "session", "only this session ID (repeatable)")
 		_ = fs.Parse(os.Args[2:])
+		setWatch(dirs, sessions)
 
-		if _, err := os.Stat(dbPath()); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", dbPath())
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
 			os.Exit(1)
 		}
+		if err := schemaProblem(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
 		sessionsCommand(*all, *noninteractive)
 		return
 	}
@@ -43,39 +70,290 @@ func main() {
 		fs := flag.NewFlagSet("serve", flag.ExitOnError)
 		port := fs.Int("port", defaultServePort, "port to listen on")
 		fs.IntVar(port, "p", defaultServePort, "port to listen on")
+		requirePairing := fs.Bool("require-pairing", false, "only answer devices paired via `otop pair`")
+		interval := fs.Duration("interval", refreshInterval, "how often to collect session data")
+		staleAfter := fs.Duration("stale-after", 10*time.Second, "mark snapshots older than this as stale")
+		allowOrigin := fs.String("allow-origin", "", "comma-separated browser origins allowed to open /ws")
+		_ = fs.Parse(os.Args[2:])
+		if *interval < minRefreshInterval {
+			fmt.Fprintf(os.Stderr, "error: --interval: %s is below the %s minimum\n", *interval, minRefreshInterval)
+			os.Exit(1)
+		}
+
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		serveCommand(serveConfig{
+			port:           *port,
+			requirePairing: *requirePairing,
+			interval:       *interval,
+			staleAfter:     *staleAfter,
+			allowOrigins:   splitList(*allowOrigin),
+		})
+		return
+	}
+
+	// `otop pair` subcommand — one-time pairing codes for the Rose companion
+	if len(os.Args) > 1 && os.Args[1] == "pair" {
+		fs := flag.NewFlagSet("pair", flag.ExitOnError)
+		list := fs.Bool("list", false, "list paired devices")
+		revoke := fs.String("revoke", "", "revoke the paired device with this name")
+		_ = fs.Parse(os.Args[2:])
+		pairCommand(*list, *revoke)
+		return
+	}
+
+	// `otop export-messages` subcommand — per-message csv/jsonl for notebooks
+	if len(os.Args) > 1 && os.Args[1] == "export-messages" {
+		fs := flag.NewFlagSet("export-messages", flag.ExitOnError)
+		session := fs.String("session", "", "session ID to export")
+		fs.StringVar(session, "s", "", "session ID to export")
+		format := fs.String("format", "csv", "output format: csv or jsonl")
+		_ = fs.Parse(os.Args[2:])
+
+		if *session == "" {
+			fmt.Fprintln(os.Stderr, "error: --session is required")
+			os.Exit(1)
+		}
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		exportMessagesCommand(*session, *format)
+		return
+	}
+
+	// `otop show` subcommand — one-session report for scripts and ssh
+	if len(os.Args) > 1 && os.Args[1] == "show" {
+		fs := flag.NewFlagSet("show", flag.ExitOnError)
+		format := fs.String("format", "text", "output format: text or json")
+		messages := fs.Int("messages", 10, "how many recent messages to include")
+		fs.IntVar(messages, "n", 10, "how many recent messages to include")
 		_ = fs.Parse(os.Args[2:])
 
-		if _, err := os.Stat(dbPath()); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", dbPath())
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "error: usage: otop show [--format text|json] [-n N] <session-id>")
+			os.Exit(1)
+		}
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		if err := showCommand(fs.Arg(0), *format, *messages); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
-		serveCommand(*port)
+		return
+	}
+
+	// `otop tail` subcommand — follow a session's transcript
+	if len(os.Args) > 1 && os.Args[1] == "tail" {
+		fs := flag.NewFlagSet("tail", flag.ExitOnError)
+		format := fs.String("format", "text", "output format: text or jsonl")
+		last := fs.Int("n", 10, "how many earlier entries to print first")
+		noFollow := fs.Bool("no-follow", false, "print the last entries and exit")
+		_ = fs.Parse(os.Args[2:])
+
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "error: usage: otop tail [--format text|jsonl] [-n N] [--no-follow] <session-id>")
+			os.Exit(1)
+		}
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		if err := tailCommand(fs.Arg(0), *format, *last, !*noFollow); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// `otop retro` subcommand — weekly retrospective summary
+	if len(os.Args) > 1 && os.Args[1] == "retro" {
+		fs := flag.NewFlagSet("retro", flag.ExitOnError)
+		days := fs.Int("days", 7, "how many days back to summarize")
+		fs.IntVar(days, "d", 7, "how many days back to summarize")
+		_ = fs.Parse(os.Args[2:])
+
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		retroCommand(*days)
+		return
+	}
+
+	// `otop project` subcommand — best session for a directory, for editor statuslines
+	if len(os.Args) > 1 && os.Args[1] == "project" {
+		fs := flag.NewFlagSet("project", flag.ExitOnError)
+		format := fs.String("format", "json", "output format: json or line")
+		_ = fs.Parse(os.Args[2:])
+		projectCommand(projectDirArg(fs.Args()), *format)
+		return
+	}
+
+	// `otop status` subcommand — one-line summary for tmux status-right
+	if len(os.Args) > 1 && os.Args[1] == "status" {
+		fs := flag.NewFlagSet("status", flag.ExitOnError)
+		format := fs.String("format", defaultStatusFormat, "template: {gen} {wait} {idle} {total} or any status, e.g. {asking}")
+		_ = fs.Parse(os.Args[2:])
+		statusCommand(*format)
+		return
+	}
+
+	// `otop jump` subcommand — fuzzy-pick a session and focus its pane
+	if len(os.Args) > 1 && os.Args[1] == "jump" {
+		fs := flag.NewFlagSet("jump", flag.ExitOnError)
+		list := fs.Bool("list", false, "print picker lines (ID, status, title, dir; tab-separated) and exit")
+		_ = fs.Parse(os.Args[2:])
+		if err := jumpCommand(strings.Join(fs.Args(), " "), *list); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// `otop prompt` subcommand — shell prompt segment for the current directory
+	if len(os.Args) > 1 && os.Args[1] == "prompt" {
+		promptCommand()
+		return
+	}
+
+	// `otop --once` / `otop snapshot` — render the list once to stdout
+	if len(os.Args) > 1 && (os.Args[1] == "--once" || os.Args[1] == "-once" || os.Args[1] == "snapshot") {
+		if missing := firstMissingDB(); missing != "" {
+			fmt.Fprintf(os.Stderr, "error: opencode db not found at %s\n", missing)
+			os.Exit(1)
+		}
+		onceCommand()
+		return
+	}
+
+	// `otop doctor` subcommand — capability report
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		doctorCommand()
 		return
 	}
 
 	// default: launch TUI
-	if _, err := os.Stat(dbPath()); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "error: opencode db not found at %s\n", dbPath())
+	fs := flag.NewFlagSet("otop", flag.ExitOnError)
+	record := fs.String("record", "", "append every fetch to this trace file")
+	replay := fs.String("replay", "", "run off a recorded trace instead of collecting")
+	oneLine := fs.Bool("one-line", false, "start in the one-line layout")
+	full := fs.Bool("full", false, "start in the two-line layout")
+	icons := fs.String("icons", "", "status glyphs in the one-line layout: nerd, unicode, ascii or off")
+	var dirs, sessions listFlag
+	fs.Var(&dirs, "dir", "only sessions in this directory or glob, or below it (repeatable)")
+	fs.Var(&sessions, "session", "only this session ID (repeatable)")
+	_ = fs.Parse(os.Args[1:])
+	setWatch(dirs, sessions)
+
+	m := newModel()
+	m.restoreUIState()
+	m.applyEnvState()
+	if *oneLine {
+		display.oneLine = true
+	}
+	if *full {
+		display.oneLine = false
+	}
+	if *icons != "" {
+		if err := setStatusIcons(*icons); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --icons: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	m.tickerRunning = display.oneLine && display.ticker.rateMS > 0
+	if *replay != "" {
+		frames, err := loadTrace(*replay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: replay: %v\n", err)
+			os.Exit(1)
+		}
+		m.replay = frames
+	} else if missing := firstMissingDB(); missing != "" {
+		fmt.Fprintf(os.Stderr, "error: opencode db not found at %s\n", missing)
 		os.Exit(1)
 	}
 
+	others, unregisterInstance := registerInstance()
+	stopSocket := func() {}
+	if m.replay == nil {
+		stopSocket = startSocketAPI() // a replay must not answer for live sessions
+	}
+	stopRecording := func() {}
+	if *record != "" {
+		stop, err := startRecording(*record)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: record: %v\n", err)
+			os.Exit(1)
+		}
+		stopRecording = stop
+	}
+	bus.subscribe(ringBell)
+	bus.subscribe(recordTransition)
+	stopHistory := func() {}
+	if display.history && m.replay == nil {
+		if stop, err := startHistory(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: history: %v\n", err)
+		} else {
+			stopHistory = stop
+		}
+	}
+	unregister := func() {
+		stopHistory()
+		stopRecording()
+		stopSocket()
+		unregisterInstance()
+	}
+
 	// clean exit on SIGTERM/SIGHUP so alt screen gets restored
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
 		<-sigCh
+		unregister()
 		os.Exit(0)
 	}()
 
 	setProcessTitle()
 
-	p := tea.NewProgram(newModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	m.otherInstances = others
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	final, err := p.Run()
+	unregister()
+	if fm, ok := final.(model); ok {
+		fm.saveUIState()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// extractGlobalFlags removes --db (repeatable, "--db path" or
+// "--db=path") and --cwd from args, returning the rest plus their values.
+func extractGlobalFlags(args []string) (rest, dbs []string, cwd bool) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--cwd" || args[i] == "-cwd":
+			cwd = true
+		case args[i] == "--db" || args[i] == "-db":
+			if i+1 < len(args) {
+				dbs = append(dbs, args[i+1])
+				i++
+			}
+		case strings.HasPrefix(args[i], "--db="):
+			dbs = append(dbs, strings.TrimPrefix(args[i], "--db="))
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest, dbs, cwd
+}
+
 // setProcessTitle sets tmux window name and xterm title.
 func setProcessTitle() {
 	fmt.Print("\033kotop\033\\")
@@ -84,7 +362,11 @@ func setProcessTitle() {
 
 // sessionsCommand outputs running opencode sessions as JSON.
 func sessionsCommand(includeAll, includeNoninteractive bool) {
-	_, correlated := correlateAllSessions()
+	_, correlated, err := correlateAllSessions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: db error: %v\n", err)
+	}
+	nowMS := time.Now().UnixMilli()
 
 	var results []map[string]any
 	for _, cs := range correlated {
@@ -94,28 +376,57 @@ func sessionsCommand(includeAll, includeNoninteractive bool) {
 		if !includeNoninteractive && cs.session != nil && !cs.session.interactive {
 			continue
 		}
+		if !sessionInScope(cs) {
+			continue
+		}
+
+		tmuxPane := ""
+		if cs.process.host == "" { // a remote tty names a pane on the other machine
+			tmuxPane = tmuxPanes()[cs.process.tty].address
+		}
 
-		tmuxPane := tmuxPaneForTTY(cs.process.tty)
+		uptimeMS := int64(0)
+		if cs.process.startTimeMS > 0 {
+			uptimeMS = nowMS - cs.process.startTimeMS
+		}
 
 		entry := map[string]any{
-			"pid":             cs.process.pid,
-			"tty":             cs.process.tty,
-			"cwd":             cs.process.cwd,
-			"cpu_percent":     cs.process.cpuPercent,
-			"mem_mb":          cs.process.memMB,
-			"is_tool_process": cs.process.isToolProcess,
-			"tmux_pane":       tmuxPane,
+			"pid":                  cs.process.pid,
+			"tty":                  cs.process.tty,
+			"cwd":                  cs.process.cwd,
+			"cpu_percent":          cs.process.cpuPercent,
+			"mem_mb":               cs.process.memMB,
+			"children_cpu_percent": cs.process.totalCPU() - cs.process.cpuPercent,
+			"children_mem_mb":      cs.process.totalMemMB() - cs.process.memMB,
+			"is_tool_process":      cs.process.isToolProcess,
+			"tmux_pane":            tmuxPane,
+			"api_port":             cs.process.apiPort,
+			"uptime_ms":            uptimeMS,
+			"uptime_human":         formatDuration(uptimeMS),
 		}
 
 		if cs.session != nil {
+			roundMS := int64(0)
+			if cs.session.roundStartTime > 0 {
+				roundMS = nowMS - cs.session.roundStartTime
+			}
 			entry["session"] = map[string]any{
-				"id":            cs.session.sessionID,
-				"title":         cs.session.title,
-				"directory":     cs.session.directory,
-				"model":         cs.session.model,
-				"status":        inferStatus(cs.session, cs.process.cpuPercent),
-				"message_count": cs.session.messageCount,
-				"interactive":   cs.session.interactive,
+				"id":               cs.session.sessionID,
+				"source":           cs.session.source,
+				"title":            cs.session.title,
+				"directory":        cs.session.directory,
+				"model":            cs.session.model,
+				"model_short":      shortModel(cs.session.model),
+				"provider":         cs.session.provider,
+				"status":           inferStatus(cs.session, cs.process.cpuPercent),
+				"message_count":    cs.session.messageCount,
+				"interactive":      cs.session.interactive,
+				"cost":             cs.session.totalCost,
+				"last_output":      cs.session.lastOutput,
+				"last_output_full": cs.session.lastOutputFull,
+				"round_ms":         roundMS,
+				"round_human":      formatDuration(roundMS),
+				"todo_summary":     todoSummary(cs.session.activeTodos),
 			}
 		}
 
@@ -0,0 +1,302 @@
+// MCP server health for the m panel. the config in opencode.json only
+// says what should run, so each configured server is checked two ways:
+//
+//   - opencode's API (GET /mcp) on every instance with a listening port
+//     reports connected / failed / needs_auth per server
+//   - local servers are stdio children of opencode, so the process tree
+//     under each opencode PID is searched for the server's command,
+//     which gives the PID and memory even without the API
+//
+// with a session selected, the panel covers that session alone: its
+// project's merged config (projectconfig.go) and its own instance.
+// otherwise it covers the global config across every instance. probing
+// runs only while the panel is open, every mcpHealthRefresh or when the
+// selection moves to another directory.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const mcpHealthRefresh = 5 * time.Second
+
+// mcpServerHealth is the probed state of one configured MCP server.
+type mcpServerHealth struct {
+	name      string
+	remote    bool
+	status    string  // connected, failed, needs_auth, disabled, running, down, unknown
+	err       string  // from the API when a connection failed
+	connected int     // instances reporting it connected
+	instances int     // instances that answered GET /mcp
+	pids      []int   // matching child processes (local servers)
+	memMB     float64 // RSS of the matching processes and their children
+	local     string  // project config file that defines or overrides it
+}
+
+// mcpHealthMsg carries the probe results for dir, sorted by name.
+type mcpHealthMsg struct {
+	dir     string
+	servers []mcpServerHealth
+}
+
+// mcpAPIStatus is one entry of opencode's GET /mcp response.
+type mcpAPIStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// mcpHealthCmd probes the servers configured for dir ("" = global) on
+// the given processes.
+func mcpHealthCmd(dir string, procs []processInfo) tea.Cmd {
+	return func() tea.Msg {
+		config, overrides := effectiveMCPConfig(dir)
+		servers := probeMCPHealth(config, procs)
+		for i := range servers {
+			servers[i].local = overrides[servers[i].name]
+		}
+		return mcpHealthMsg{dir: dir, servers: servers}
+	}
+}
+
+// mcpScope returns the selected session's directory and process, or ""
+// and every process when nothing is selected.
+func (m model) mcpScope() (string, []processInfo) {
+	if cs, ok := m.selectedSession(); ok && m.selectMode {
+		dir := cs.process.cwd
+		if cs.session != nil && cs.session.directory != "" {
+			dir = cs.session.directory
+		}
+		return dir, []processInfo{cs.process}
+	}
+	procs := make([]processInfo, len(m.sessions))
+	for i, cs := range m.sessions {
+		procs[i] = cs.process
+	}
+	return "", procs
+}
+
+// mcpHealthStale reports whether the panel's probe should be rerun.
+func (m model) mcpHealthStale() bool {
+	if !m.showMCPs {
+		return false
+	}
+	dir, _ := m.mcpScope()
+	return dir != m.mcpHealthDir || time.Since(m.mcpHealthAt) > mcpHealthRefresh
+}
+
+// refreshMCPHealth starts a probe for the current scope.
+func (m *model) refreshMCPHealth() tea.Cmd {
+	dir, procs := m.mcpScope()
+	m.mcpHealthAt = time.Now() // don't queue another probe while this one runs
+	if dir != m.mcpHealthDir {
+		m.mcpHealth = nil // another project's servers; don't show them meanwhile
+	}
+	m.mcpHealthDir = dir
+	return mcpHealthCmd(dir, procs)
+}
+
+func probeMCPHealth(config map[string]any, procs []processInfo) []mcpServerHealth {
+	apiStatus := queryMCPStatus(procs)
+	children := processTable()
+
+	var opencodePIDs []int
+	for _, p := range procs {
+		if !p.isToolProcess {
+			opencodePIDs = append(opencodePIDs, p.pid)
+		}
+	}
+	var result []mcpServerHealth
+	for name, raw := range config {
+		cfg, _ := raw.(map[string]any)
+		h := mcpServerHealth{name: name, remote: cfg["type"] == "remote", instances: len(apiStatus)}
+		if en, ok := cfg["enabled"].(bool); ok && !en {
+			h.status = "disabled"
+			result = append(result, h)
+			continue
+		}
+
+		for _, statuses := range apiStatus {
+			st, ok := statuses[name]
+			if !ok {
+				continue
+			}
+			if st.Status == "connected" {
+				h.connected++
+			} else if h.status == "" {
+				h.status = st.Status
+				h.err = st.Error
+			}
+		}
+
+		if token := mcpCommandToken(cfg["command"]); token != "" {
+			for _, pid := range opencodePIDs {
+				if e, ok := findDescendant(children, pid, token); ok {
+					h.pids = append(h.pids, e.pid)
+					h.memMB += subtreeMem(children, e)
+				}
+			}
+		}
+
+		switch {
+		case h.status != "":
+			// an instance reported a failure; it outranks the others
+		case h.connected > 0:
+			h.status = "connected"
+		case len(h.pids) > 0:
+			h.status = "running"
+		case !h.remote && len(opencodePIDs) > 0:
+			h.status = "down"
+		default:
+			h.status = "unknown"
+		}
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// queryMCPStatus asks every opencode instance with an API port for its
+// MCP status, in parallel. instances that don't answer are left out.
+func queryMCPStatus(procs []processInfo) []map[string]mcpAPIStatus {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []map[string]mcpAPIStatus
+	)
+	for _, p := range procs {
+		if p.apiPort == 0 || p.isToolProcess {
+			continue
+		}
+		wg.Add(1)
+		go func(p processInfo) {
+			defer wg.Done()
+			var statuses map[string]mcpAPIStatus
+			if opencodeGet(p, "/mcp", &statuses, time.Second) != nil {
+				return
+			}
+			mu.Lock()
+			results = append(results, statuses)
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+	return results
+}
+
+// processTable lists every process by parent, from the same ps call
+// and parser as the process list (process.go).
+func processTable() map[int][]childProcess {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", "axo", psFormat).Output()
+	if err != nil {
+		return nil
+	}
+	_, all := parsePS(string(out))
+	return all
+}
+
+// mcpCommandToken picks the most specific word of a local server's
+// command to look for in process args: the last argument that isn't a
+// flag, reduced to its base name (a package or script name). wrappers
+// like npx or uvx re-exec under different binaries, so the package name
+// is a better match than the binary.
+func mcpCommandToken(command any) string {
+	words, _ := command.([]any)
+	for i := len(words) - 1; i >= 0; i-- {
+		w, _ := words[i].(string)
+		if w == "" || strings.HasPrefix(w, "-") {
+			continue
+		}
+		return filepath.Base(w)
+	}
+	return ""
+}
+
+// findDescendant returns the shallowest process under root whose args
+// contain token.
+func findDescendant(children map[int][]childProcess, root int, token string) (childProcess, bool) {
+	queue := children[root]
+	for depth := 0; depth < 4 && len(queue) > 0; depth++ {
+		var next []childProcess
+		for _, e := range queue {
+			if strings.Contains(e.args, token) {
+				return e, true
+			}
+			next = append(next, children[e.pid]...)
+		}
+		queue = next
+	}
+	return childProcess{}, false
+}
+
+// mcpStatusRank orders statuses worst first; disabled servers go last.
+func mcpStatusRank(status string) int {
+	switch status {
+	case "failed", "down":
+		return 0
+	case "needs_auth", "needs_client_registration":
+		return 1
+	case "unknown":
+		return 2
+	case "disabled":
+		return 4
+	}
+	return 3
+}
+
+// mcpStatusStyle colors a server status in the panel.
+func mcpStatusStyle(status string) lipgloss.Style {
+	switch status {
+	case "connected", "running":
+		return activeStyle
+	case "failed", "down":
+		return errorStyle
+	case "needs_auth", "needs_client_registration":
+		return askingStyle
+	}
+	return dimStyle
+}
+
+// renderMCPHealthRow formats one server for the panel.
+func (m model) renderMCPHealthRow(h mcpServerHealth) string {
+	name := h.name
+	if h.local != "" {
+		name += "*"
+	}
+	line := "  " + truncOrPad(name, 20) + "  " + truncOrPad(h.status, 10)
+	if h.instances > 0 && h.status != "disabled" {
+		line += fmt.Sprintf("  up %d/%d", h.connected, h.instances)
+	}
+	if len(h.pids) > 0 {
+		line += fmt.Sprintf("  pid %d", h.pids[0])
+		if len(h.pids) > 1 {
+			line += fmt.Sprintf(" +%d", len(h.pids)-1)
+		}
+		line += "  " + formatMem(h.memMB)
+	}
+	if h.err != "" {
+		line += "  " + h.err
+	}
+	return mcpStatusStyle(h.status).Render(truncOrPad(line, m.width))
+}
+
+// subtreeMem sums the memory of e and everything below it, in MB.
+func subtreeMem(children map[int][]childProcess, e childProcess) float64 {
+	total := e.memMB
+	for _, c := range children[e.pid] {
+		total += subtreeMem(children, c)
+	}
+	return total
+}
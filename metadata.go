@@ -0,0 +1,93 @@
+// session metadata block for the detail view (I). the info bar only has
+// room for the process side; this lists what the db knows about the
+// session (times, project, version, agent, permission mode, totals) and
+// how otop matched the process to it.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// correlationTier describes how a process's session was found.
+func correlationTier(proc processInfo, inv invocation) string {
+	switch {
+	case proc.sessionID == "":
+		return "unmatched (no plugin PID file)"
+	case proc.guessed:
+		return fmt.Sprintf("best activity fit under the container's working directory (score %.2f)", proc.matchScore)
+	case proc.container != "":
+		return "plugin PID file in " + proc.container
+	case proc.host != "":
+		return "plugin PID file on " + proc.host
+	case inv.session != "" && inv.session == proc.sessionID:
+		return "plugin PID file (agrees with --session)"
+	}
+	return "plugin PID file"
+}
+
+// metadataLines renders the metadata block, one "key  value" row per line.
+func (m model) metadataLines() []string {
+	session := m.detailSession.session
+	proc := m.detailSession.process
+	if session == nil {
+		return []string{" correlation  " + correlationTier(proc, m.detailInvoc)}
+	}
+
+	nowMS := time.Now().UnixMilli()
+	at := func(ms int64) string {
+		if ms <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%s (%s ago)", time.UnixMilli(ms).Format("2006-01-02 15:04"), formatDuration(nowMS-ms))
+	}
+	permission := "interactive"
+	if !session.interactive {
+		permission = "non-interactive (permission rules set)"
+	}
+	version := opencodeVersion(*m.detailSession)
+	if proc.version != "" && session.version != "" && session.version != proc.version {
+		version += "  (session created on " + session.version + ")"
+	}
+	if m.versionBehind(*m.detailSession) {
+		version += fmt.Sprintf("  (behind %s, restart to upgrade)", m.newestVersions[proc.host])
+	}
+	tier := correlationTier(proc, m.detailInvoc)
+	if len(m.competitors(*m.detailSession)) > 0 || len(proc.candidates) > 0 {
+		tier += ", ambiguous"
+	}
+	model := session.model
+	if session.provider != "" {
+		model = session.provider + "/" + model
+	}
+
+	rows := [][2]string{
+		{"created", at(session.timeCreated)},
+		{"updated", at(session.timeUpdated)},
+		{"project", session.projectID},
+		{"version", version},
+		{"agent", session.agent + "  model " + model},
+		{"permission", permission},
+		{"tokens", fmt.Sprintf("in %s  out %s  cache read %s  write %s  hit %.0f%%",
+			formatTokens(session.totalInputTokens), formatTokens(session.totalOutputTokens),
+			formatTokens(session.totalCacheRead), formatTokens(session.totalCacheWrite), cacheHitPercent(session))},
+		{"cost", fmt.Sprintf("%s over %d messages in %d rounds", formatCost(session.totalCost), session.messageCount, session.roundCount)},
+		{"tools", fmt.Sprintf("%d calls  %s", session.toolCalls, toolSummary(session, 8))},
+		{"correlation", tier},
+	}
+	if session.source != "" {
+		rows = append(rows, [2]string{"database", session.source})
+	}
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		value := row[1]
+		if strings.TrimSpace(value) == "" {
+			value = "-"
+		}
+		lines[i] = fmt.Sprintf(" %-11s  %s", row[0], value)
+	}
+	return lines
+}
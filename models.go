@@ -0,0 +1,102 @@
+// per-model usage panel (M): today's assistant messages grouped by
+// model, with sessions, messages, tokens and cost per model, most
+// expensive first, to show where the spend goes (opus vs sonnet, ...).
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	modelUsageRefresh = time.Minute
+	modelUsageMaxRows = 8 // models listed; the rest are summed into one row
+)
+
+// modelUsage is today's usage by one model.
+type modelUsage struct {
+	model     string
+	sessions  int
+	messages  int
+	tokensIn  int64 // fresh input
+	tokensOut int64
+	cacheRead int64
+	cost      float64
+}
+
+// modelUsageMsg carries the panel rows, most expensive first.
+type modelUsageMsg struct {
+	rows []modelUsage
+	err  error
+}
+
+func modelUsageCmd() tea.Msg {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	rows, err := queryModelUsage(today.UnixMilli())
+	return modelUsageMsg{rows: rows, err: err}
+}
+
+// modelUsageStale reports whether the panel's data should be reloaded.
+func (m model) modelUsageStale() bool {
+	return m.showModelUsage && time.Since(m.modelUsageAt) > modelUsageRefresh
+}
+
+// modelUsagePanelRows is the panel height: separator, header, and one
+// line per model (at least one, for the placeholder).
+func (m model) modelUsagePanelRows() int {
+	return 2 + max(1, min(len(m.modelUsage), modelUsageMaxRows))
+}
+
+// renderModelUsagePanel draws the table, modelUsagePanelRows lines.
+func (m model) renderModelUsagePanel() string {
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	const labelW, numW = 18, 9
+	header := fmt.Sprintf("%-*s", labelW, " MODELS TODAY")
+	for _, h := range []string{"SESSIONS", "MSGS", "IN", "OUT", "CACHE R", "COST"} {
+		header += fmt.Sprintf("%*s", numW, h)
+	}
+	b.WriteString(panelStyle.Render(truncOrPad(header, m.width)))
+	b.WriteString("\n")
+
+	if len(m.modelUsage) == 0 {
+		label := "(no messages today)"
+		if m.modelUsageAt.IsZero() {
+			label = "..."
+		}
+		b.WriteString(dimStyle.Render(truncOrPad("  "+label, m.width)))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	rows := m.modelUsage
+	if len(rows) > modelUsageMaxRows {
+		rest := modelUsage{model: fmt.Sprintf("%d others", len(rows)-modelUsageMaxRows+1)}
+		for _, r := range rows[modelUsageMaxRows-1:] {
+			rest.sessions += r.sessions // a session using several counts once per model
+			rest.messages += r.messages
+			rest.tokensIn += r.tokensIn
+			rest.tokensOut += r.tokensOut
+			rest.cacheRead += r.cacheRead
+			rest.cost += r.cost
+		}
+		rows = append(rows[:modelUsageMaxRows-1:modelUsageMaxRows-1], rest)
+	}
+	for _, r := range rows {
+		line := fmt.Sprintf(" %-*s", labelW-1, truncOrPad(shortModel(r.model), labelW-2))
+		line += fmt.Sprintf("%*d%*d%*s%*s%*s%*s",
+			numW, r.sessions, numW, r.messages,
+			numW, formatTokens(r.tokensIn), numW, formatTokens(r.tokensOut),
+			numW, formatTokens(r.cacheRead), numW, fmt.Sprintf("$%.2f", r.cost))
+		b.WriteString(truncOrPad(line, m.width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
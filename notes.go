@@ -0,0 +1,94 @@
+// session nicknames and notes: a local alias shown in place of the db
+// title and a one-line note shown beside it, set with R and N. they live
+// in $XDG_DATA_HOME/otop/notes.json keyed by session ID, so they survive
+// restarts and never touch opencode's db. the filter matches both.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// sessionNote is what the user attached to one session.
+type sessionNote struct {
+	Alias string `json:"alias,omitempty"`
+	Note  string `json:"note,omitempty"`
+}
+
+// notes is loaded once at startup and edited from the TUI; the socket
+// goroutines can read it while a snapshot renders, hence the lock.
+var notes = struct {
+	sync.RWMutex
+	byID map[string]sessionNote
+}{byID: map[string]sessionNote{}}
+
+func notesPath() string {
+	return filepath.Join(otopStateDir(), "notes.json")
+}
+
+// loadSessionNotes reads notes.json. a missing or unreadable file means
+// no notes.
+func loadSessionNotes() {
+	data, err := os.ReadFile(notesPath())
+	if err != nil {
+		return
+	}
+	byID := map[string]sessionNote{}
+	if json.Unmarshal(data, &byID) != nil {
+		return
+	}
+	notes.Lock()
+	notes.byID = byID
+	notes.Unlock()
+}
+
+// noteFor returns the alias and note for a session, empty if none.
+func noteFor(sessionID string) sessionNote {
+	notes.RLock()
+	defer notes.RUnlock()
+	return notes.byID[sessionID]
+}
+
+// setNote stores n for sessionID (dropping it when both fields are empty)
+// and rewrites notes.json.
+func setNote(sessionID string, n sessionNote) error {
+	notes.Lock()
+	if n.Alias == "" && n.Note == "" {
+		delete(notes.byID, sessionID)
+	} else {
+		notes.byID[sessionID] = n
+	}
+	data, err := json.MarshalIndent(notes.byID, "", "  ")
+	notes.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(otopStateDir(), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(notesPath(), data, 0o644)
+}
+
+// sessionTitle is the session's alias if it has one, else its db title.
+func sessionTitle(s *sessionInfo) string {
+	if alias := noteFor(s.sessionID).Alias; alias != "" {
+		return alias
+	}
+	return s.title
+}
+
+// sessionLabel is sessionTitle followed by the note, for list rows.
+func sessionLabel(s *sessionInfo) string {
+	n := noteFor(s.sessionID)
+	title := s.title
+	if n.Alias != "" {
+		title = n.Alias
+	}
+	if n.Note != "" {
+		title += " · " + n.Note
+	}
+	return title
+}
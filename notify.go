@@ -0,0 +1,109 @@
+// status-change notifications.
+//
+// when a session moves from an active status (generating, thinking,
+// tool use, busy) to one listed in display.notify.statuses, a notice
+// line appears above the footer for a few seconds, optionally with a
+// terminal bell. transitions are detected across all sessions, not just
+// the visible page, so finishing agents scrolled off screen still show.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// activeStatuses are the statuses a session works in; leaving one of
+// these for a notify status counts as a transition.
+var activeStatuses = map[string]bool{
+	"generating": true,
+	"thinking":   true,
+	"tool use":   true,
+	"busy":       true,
+}
+
+// statusNotice is one detected transition.
+type statusNotice struct {
+	sessionID string
+	title     string
+	status    string
+	at        time.Time
+}
+
+// sessionStatuses maps session IDs to their current status.
+func sessionStatuses(correlated []correlatedSession) map[string]string {
+	statuses := make(map[string]string)
+	for _, cs := range correlated {
+		if cs.session == nil || cs.process.isToolProcess {
+			continue
+		}
+		statuses[cs.session.sessionID] = inferStatus(cs.session, cs.process.cpuPercent)
+	}
+	return statuses
+}
+
+// detectTransitions compares the previous statuses against the new data
+// and returns notices for sessions that just finished. a nil prev (first
+// fetch) yields nothing, so startup doesn't announce every idle session.
+func detectTransitions(prev map[string]string, correlated []correlatedSession) []statusNotice {
+	if prev == nil {
+		return nil
+	}
+	now := time.Now()
+	var notices []statusNotice
+	for _, cs := range correlated {
+		if cs.session == nil || cs.process.isToolProcess || !sessionInScope(cs) {
+			continue
+		}
+		before, ok := prev[cs.session.sessionID]
+		if !ok || !activeStatuses[before] {
+			continue
+		}
+		status := inferStatus(cs.session, cs.process.cpuPercent)
+		if slices.Contains(display.notify.statuses, status) || (status == "stuck" && display.watchdog.notify) {
+			notices = append(notices, statusNotice{sessionID: cs.session.sessionID, title: sessionTitle(cs.session), status: status, at: now})
+		}
+	}
+	return notices
+}
+
+// ringBell is a bus subscriber that rings the terminal bell on each
+// transition or budget crossing when display.notify.bell is set.
+func ringBell(ev event) {
+	switch ev.(type) {
+	case transitionEvent, budgetEvent:
+		if display.notify.bell {
+			fmt.Fprint(os.Stdout, "\a")
+		}
+	}
+}
+
+// liveNotices returns notices younger than display.notify.hold, newest first.
+func (m model) liveNotices() []statusNotice {
+	var live []statusNotice
+	for i := len(m.notices) - 1; i >= 0; i-- {
+		if time.Since(m.notices[i].at) < display.notify.hold {
+			live = append(live, m.notices[i])
+		}
+	}
+	return live
+}
+
+// renderNoticeLine renders live notices on one line, truncated to width.
+func (m model) renderNoticeLine() string {
+	var parts []string
+	for _, n := range m.liveNotices() {
+		title := n.title
+		if title == "" {
+			title = "(untitled)"
+		}
+		parts = append(parts, statusStyleFor(n.status).Render("● "+n.status)+" "+title)
+	}
+	line := " " + strings.Join(parts, dimStyle.Render("  ·  "))
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
@@ -0,0 +1,41 @@
+// one-shot render: `otop --once` (or `otop snapshot`) fetches once,
+// prints the list view to stdout without the alt screen, and exits.
+// for piping into files, cron summaries, and `watch otop --once`.
+// colors are dropped automatically when stdout isn't a terminal.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// onceWidth is the render width: the terminal's, then $COLUMNS, then 120.
+func onceWidth() int {
+	if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 120
+}
+
+// onceCommand renders every visible session once. the height is
+// unbounded so nothing is paged off; the key-hint footer is left out.
+func onceCommand() {
+	m := newModel()
+	m.once = true
+	m.width = onceWidth()
+	m.height = 1 << 16
+
+	updated, _ := m.handleData(fetchAll())
+	m = updated.(model)
+	if m.dbErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: db error: %v\n", m.dbErr)
+	}
+	fmt.Print(m.View())
+}
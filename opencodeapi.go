@@ -0,0 +1,112 @@
+// actions through opencode's local HTTP server.
+//
+// each opencode instance listens on a loopback port (found via lsof,
+// see processInfo.apiPort). otop uses it to drive sessions rather than
+// just watch them:
+//   - POST /session/<id>/abort         stop the current generation
+//   - POST /session/<id>/prompt_async  queue a user message
+//   - POST /session/<id>/summarize     compact the context
+//   - GET  /mcp                        MCP server status (mcp.go)
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errNoAPI means the process has no listening opencode server.
+var errNoAPI = errors.New("opencode API not available (no listening port)")
+
+// opencodeCall POSTs body (JSON-encoded unless nil) to path on the
+// process's server. non-2xx responses become errors with the body text.
+func opencodeCall(proc processInfo, path string, body any, timeout time.Duration) error {
+	if proc.apiPort == 0 {
+		return errNoAPI
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", proc.apiPort, path)
+	req, err := http.NewRequest(http.MethodPost, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if dir := proc.cwd; dir != "" && dir != "?" {
+		req.Header.Set("x-opencode-directory", dir)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// opencodeGet fetches path from the process's server and decodes the
+// JSON response into out.
+func opencodeGet(proc processInfo, path string, out any, timeout time.Duration) error {
+	if proc.apiPort == 0 {
+		return errNoAPI
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", proc.apiPort, path)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if dir := proc.cwd; dir != "" && dir != "?" {
+		req.Header.Set("x-opencode-directory", dir)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// abortSession stops the session's in-progress generation.
+func abortSession(proc processInfo, sessionID string) error {
+	return opencodeCall(proc, "/session/"+sessionID+"/abort", nil, 5*time.Second)
+}
+
+// sendPrompt queues a user message without waiting for the reply.
+func sendPrompt(proc processInfo, sessionID, text string) error {
+	body := map[string]any{
+		"parts": []map[string]string{{"type": "text", "text": text}},
+	}
+	return opencodeCall(proc, "/session/"+sessionID+"/prompt_async", body, 5*time.Second)
+}
+
+// compactSession summarizes the session's context with its current
+// model. blocks until the summary is written, which can take a while.
+func compactSession(proc processInfo, session *sessionInfo) error {
+	if session.provider == "" || session.model == "" || session.model == "?" {
+		return errors.New("compact: session has no model yet")
+	}
+	body := map[string]string{"providerID": session.provider, "modelID": session.model}
+	return opencodeCall(proc, "/session/"+session.sessionID+"/summarize", body, 5*time.Minute)
+}
@@ -0,0 +1,347 @@
+// trust-on-first-use device pairing for the Rose companion app.
+//
+// `otop pair` (or Q in the TUI) issues a one-time code, shown alongside
+// a QR code, that stays valid for a few minutes or five wrong guesses.
+// the phone POSTs it to /pair on the serve endpoint with a device name
+// and receives a persistent bearer token.
+// only sha256 hashes of codes and tokens are written to disk, in
+// $XDG_DATA_HOME/otop/devices.json, so each device can be revoked on
+// its own with `otop pair --revoke <name>`.
+//
+// auth is opt-in: `otop serve --require-pairing` rejects /sessions
+// requests without a paired token. bar-status doesn't send a token, so
+// leave pairing off for the serve instance SwiftBar talks to.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"rsc.io/qr"
+)
+
+const (
+	pairCodeTTL     = 5 * time.Minute
+	pairMaxFailures = 5 // wrong guesses before the pending code is dropped
+)
+
+// pairedDevice is one phone (or other client) that completed pairing.
+type pairedDevice struct {
+	Name      string `json:"name"`
+	TokenHash string `json:"token_hash"`
+	PairedAt  int64  `json:"paired_at"`
+}
+
+// pairingState is the on-disk format of devices.json.
+type pairingState struct {
+	Devices         []pairedDevice `json:"devices"`
+	PendingHash     string         `json:"pending_code_hash,omitempty"`
+	PendingExpires  int64          `json:"pending_expires,omitempty"`
+	PendingFailures int            `json:"pending_failures,omitempty"`
+}
+
+// pairingMu serializes read-modify-write cycles on devices.json
+// within a single process (serve handles requests concurrently).
+var pairingMu sync.Mutex
+
+func pairingStatePath() string {
+	return filepath.Join(otopStateDir(), "devices.json")
+}
+
+func loadPairingState() pairingState {
+	var state pairingState
+	data, err := os.ReadFile(pairingStatePath())
+	if err != nil {
+		return state
+	}
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+func savePairingState(state pairingState) error {
+	if err := os.MkdirAll(otopStateDir(), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pairingStatePath(), data, 0o600)
+}
+
+// hashSecret returns the hex sha256 of a code or token.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// pairCommand handles `otop pair`: issue a code, list, or revoke devices.
+func pairCommand(list bool, revoke string) {
+	pairingMu.Lock()
+	defer pairingMu.Unlock()
+	state := loadPairingState()
+
+	if list {
+		if len(state.Devices) == 0 {
+			fmt.Println("no paired devices")
+			return
+		}
+		for _, d := range state.Devices {
+			paired := time.UnixMilli(d.PairedAt).Format("2006-01-02 15:04")
+			fmt.Printf("%-20s paired %s\n", d.Name, paired)
+		}
+		return
+	}
+
+	if revoke != "" {
+		kept := state.Devices[:0]
+		for _, d := range state.Devices {
+			if d.Name != revoke {
+				kept = append(kept, d)
+			}
+		}
+		if len(kept) == len(state.Devices) {
+			fmt.Fprintf(os.Stderr, "error: no device named %q\n", revoke)
+			os.Exit(1)
+		}
+		state.Devices = kept
+		if err := savePairingState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("revoked %s\n", revoke)
+		return
+	}
+
+	code, err := issuePairingCode(&state)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(renderQR(pairingURL(code)))
+	fmt.Printf("\npairing code: %s (valid for %s)\n", code, pairCodeTTL)
+}
+
+// issuePairingCode replaces any pending code with a new one and saves
+// state. the caller holds pairingMu.
+func issuePairingCode(state *pairingState) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	state.PendingHash = hashSecret(code)
+	state.PendingExpires = time.Now().Add(pairCodeTTL).UnixMilli()
+	state.PendingFailures = 0
+	return code, savePairingState(*state)
+}
+
+// newPairingCode issues a code for the TUI's pairing screen (Q).
+func newPairingCode() (string, error) {
+	pairingMu.Lock()
+	defer pairingMu.Unlock()
+	state := loadPairingState()
+	return issuePairingCode(&state)
+}
+
+// pairingURL is what the QR code encodes.
+func pairingURL(code string) string {
+	return "otop://pair?code=" + code
+}
+
+// redeemPairingCode exchanges a one-time code for a new device token.
+// the code is consumed whether or not a device name collides, and
+// dropped after pairMaxFailures wrong guesses, so six digits can't be
+// brute-forced within the code's lifetime.
+func redeemPairingCode(code, name string) (string, error) {
+	pairingMu.Lock()
+	defer pairingMu.Unlock()
+	state := loadPairingState()
+
+	if state.PendingHash == "" || time.Now().UnixMilli() > state.PendingExpires {
+		return "", errors.New("no active pairing code")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(code)), []byte(state.PendingHash)) != 1 {
+		state.PendingFailures++
+		if state.PendingFailures >= pairMaxFailures {
+			state.PendingHash = ""
+			state.PendingExpires = 0
+			state.PendingFailures = 0
+		}
+		if err := savePairingState(state); err != nil {
+			return "", err
+		}
+		return "", errors.New("invalid pairing code")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	// re-pairing a device name replaces its old token
+	kept := state.Devices[:0]
+	for _, d := range state.Devices {
+		if d.Name != name {
+			kept = append(kept, d)
+		}
+	}
+	state.Devices = append(kept, pairedDevice{
+		Name:      name,
+		TokenHash: hashSecret(token),
+		PairedAt:  time.Now().UnixMilli(),
+	})
+	state.PendingHash = ""
+	state.PendingExpires = 0
+	state.PendingFailures = 0
+	if err := savePairingState(state); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// deviceForToken returns the name of the paired device owning token.
+func deviceForToken(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	pairingMu.Lock()
+	state := loadPairingState()
+	pairingMu.Unlock()
+
+	hash := hashSecret(token)
+	for _, d := range state.Devices {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(d.TokenHash)) == 1 {
+			return d.Name, true
+		}
+	}
+	return "", false
+}
+
+// handlePair implements POST /pair: {"code": "...", "name": "..."} -> {"token": "..."}.
+func handlePair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Code == "" || req.Name == "" {
+		http.Error(w, "code and name are required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := redeemPairingCode(req.Code, req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// requirePairedDevice wraps a handler so it only answers requests
+// carrying a paired token (Authorization: Bearer <token> or ?token=).
+func requirePairedDevice(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if _, ok := deviceForToken(token); !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// renderQR draws a QR code with unicode half blocks, two modules per
+// character row, with the 4-module quiet zone scanners expect.
+func renderQR(text string) string {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return ""
+	}
+
+	const quiet = 4
+	size := code.Size + 2*quiet
+	black := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Black(x, y)
+	}
+
+	// drawn light-on-dark: a "black" module is a space on the
+	// terminal's dark background, white modules are full blocks
+	var b strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := !black(x, y), !black(x, y+1)
+			if y+1 >= size {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// pairCodeCmd issues a pairing code off the UI goroutine.
+func pairCodeCmd() tea.Cmd {
+	return func() tea.Msg {
+		code, err := newPairingCode()
+		return pairCodeMsg{code: code, err: err}
+	}
+}
+
+// renderPairView shows the pending code as a QR for the phone to scan.
+func (m model) renderPairView() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(truncOrPad(" opencode > pair device", m.width)))
+	b.WriteString("\n\n")
+	if m.pairCode == "" {
+		b.WriteString(dimStyle.Render(" issuing a code..."))
+		b.WriteString("\n")
+		return b.String()
+	}
+	b.WriteString(renderQR(pairingURL(m.pairCode)))
+	fmt.Fprintf(&b, "\n pairing code: %s (valid for %s)\n", m.pairCode, pairCodeTTL)
+	b.WriteString(dimStyle.Render(" scan it with the companion app while otop serve is running"))
+	b.WriteString("\n\n")
+	b.WriteString(" " + keyStyle.Render("esc") + " " + helpStyle.Render("close"))
+	return b.String()
+}
@@ -0,0 +1,161 @@
+// pane providers: terminal multiplexer backends for locating and
+// capturing the pane an opencode process runs in.
+//
+// each provider maps processes to a pane location (shown in the TMUX
+// and WINDOW columns) and captures pane content for the detail view,
+// preview split, and wall. providers are tried in order; the first one
+// that knows a process wins.
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// paneLocation is where a process's pane lives inside a multiplexer.
+type paneLocation struct {
+	provider string // provider name, e.g. "tmux" or "zellij"
+	session  string // multiplexer session name
+	window   string // window/tab name
+}
+
+// paneProvider is a multiplexer backend.
+type paneProvider interface {
+	// name identifies the provider; also used as the detail view source tag.
+	name() string
+	// available reports whether the backing binary is installed. checked
+	// once per run so missing tools don't cost an exec on every refresh.
+	available() bool
+	// locate maps PIDs to pane locations for the processes it manages.
+	locate(procs []processInfo) map[int]paneLocation
+	// capture returns the screen content of a process's pane, nil if
+	// the process isn't in one of this provider's panes.
+	capture(proc processInfo) []string
+	// focus brings the process's pane to the front. returns false if the
+	// provider doesn't manage the process or can't focus it.
+	focus(proc processInfo) bool
+}
+
+// paneProviders lists the enabled backends in priority order.
+var paneProviders = []paneProvider{
+	tmuxProvider{},
+	zellijProvider{},
+	weztermProvider{},
+	kittyProvider{},
+	itermProvider{},
+}
+
+// paneCaptureAvailable reports whether any provider can capture panes.
+// when false, pane-only affordances (tab toggle, wall) are hidden.
+func paneCaptureAvailable() bool {
+	for _, p := range paneProviders {
+		if p.available() {
+			return true
+		}
+	}
+	return false
+}
+
+// locatePanes fills in multiplexer session/window names on processes.
+func locatePanes(procs []processInfo) {
+	for _, p := range paneProviders {
+		if !p.available() {
+			continue
+		}
+		done := timeStep("panes: " + p.name())
+		locations := p.locate(procs)
+		done()
+		for i := range procs {
+			if procs[i].tmuxSession != "" {
+				continue // an earlier provider already claimed it
+			}
+			if loc, ok := locations[procs[i].pid]; ok {
+				procs[i].tmuxSession = loc.session
+				procs[i].tmuxWindow = loc.window
+			}
+		}
+	}
+}
+
+// capturePane captures a process's pane from the first provider that
+// has it. returns the lines and the provider name, or nil and "".
+func capturePane(proc processInfo) ([]string, string) {
+	if proc.host != "" {
+		return nil, "" // the pane is on another machine
+	}
+	for _, p := range paneProviders {
+		if !p.available() {
+			continue
+		}
+		if lines := p.capture(proc); lines != nil {
+			return lines, p.name()
+		}
+	}
+	return nil, ""
+}
+
+// focusPane jumps to a process's pane using the first provider that can.
+// returns the provider name, or "" if nothing could focus it.
+func focusPane(proc processInfo) string {
+	if proc.host != "" {
+		return ""
+	}
+	for _, p := range paneProviders {
+		if p.available() && p.focus(proc) {
+			return p.name()
+		}
+	}
+	return ""
+}
+
+// -- tmux --
+
+type tmuxProvider struct{}
+
+// tmuxInstalled is checked once; without tmux every tmux helper is a no-op.
+var tmuxInstalled = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("tmux")
+	return err == nil
+})
+
+func (tmuxProvider) name() string    { return "tmux" }
+func (tmuxProvider) available() bool { return tmuxInstalled() }
+
+func (tmuxProvider) locate(procs []processInfo) map[int]paneLocation {
+	panes := tmuxPanes()
+	if panes == nil {
+		return nil
+	}
+	result := make(map[int]paneLocation)
+	for _, proc := range procs {
+		if info, ok := panes[proc.tty]; ok {
+			result[proc.pid] = paneLocation{provider: "tmux", session: info.session, window: info.window}
+		}
+	}
+	return result
+}
+
+func (tmuxProvider) capture(proc processInfo) []string {
+	return captureTmuxPane(proc.tty)
+}
+
+// focus selects the pane's window and pane, then switches the client to
+// its session. switch-client fails harmlessly when otop isn't in tmux.
+func (tmuxProvider) focus(proc processInfo) bool {
+	target := tmuxPaneForTTY(proc.tty)
+	if target == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if exec.CommandContext(ctx, "tmux", "select-window", "-t", target).Run() != nil {
+		return false
+	}
+	_ = exec.CommandContext(ctx, "tmux", "select-pane", "-t", target).Run()
+	_ = exec.CommandContext(ctx, "tmux", "switch-client", "-t", target).Run()
+	return true
+}
@@ -0,0 +1,49 @@
+// column picker: interactive chooser for one-line mode columns.
+//
+// pressing C lists every column from oneLineColumnOrder with a checkbox.
+// toggling and reordering mutate the active display config directly,
+// so the list view reflects changes as soon as the picker closes.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m model) renderColumnPicker() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(" opencode > sessions > columns"))
+	b.WriteString("\n")
+	note := " applies to one-line mode"
+	if display.oneLine {
+		note = " top to bottom = left to right"
+	}
+	b.WriteString(dimStyle.Render(note))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	for i, col := range oneLineColumnOrder {
+		check := " "
+		if display.columns.isEnabled(col.key) {
+			check = "x"
+		}
+		line := fmt.Sprintf("  [%s] %-8s %s", check, col.label, dimStyle.Render(col.key))
+		if i == m.pickerCursor {
+			line = selectStyle.Render(fmt.Sprintf("  [%s] %-8s %s", check, col.label, col.key))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	footer := " " +
+		keyStyle.Render("esc") + " " + helpStyle.Render("done") + "  " +
+		keyStyle.Render("j/k") + " " + helpStyle.Render("move") + "  " +
+		keyStyle.Render("space") + " " + helpStyle.Render("toggle") + "  " +
+		keyStyle.Render("J/K") + " " + helpStyle.Render("reorder")
+	b.WriteString(footer)
+
+	return b.String()
+}
@@ -5,6 +5,7 @@
 // session events and writes the active session ID on every change.
 //
 // lsof is still used for cwd (display) and log filename (uptime calculation).
+// multiplexer session/window names come from the pane providers in panes.go.
 // install the plugin: ~/.config/opencode/plugins/otop.ts
 
 package main
@@ -17,6 +18,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,24 +26,27 @@ import (
 // each file is named by PID and contains the active session ID.
 // NOTE: added in ses_34dda6ebdffev5A6J7sPKV6fVt to fix same-cwd correlation bug
 func otopPidDir() string {
-	dataHome := os.Getenv("XDG_DATA_HOME")
-	if dataHome == "" {
-		home, _ := os.UserHomeDir()
-		dataHome = filepath.Join(home, ".local", "share")
-	}
-	return filepath.Join(dataHome, "opencode", "otop")
+	return pidDirFor(dbPath())
+}
+
+// pidDirFor returns the plugin's PID directory next to a database.
+func pidDirFor(db string) string {
+	return filepath.Join(filepath.Dir(db), "otop")
 }
 
 // readSessionFromPidFile reads the session ID written by the otop plugin
-// for a given opencode PID. returns "" if not found.
+// for a given opencode PID, checking the PID directory of every
+// configured database. returns "" if not found.
 func readSessionFromPidFile(pid int) string {
-	data, err := os.ReadFile(filepath.Join(otopPidDir(), strconv.Itoa(pid)))
-	if err != nil {
-		return ""
-	}
-	sid := strings.TrimSpace(string(data))
-	if strings.HasPrefix(sid, "ses_") {
-		return sid
+	for _, src := range allDBSources() {
+		data, err := os.ReadFile(filepath.Join(pidDirFor(src.path), strconv.Itoa(pid)))
+		if err != nil {
+			continue
+		}
+		sid := strings.TrimSpace(string(data))
+		if strings.HasPrefix(sid, "ses_") {
+			return sid
+		}
 	}
 	return ""
 }
@@ -49,23 +54,68 @@ func readSessionFromPidFile(pid int) string {
 type tmuxPaneInfo struct {
 	session string
 	window  string
+	paneID  string // "%12": survives other panes closing, for commands; "" from remotes
+	address string // "session:window.pane", as shown to users; "" from remotes
 }
 
-// batchTmuxSessions maps TTY names (e.g. "ttys005") to tmux session and
-// window names via a single tmux list-panes call.
-func batchTmuxSessions() map[string]tmuxPaneInfo {
+var (
+	tmuxPanesMu    sync.Mutex
+	tmuxPanesCycle = int64(-1) // refresh cycle tmuxPanesCache was listed in
+	tmuxPanesCache map[string]tmuxPaneInfo
+)
+
+// tmuxPanes maps TTY names (e.g. "ttys005") to their tmux panes. the
+// pane list is read with one tmux list-panes call per refresh cycle and
+// shared by the bulk location pass and the sessions command, so a
+// refresh no longer runs it once per row. commands aimed at one pane
+// use tmuxPaneForTTY, which lists fresh: the cycle only advances on
+// list refreshes, and a cached pane may have closed since.
+func tmuxPanes() map[string]tmuxPaneInfo {
+	if !tmuxInstalled() {
+		return nil
+	}
+	tmuxPanesMu.Lock()
+	defer tmuxPanesMu.Unlock()
+	if cycle := snapshotCycle.Load(); cycle != tmuxPanesCycle || tmuxPanesCache == nil {
+		tmuxPanesCache = listTmuxPanes()
+		tmuxPanesCycle = cycle
+	}
+	return tmuxPanesCache
+}
+
+// listTmuxPanes runs tmux list-panes. nil if it fails.
+func listTmuxPanes() map[string]tmuxPaneInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
+	// tab-separated: session and window names may contain spaces
 	out, err := exec.CommandContext(ctx, "tmux", "list-panes", "-a", "-F",
-		"#{pane_tty} #{session_name} #{window_name}").Output()
+		"#{pane_tty}\t#{pane_id}\t#{session_name}\t#{session_name}:#{window_index}.#{pane_index}\t#{window_name}").Output()
 	if err != nil {
 		return nil
 	}
 
 	result := make(map[string]tmuxPaneInfo)
 	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		parts := strings.SplitN(line, " ", 3)
+		parts := strings.SplitN(line, "\t", 5)
+		if len(parts) < 4 {
+			continue
+		}
+		info := tmuxPaneInfo{paneID: parts[1], session: parts[2], address: parts[3]}
+		if len(parts) == 5 {
+			info.window = parts[4]
+		}
+		result[strings.TrimPrefix(parts[0], "/dev/")] = info
+	}
+	return result
+}
+
+// parseTmuxPanes parses list-panes output (tab-separated "tty session
+// window" lines) into a map keyed by TTY name without the /dev/ prefix.
+func parseTmuxPanes(out string) map[string]tmuxPaneInfo {
+	result := make(map[string]tmuxPaneInfo)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
 		if len(parts) < 2 {
 			continue
 		}
@@ -102,38 +152,55 @@ func parseLogTimestamp(logpath string) int64 {
 	return t.UnixMilli()
 }
 
-// lsofInfo holds cwd and log path extracted from a single lsof call.
+// lsofInfo holds cwd, log path, and API port extracted from a single lsof call.
 type lsofInfo struct {
 	cwd     string
 	logpath string
+	apiPort int
+	exe     string // the running binary: lsof's first txt entry
 }
 
-// batchLsof runs a single lsof call for all PIDs.
-// extracts cwd and opencode log file paths. even unlinked log files
-// are visible via lsof (unix keeps the inode alive while the fd is open).
+// batchLsof asks lsof about all PIDs at once: one call for the cwd,
+// binary and open files (cwd, txt and numbered fds, skipping the mapped
+// libraries that make up most of a full listing) and one for listening
+// TCP sockets only. -P -n keep ports and addresses numeric; otherwise
+// lsof prints "localhost:http-alt" and the port can't be parsed. even
+// unlinked log files are visible via lsof (unix keeps the inode alive
+// while the fd is open).
 func batchLsof(pids []int) map[int]lsofInfo {
-	result := make(map[int]lsofInfo)
 	if len(pids) == 0 {
-		return result
-	}
-	for _, p := range pids {
-		result[p] = lsofInfo{cwd: "?"}
+		return make(map[int]lsofInfo)
 	}
 
 	pidStrs := make([]string, len(pids))
 	for i, p := range pids {
 		pidStrs[i] = strconv.Itoa(p)
 	}
+	pidList := strings.Join(pidStrs, ",")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	out, err := exec.CommandContext(ctx, "lsof", "-p", strings.Join(pidStrs, ",")).Output()
-	if err != nil {
-		return result
+	done := timeStep("lsof")
+	files, err := exec.CommandContext(ctx, "lsof", "-P", "-n", "-a", "-p", pidList, "-d", "cwd,txt,0-1023").Output()
+	// lsof exits 1 when a PID has no listening socket; the output is still good
+	ports, _ := exec.CommandContext(ctx, "lsof", "-P", "-n", "-a", "-p", pidList, "-iTCP", "-sTCP:LISTEN").Output()
+	done()
+	if err != nil && len(files) == 0 {
+		return parseLsof("", pids)
 	}
 
-	for _, line := range strings.Split(string(out), "\n") {
+	return parseLsof(string(files)+string(ports), pids)
+}
+
+// parseLsof extracts each PID's cwd, opencode log path and listening
+// port from lsof output. PIDs lsof said nothing about keep cwd "?".
+func parseLsof(out string, pids []int) map[int]lsofInfo {
+	result := make(map[int]lsofInfo, len(pids))
+	for _, p := range pids {
+		result[p] = lsofInfo{cwd: "?"}
+	}
+	for _, line := range strings.Split(out, "\n") {
 		parts := strings.Fields(line)
 		if len(parts) < 9 {
 			continue
@@ -151,9 +218,19 @@ func batchLsof(pids []int) map[int]lsofInfo {
 		if fdCol == "cwd" {
 			info.cwd = path
 		}
+		if fdCol == "txt" && info.exe == "" && path != "(deleted)" {
+			info.exe = path
+		}
 		if strings.Contains(path, ".log") && strings.Contains(path, "opencode/log/") {
 			info.logpath = path
 		}
+		// the local HTTP server: "... TCP 127.0.0.1:4096 (LISTEN)"
+		if path == "(LISTEN)" && info.apiPort == 0 {
+			addr := parts[len(parts)-2]
+			if port, err := strconv.Atoi(addr[strings.LastIndex(addr, ":")+1:]); err == nil {
+				info.apiPort = port
+			}
+		}
 		result[pid] = info
 	}
 
@@ -167,28 +244,71 @@ func getOpencodeProcesses() []processInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	out, err := exec.CommandContext(ctx, "ps", "axo", "pid,pcpu,rss,tty,etime,args").Output()
+	done := timeStep("ps")
+	out, err := exec.CommandContext(ctx, "ps", "axo", psFormat).Output()
+	done()
 	if err != nil {
 		return nil
 	}
+	raw, all := parsePS(string(out))
 
-	type rawProc struct {
-		pid     int
-		cpu     float64
-		rss     int
-		tty     string
-		elapsed string
-		args    string
+	// single batched lsof for all PIDs
+	pids := make([]int, len(raw))
+	for i, r := range raw {
+		pids[i] = r.pid
 	}
+	lsofResults := batchLsof(pids)
 
+	var processes []processInfo
+	opencodePIDs := make(map[int]bool, len(raw))
+	for _, r := range raw {
+		opencodePIDs[r.pid] = true
+	}
+
+	for _, r := range raw {
+		// session ID from otop plugin PID file (sole source of truth)
+		processes = append(processes, newProcessInfo(r, lsofResults[r.pid], readSessionFromPidFile(r.pid),
+			descendants(all, r.pid, opencodePIDs)))
+	}
+
+	// multiplexer session/window lookup (tmux, zellij)
+	locatePanes(processes)
+
+	return processes
+}
+
+// psFormat is the ps -o column list parsePS expects.
+const psFormat = "pid,ppid,pcpu,rss,tty,etime,args"
+
+// rawProc is one opencode process as ps reports it.
+type rawProc struct {
+	pid     int
+	ppid    int
+	cpu     float64
+	rss     int
+	tty     string
+	elapsed string
+	args    string
+}
+
+// parsePS picks the opencode processes out of `ps axo psFormat` output,
+// and indexes every process by parent for child attribution.
+func parsePS(out string) ([]rawProc, map[int][]childProcess) {
 	var raw []rawProc
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	all := make(map[int][]childProcess)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
 	for _, line := range lines[1:] {
 		parts := strings.Fields(line)
-		if len(parts) < 6 {
+		if len(parts) < 7 {
 			continue
 		}
-		args := strings.Join(parts[5:], " ")
+		args := strings.Join(parts[6:], " ")
+		pid, _ := strconv.Atoi(parts[0])
+		ppid, _ := strconv.Atoi(parts[1])
+		cpu, _ := strconv.ParseFloat(parts[2], 64)
+		rss, _ := strconv.Atoi(parts[3])
+		all[ppid] = append(all[ppid], childProcess{pid: pid, cpuPercent: cpu, memMB: float64(rss) / 1024, args: args})
+
 		if !strings.Contains(args, "opencode") {
 			continue
 		}
@@ -200,66 +320,98 @@ func getOpencodeProcesses() []processInfo {
 			continue
 		}
 
-		pid, _ := strconv.Atoi(parts[0])
-		cpu, _ := strconv.ParseFloat(parts[1], 64)
-		rss, _ := strconv.Atoi(parts[2])
-
 		raw = append(raw, rawProc{
 			pid:     pid,
+			ppid:    ppid,
 			cpu:     cpu,
 			rss:     rss,
-			tty:     parts[3],
-			elapsed: parts[4],
+			tty:     parts[4],
+			elapsed: parts[5],
 			args:    args,
 		})
 	}
+	return raw, all
+}
 
-	// single batched lsof for all PIDs
-	pids := make([]int, len(raw))
-	for i, r := range raw {
-		pids[i] = r.pid
+// newProcessInfo builds a process row from its ps and lsof data.
+func newProcessInfo(r rawProc, info lsofInfo, sessionID string, children []childProcess) processInfo {
+	// start time from log filename (UTC), used for uptime display
+	var startMS int64
+	if info.logpath != "" {
+		startMS = parseLogTimestamp(info.logpath)
 	}
-	lsofResults := batchLsof(pids)
 
-	var processes []processInfo
-	for _, r := range raw {
-		info := lsofResults[r.pid]
+	// detect tool processes (opencode run)
+	argParts := strings.Fields(r.args)
+	isTool := len(argParts) > 1 && argParts[1] == "run"
 
-		// session ID from otop plugin PID file (sole source of truth)
-		sessionID := readSessionFromPidFile(r.pid)
+	return processInfo{
+		pid:           r.pid,
+		cpuPercent:    r.cpu,
+		memMB:         float64(r.rss) / 1024,
+		elapsed:       r.elapsed,
+		tty:           r.tty,
+		cwd:           info.cwd,
+		cmdline:       r.args,
+		logPath:       info.logpath,
+		apiPort:       info.apiPort,
+		sessionID:     sessionID,
+		startTimeMS:   startMS,
+		isToolProcess: isTool,
+		children:      children,
+		version:       runningVersion(r.pid, info.exe),
+	}
+}
 
-		// start time from log filename (UTC), used for uptime display
-		var startMS int64
-		if info.logpath != "" {
-			startMS = parseLogTimestamp(info.logpath)
+// descendants lists the processes under pid, depth first, with their
+// depth below it. subtrees rooted at another opencode process are left
+// out since that process has its own row.
+func descendants(byParent map[int][]childProcess, pid int, opencodePIDs map[int]bool) []childProcess {
+	var result []childProcess
+	var walk func(pid, depth int)
+	walk = func(pid, depth int) {
+		if depth > 8 {
+			return // ps raced with a reparent; don't chase a cycle
+		}
+		for _, c := range byParent[pid] {
+			if opencodePIDs[c.pid] {
+				continue
+			}
+			c.depth = depth
+			result = append(result, c)
+			walk(c.pid, depth+1)
 		}
-
-		// detect tool processes (opencode run)
-		argParts := strings.Fields(r.args)
-		isTool := len(argParts) > 1 && argParts[1] == "run"
-
-		processes = append(processes, processInfo{
-			pid:           r.pid,
-			cpuPercent:    r.cpu,
-			memMB:         float64(r.rss) / 1024,
-			elapsed:       r.elapsed,
-			tty:           r.tty,
-			cwd:           info.cwd,
-			cmdline:       r.args,
-			sessionID:     sessionID,
-			startTimeMS:   startMS,
-			isToolProcess: isTool,
-		})
 	}
+	walk(pid, 1)
+	return result
+}
 
-	// batch tmux session lookup
-	tmuxSessions := batchTmuxSessions()
-	for i := range processes {
-		if info, ok := tmuxSessions[processes[i].tty]; ok {
-			processes[i].tmuxSession = info.session
-			processes[i].tmuxWindow = info.window
+// processEnv returns the environment of a process. reads /proc on linux
+// and falls back to `ps eww` (macOS), which appends KEY=value pairs to
+// the command line. values containing spaces are not recovered by the
+// fallback, which is fine for the identifiers we look up.
+func processEnv(pid int) map[string]string {
+	env := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "environ")); err == nil {
+		for _, kv := range strings.Split(string(data), "\x00") {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				env[k] = v
+			}
 		}
+		return env
 	}
 
-	return processes
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "ps", "eww", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return env
+	}
+	for _, field := range strings.Fields(string(out)) {
+		if k, v, ok := strings.Cut(field, "="); ok && k != "" && strings.ToUpper(k) == k {
+			env[k] = v
+		}
+	}
+	return env
 }
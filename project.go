@@ -0,0 +1,156 @@
+// per-project lookup for editor statuslines.
+//
+// given a directory, picks the active session working in it (or in a
+// parent of it) and returns a compact summary: status, round time, last
+// output. `otop project [dir]` asks the running TUI over its unix socket
+// first, so a statusline refresh costs one socket round trip; without a
+// TUI it falls back to collecting directly. also served as GET /project.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// bestSessionForDir returns the session whose directory is dir or the
+// closest parent of it. ties prefer working sessions, then the most
+// recently active. nil if no session matches.
+func bestSessionForDir(correlated []correlatedSession, dir string) *correlatedSession {
+	dir = filepath.Clean(dir)
+	var (
+		best      *correlatedSession
+		bestDepth int
+	)
+	for i := range correlated {
+		cs := &correlated[i]
+		if cs.session == nil || cs.process.isToolProcess || !cs.session.interactive {
+			continue
+		}
+		root := cs.session.directory
+		if root == "" {
+			root = cs.process.cwd
+		}
+		root = filepath.Clean(root)
+		if dir != root && !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			continue
+		}
+		depth := len(root)
+		if best == nil || depth > bestDepth || (depth == bestDepth && preferSession(cs, best)) {
+			best, bestDepth = cs, depth
+		}
+	}
+	return best
+}
+
+// preferSession reports whether a should win over b for the same directory.
+func preferSession(a, b *correlatedSession) bool {
+	aActive := activeStatuses[inferStatus(a.session, a.process.cpuPercent)]
+	bActive := activeStatuses[inferStatus(b.session, b.process.cpuPercent)]
+	if aActive != bActive {
+		return aActive
+	}
+	return a.session.lastMessageTime > b.session.lastMessageTime
+}
+
+// projectEntry is the summary returned for a matched session.
+func projectEntry(cs *correlatedSession) map[string]any {
+	roundMS := int64(0)
+	if cs.session.roundStartTime > 0 {
+		roundMS = time.Now().UnixMilli() - cs.session.roundStartTime
+	}
+	return map[string]any{
+		"session_id":  cs.session.sessionID,
+		"title":       cs.session.title,
+		"directory":   cs.session.directory,
+		"status":      inferStatus(cs.session, cs.process.cpuPercent),
+		"round_ms":    roundMS,
+		"round_human": formatDuration(roundMS),
+		"last_output": cs.session.lastOutput,
+		"model_short": shortModel(cs.session.model),
+		"pid":         cs.process.pid,
+	}
+}
+
+// handleProject serves GET /project?dir=<path>. responds with null when
+// no session matches.
+func handleProject(w http.ResponseWriter, r *http.Request) {
+	dir := r.URL.Query().Get("dir")
+	if dir == "" {
+		http.Error(w, "missing dir", http.StatusBadRequest)
+		return
+	}
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	setFreshnessHeaders(w, snap)
+	var entry map[string]any
+	if cs := bestSessionForDir(snap.correlated, dir); cs != nil {
+		entry = projectEntry(cs)
+	}
+	json.NewEncoder(w).Encode(entry)
+}
+
+// projectCommand prints the best session for dir. format "json" prints
+// the entry (or null); "line" prints "agent: <status> <round>" or nothing.
+func projectCommand(dir, format string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	entry, ok := projectFromSocket(dir)
+	if !ok {
+		_, correlated, _ := correlateAllSessions()
+		if cs := bestSessionForDir(correlated, dir); cs != nil {
+			entry = projectEntry(cs)
+		}
+	}
+
+	if format == "line" {
+		if entry != nil {
+			fmt.Printf("agent: %v %v\n", entry["status"], entry["round_human"])
+		}
+		return
+	}
+	out, _ := json.Marshal(entry)
+	fmt.Println(string(out))
+}
+
+// projectFromSocket asks a running TUI. ok is false if none answered.
+func projectFromSocket(dir string) (map[string]any, bool) {
+	resp, err := socketClient().Get("http://otop/project?dir=" + url.QueryEscape(dir))
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false
+	}
+	var entry map[string]any
+	if json.Unmarshal(body, &entry) != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// projectDirArg returns the first positional argument, or the cwd.
+func projectDirArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
@@ -0,0 +1,141 @@
+// project-level opencode config. besides the global opencode.json,
+// opencode loads opencode.json / opencode.jsonc files from a session's
+// directory and each parent up to the git root, deep-merging them over
+// the global config with the closest file winning. effectiveMCPConfig
+// repeats that for the mcp section so the m panel shows what the
+// selected session actually runs.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+var projectConfigNames = []string{"opencode.jsonc", "opencode.json"}
+
+// effectiveMCPConfig returns the merged mcp section for dir, plus, for
+// each server a project file defines or changes, that file's path.
+// an empty dir yields the global config alone.
+func effectiveMCPConfig(dir string) (servers map[string]any, overrides map[string]string) {
+	servers = make(map[string]any)
+	for name, cfg := range readMCPConfig() {
+		servers[name] = cfg
+	}
+	overrides = make(map[string]string)
+
+	for _, path := range projectConfigFiles(dir) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cfg map[string]any
+		if json.Unmarshal(stripJSONComments(data), &cfg) != nil {
+			continue
+		}
+		mcp, _ := cfg["mcp"].(map[string]any)
+		for name, def := range mcp {
+			servers[name] = mergeJSON(servers[name], def)
+			overrides[name] = path
+		}
+	}
+	return servers, overrides
+}
+
+// projectConfigFiles lists the project config files that apply to dir,
+// outermost first so later ones override earlier ones. the search stops
+// at the directory holding .git, or at the filesystem root.
+func projectConfigFiles(dir string) []string {
+	if dir == "" || dir == "?" {
+		return nil
+	}
+	var found []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		// within one directory opencode.json is read after opencode.jsonc
+		for i := len(projectConfigNames) - 1; i >= 0; i-- {
+			path := filepath.Join(d, projectConfigNames[i])
+			if path == configPath() {
+				continue // the global file, already read
+			}
+			if _, err := os.Stat(path); err == nil {
+				found = append(found, path)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil || filepath.Dir(d) == d {
+			break
+		}
+	}
+	// collected innermost first; reverse so the closest file applies last
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	return found
+}
+
+// mergeJSON deep-merges over onto base: objects merge key by key, any
+// other value in over replaces base.
+func mergeJSON(base, over any) any {
+	b, okB := base.(map[string]any)
+	o, okO := over.(map[string]any)
+	if !okB || !okO {
+		return over
+	}
+	merged := make(map[string]any, len(b)+len(o))
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range o {
+		merged[k] = mergeJSON(merged[k], v)
+	}
+	return merged
+}
+
+// stripJSONComments blanks // and /* */ comments outside strings and
+// drops trailing commas before } or ], turning JSONC into JSON.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// drop a trailing comma (and the whitespace after it)
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+// prompt box (:) for sending a message to a session.
+//
+// typing : in the list or detail view opens a one-line prompt aimed at
+// the selected session. enter sends it through opencode's API when the
+// process has a listening port, else types it into the session's tmux
+// pane with send-keys. the box closes on send, so several sessions can
+// be given follow-ups in a row.
+//
+// R and N reuse the box to edit the session's alias and note (notes.go).
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptSentMsg reports how a prompt was delivered ("api" or "tmux").
+type promptSentMsg struct {
+	sessionID string
+	title     string
+	via       string
+	err       error
+}
+
+// deliverPrompt sends text to the session, preferring the API.
+// remote processes have neither: their API listens on the remote's
+// loopback and their tty names a pane on the other machine.
+func deliverPrompt(proc processInfo, sessionID, text string) (string, error) {
+	if proc.host != "" {
+		return "", fmt.Errorf("can't reach sessions on %s", proc.host)
+	}
+	if proc.apiPort != 0 {
+		return "api", sendPrompt(proc, sessionID, text)
+	}
+	if err := tmuxSendKeys(proc.tty, text); err != nil {
+		return "", err
+	}
+	return "tmux", nil
+}
+
+// tmuxSendKeys types text literally into the pane on tty, then Enter.
+// "--" keeps text starting with "-" from being read as a flag.
+func tmuxSendKeys(tty, text string) error {
+	target := tmuxPaneForTTY(tty)
+	if target == "" {
+		return errors.New("no opencode API and no tmux pane")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := exec.CommandContext(ctx, "tmux", "send-keys", "-t", target, "-l", "--", text).Run(); err != nil {
+		return err
+	}
+	return exec.CommandContext(ctx, "tmux", "send-keys", "-t", target, "Enter").Run()
+}
+
+// openPrompt aims the prompt box at cs.
+func (m model) openPrompt(cs correlatedSession) model {
+	if cs.session == nil {
+		return m
+	}
+	m.promptActive = true
+	m.promptKind = ""
+	m.promptText = ""
+	m.promptTarget = cs
+	return m
+}
+
+// openNoteEditor aims the prompt box at cs's alias or note (kind "alias"
+// or "note"), prefilled with the current value.
+func (m model) openNoteEditor(cs correlatedSession, kind string) model {
+	if cs.session == nil {
+		return m
+	}
+	m = m.openPrompt(cs)
+	m.promptKind = kind
+	n := noteFor(cs.session.sessionID)
+	if kind == "alias" {
+		m.promptText = n.Alias
+	} else {
+		m.promptText = n.Note
+	}
+	return m
+}
+
+// saveNoteEdit stores the prompt text as the target's alias or note.
+// an empty text clears it.
+func (m model) saveNoteEdit() model {
+	sid := m.promptTarget.session.sessionID
+	n := noteFor(sid)
+	if m.promptKind == "alias" {
+		n.Alias = m.promptText
+	} else {
+		n.Note = m.promptText
+	}
+	if err := setNote(sid, n); err != nil {
+		m.flashMsg = m.promptKind + ": " + err.Error()
+	} else if m.promptText == "" {
+		m.flashMsg = m.promptKind + " cleared"
+	} else {
+		m.flashMsg = m.promptKind + " saved"
+	}
+	m.flashTime = time.Now()
+	return m
+}
+
+func (m model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.promptActive = false
+	case tea.KeyEnter:
+		m.promptActive = false
+		if m.promptKind != "" {
+			return m.saveNoteEdit(), nil
+		}
+		if m.promptText == "" {
+			return m, nil
+		}
+		proc, session, text := m.promptTarget.process, m.promptTarget.session, m.promptText
+		return m, func() tea.Msg {
+			via, err := deliverPrompt(proc, session.sessionID, text)
+			return promptSentMsg{sessionID: session.sessionID, title: sessionTitle(session), via: via, err: err}
+		}
+	case tea.KeyBackspace:
+		if r := []rune(m.promptText); len(r) > 0 {
+			m.promptText = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.promptText += " "
+	case tea.KeyRunes:
+		m.promptText += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// renderPromptBar replaces the footer while the prompt box is open.
+func (m model) renderPromptBar() string {
+	title := sessionTitle(m.promptTarget.session)
+	if r := []rune(title); len(r) > 24 {
+		title = string(r[:24])
+	}
+	sep := " : "
+	if m.promptKind != "" {
+		sep = " " + m.promptKind + ": "
+	}
+	return headerStyle.Width(m.width).Render(" → " + title + sep + m.promptText + "█")
+}
@@ -0,0 +1,418 @@
+// record/replay of fetch results, for reproducing rendering and
+// correlation bugs from someone else's machine.
+//
+// `otop --record trace.jsonl` appends every fetch as one JSON line
+// (offset from the start of recording plus the full result).
+// `otop --replay trace.jsonl` runs the TUI off such a trace instead of
+// collecting, delivering frames with their original spacing and then
+// holding the last one. pane captures still hit the local machine.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recordedFrame is one line of a trace.
+type recordedFrame struct {
+	OffsetMS  int64             `json:"offset_ms"`
+	ElapsedMS int64             `json:"elapsed_ms"`
+	Sessions  []recordedSession `json:"sessions"`
+	Today     recordedStats     `json:"today"`
+	Global    recordedStats     `json:"global"`
+	MCP       map[string]any    `json:"mcp,omitempty"`
+	DBError   string            `json:"db_error,omitempty"`
+}
+
+// recordedSession mirrors correlatedSession with exported fields.
+type recordedSession struct {
+	Process recordedProcess `json:"process"`
+	Session *recordedInfo   `json:"session,omitempty"`
+}
+
+type recordedProcess struct {
+	PID           int                 `json:"pid"`
+	CPUPercent    float64             `json:"cpu_percent"`
+	MemMB         float64             `json:"mem_mb"`
+	Elapsed       string              `json:"elapsed"`
+	TTY           string              `json:"tty"`
+	TmuxSession   string              `json:"tmux_session"`
+	TmuxWindow    string              `json:"tmux_window"`
+	Container     string              `json:"container,omitempty"`
+	Host          string              `json:"host,omitempty"`
+	Cwd           string              `json:"cwd"`
+	Cmdline       string              `json:"cmdline"`
+	LogPath       string              `json:"log_path"`
+	APIPort       int                 `json:"api_port"`
+	SessionID     string              `json:"session_id"`
+	StartTimeMS   int64               `json:"start_time_ms"`
+	IsToolProcess bool                `json:"is_tool_process"`
+	Children      []recordedChild     `json:"children,omitempty"`
+	Version       string              `json:"version,omitempty"`
+	Candidates    []recordedCandidate `json:"candidates,omitempty"`
+	Guessed       bool                `json:"guessed,omitempty"`
+	MatchScore    float64             `json:"match_score,omitempty"`
+}
+
+type recordedCandidate struct {
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Updated int64   `json:"updated"`
+	Score   float64 `json:"score,omitempty"`
+}
+
+type recordedChild struct {
+	PID        int     `json:"pid"`
+	Depth      int     `json:"depth"`
+	CPUPercent float64 `json:"cpu_percent"`
+	MemMB      float64 `json:"mem_mb"`
+	Args       string  `json:"args"`
+}
+
+type recordedInfo struct {
+	SessionID         string         `json:"session_id"`
+	Source            string         `json:"source"`
+	Title             string         `json:"title"`
+	Directory         string         `json:"directory"`
+	ProjectID         string         `json:"project_id"`
+	Model             string         `json:"model"`
+	Provider          string         `json:"provider"`
+	Agent             string         `json:"agent"`
+	MessageCount      int            `json:"message_count"`
+	TotalInputTokens  int64          `json:"total_input_tokens"`
+	TotalOutputTokens int64          `json:"total_output_tokens"`
+	TotalCacheRead    int64          `json:"total_cache_read"`
+	TotalCacheWrite   int64          `json:"total_cache_write"`
+	TotalCost         float64        `json:"total_cost"`
+	LastFinish        *string        `json:"last_finish"`
+	LastMessageRole   string         `json:"last_message_role"`
+	LastMessageTime   int64          `json:"last_message_time"`
+	LastError         string         `json:"last_error"`
+	LastPartTime      int64          `json:"last_part_time"`
+	TimeCreated       int64          `json:"time_created"`
+	TimeUpdated       int64          `json:"time_updated"`
+	RoundStartTime    int64          `json:"round_start_time"`
+	LastOutput        string         `json:"last_output"`
+	LastOutputFull    string         `json:"last_output_full"`
+	ActiveTodos       []recordedTodo `json:"active_todos,omitempty"`
+	Version           string         `json:"version"`
+	Interactive       bool           `json:"interactive"`
+	PendingTool       string         `json:"pending_tool"`
+	PaneIdle          bool           `json:"pane_idle"`
+	AvgLatencyMS      int64          `json:"avg_latency_ms"`
+	TokensPerSec      float64        `json:"tokens_per_sec"`
+	ThroughputRounds  int            `json:"throughput_rounds"`
+	Compactions       int            `json:"compactions,omitempty"`
+	LastCompaction    int64          `json:"last_compaction,omitempty"`
+	RoundForecast     float64        `json:"round_forecast,omitempty"`
+	LastUserInput     int64          `json:"last_user_input,omitempty"`
+	RoundCount        int            `json:"round_count,omitempty"`
+	ToolCalls         int            `json:"tool_calls,omitempty"`
+	ToolUsage         []recordedTool `json:"tool_usage,omitempty"`
+}
+
+type recordedTool struct {
+	Tool  string `json:"tool"`
+	Count int    `json:"count"`
+}
+
+type recordedTodo struct {
+	Content  string `json:"content"`
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+}
+
+type recordedStats struct {
+	SessionCount int   `json:"session_count"`
+	MessageCount int   `json:"message_count"`
+	TotalInput   int64 `json:"total_input"`
+	TotalOutput  int64 `json:"total_output"`
+}
+
+func recordStats(s aggStats) recordedStats {
+	return recordedStats{
+		SessionCount: s.sessionCount,
+		MessageCount: s.messageCount,
+		TotalInput:   s.totalInput,
+		TotalOutput:  s.totalOutput,
+	}
+}
+
+func (s recordedStats) agg() aggStats {
+	return aggStats{
+		sessionCount: s.SessionCount,
+		messageCount: s.MessageCount,
+		totalInput:   s.TotalInput,
+		totalOutput:  s.TotalOutput,
+	}
+}
+
+// frameFromResult converts a fetch for writing.
+func frameFromResult(result fetchResult, offset time.Duration) recordedFrame {
+	frame := recordedFrame{
+		OffsetMS:  offset.Milliseconds(),
+		ElapsedMS: result.elapsed.Milliseconds(),
+		Today:     recordStats(result.todayStats),
+		Global:    recordStats(result.globalStats),
+		MCP:       result.mcpConfig,
+	}
+	if result.dbErr != nil {
+		frame.DBError = result.dbErr.Error()
+	}
+	for _, cs := range result.correlated {
+		p := cs.process
+		rs := recordedSession{Process: recordedProcess{
+			PID:           p.pid,
+			CPUPercent:    p.cpuPercent,
+			MemMB:         p.memMB,
+			Elapsed:       p.elapsed,
+			TTY:           p.tty,
+			TmuxSession:   p.tmuxSession,
+			TmuxWindow:    p.tmuxWindow,
+			Container:     p.container,
+			Host:          p.host,
+			Cwd:           p.cwd,
+			Cmdline:       p.cmdline,
+			LogPath:       p.logPath,
+			APIPort:       p.apiPort,
+			SessionID:     p.sessionID,
+			StartTimeMS:   p.startTimeMS,
+			IsToolProcess: p.isToolProcess,
+			Version:       p.version,
+			Guessed:       p.guessed,
+			MatchScore:    p.matchScore,
+		}}
+		for _, c := range p.candidates {
+			rs.Process.Candidates = append(rs.Process.Candidates, recordedCandidate{ID: c.id, Title: c.title, Updated: c.updated, Score: c.score})
+		}
+		for _, c := range p.children {
+			rs.Process.Children = append(rs.Process.Children, recordedChild{
+				PID:        c.pid,
+				Depth:      c.depth,
+				CPUPercent: c.cpuPercent,
+				MemMB:      c.memMB,
+				Args:       c.args,
+			})
+		}
+		if s := cs.session; s != nil {
+			info := &recordedInfo{
+				SessionID:         s.sessionID,
+				Source:            s.source,
+				Title:             s.title,
+				Directory:         s.directory,
+				ProjectID:         s.projectID,
+				Model:             s.model,
+				Provider:          s.provider,
+				Agent:             s.agent,
+				MessageCount:      s.messageCount,
+				TotalInputTokens:  s.totalInputTokens,
+				TotalOutputTokens: s.totalOutputTokens,
+				TotalCacheRead:    s.totalCacheRead,
+				TotalCacheWrite:   s.totalCacheWrite,
+				TotalCost:         s.totalCost,
+				LastFinish:        s.lastFinish,
+				LastMessageRole:   s.lastMessageRole,
+				LastMessageTime:   s.lastMessageTime,
+				LastError:         s.lastError,
+				LastPartTime:      s.lastPartTime,
+				TimeCreated:       s.timeCreated,
+				TimeUpdated:       s.timeUpdated,
+				RoundStartTime:    s.roundStartTime,
+				LastOutput:        s.lastOutput,
+				LastOutputFull:    s.lastOutputFull,
+				Version:           s.version,
+				Interactive:       s.interactive,
+				PendingTool:       s.pendingTool,
+				PaneIdle:          s.paneIdle,
+				AvgLatencyMS:      s.avgLatencyMS,
+				TokensPerSec:      s.tokensPerSec,
+				ThroughputRounds:  s.throughputRounds,
+				Compactions:       s.compactions,
+				LastCompaction:    s.lastCompaction,
+				RoundForecast:     s.roundForecast,
+				LastUserInput:     s.lastUserInput,
+				RoundCount:        s.roundCount,
+				ToolCalls:         s.toolCalls,
+			}
+			for _, tc := range s.toolUsage {
+				info.ToolUsage = append(info.ToolUsage, recordedTool{Tool: tc.tool, Count: tc.count})
+			}
+			for _, t := range s.activeTodos {
+				info.ActiveTodos = append(info.ActiveTodos, recordedTodo{Content: t.content, Status: t.status, Priority: t.priority})
+			}
+			rs.Session = info
+		}
+		frame.Sessions = append(frame.Sessions, rs)
+	}
+	return frame
+}
+
+// result converts a frame back into what a fetch would have returned.
+func (f recordedFrame) result() fetchResult {
+	result := fetchResult{
+		todayStats:  f.Today.agg(),
+		globalStats: f.Global.agg(),
+		mcpConfig:   f.MCP,
+		elapsed:     time.Duration(f.ElapsedMS) * time.Millisecond,
+	}
+	if f.DBError != "" {
+		result.dbErr = errors.New(f.DBError)
+	}
+	for _, rs := range f.Sessions {
+		p := rs.Process
+		cs := correlatedSession{process: processInfo{
+			pid:           p.PID,
+			cpuPercent:    p.CPUPercent,
+			memMB:         p.MemMB,
+			elapsed:       p.Elapsed,
+			tty:           p.TTY,
+			tmuxSession:   p.TmuxSession,
+			tmuxWindow:    p.TmuxWindow,
+			container:     p.Container,
+			host:          p.Host,
+			cwd:           p.Cwd,
+			cmdline:       p.Cmdline,
+			logPath:       p.LogPath,
+			apiPort:       p.APIPort,
+			sessionID:     p.SessionID,
+			startTimeMS:   p.StartTimeMS,
+			isToolProcess: p.IsToolProcess,
+			version:       p.Version,
+			guessed:       p.Guessed,
+			matchScore:    p.MatchScore,
+		}}
+		for _, c := range p.Candidates {
+			cs.process.candidates = append(cs.process.candidates, sessionCandidate{id: c.ID, title: c.Title, updated: c.Updated, score: c.Score})
+		}
+		for _, c := range p.Children {
+			cs.process.children = append(cs.process.children, childProcess{
+				pid:        c.PID,
+				depth:      c.Depth,
+				cpuPercent: c.CPUPercent,
+				memMB:      c.MemMB,
+				args:       c.Args,
+			})
+		}
+		if s := rs.Session; s != nil {
+			info := &sessionInfo{
+				sessionID:         s.SessionID,
+				source:            s.Source,
+				title:             s.Title,
+				directory:         s.Directory,
+				projectID:         s.ProjectID,
+				model:             s.Model,
+				provider:          s.Provider,
+				agent:             s.Agent,
+				messageCount:      s.MessageCount,
+				totalInputTokens:  s.TotalInputTokens,
+				totalOutputTokens: s.TotalOutputTokens,
+				totalCacheRead:    s.TotalCacheRead,
+				totalCacheWrite:   s.TotalCacheWrite,
+				totalCost:         s.TotalCost,
+				lastFinish:        s.LastFinish,
+				lastMessageRole:   s.LastMessageRole,
+				lastMessageTime:   s.LastMessageTime,
+				lastError:         s.LastError,
+				lastPartTime:      s.LastPartTime,
+				timeCreated:       s.TimeCreated,
+				timeUpdated:       s.TimeUpdated,
+				roundStartTime:    s.RoundStartTime,
+				lastOutput:        s.LastOutput,
+				lastOutputFull:    s.LastOutputFull,
+				version:           s.Version,
+				interactive:       s.Interactive,
+				pendingTool:       s.PendingTool,
+				paneIdle:          s.PaneIdle,
+				avgLatencyMS:      s.AvgLatencyMS,
+				tokensPerSec:      s.TokensPerSec,
+				throughputRounds:  s.ThroughputRounds,
+				compactions:       s.Compactions,
+				lastCompaction:    s.LastCompaction,
+				roundForecast:     s.RoundForecast,
+				lastUserInput:     s.LastUserInput,
+				roundCount:        s.RoundCount,
+				toolCalls:         s.ToolCalls,
+			}
+			for _, tc := range s.ToolUsage {
+				info.toolUsage = append(info.toolUsage, toolCount{tool: tc.Tool, count: tc.Count})
+			}
+			for _, t := range s.ActiveTodos {
+				info.activeTodos = append(info.activeTodos, todoItem{content: t.Content, status: t.Status, priority: t.Priority})
+			}
+			cs.session = info
+		}
+		result.correlated = append(result.correlated, cs)
+	}
+	return result
+}
+
+// startRecording subscribes a trace writer to snapshot events. returns
+// a function that stops recording and closes the file.
+func startRecording(path string) (stop func(), err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	start := time.Now()
+	unsubscribe := bus.subscribe(func(ev event) {
+		if snap, ok := ev.(snapshotEvent); ok {
+			_ = enc.Encode(frameFromResult(snap.result, time.Since(start)))
+		}
+	})
+	return func() {
+		unsubscribe()
+		f.Close()
+	}, nil
+}
+
+// loadTrace reads a recorded trace.
+func loadTrace(path string) ([]recordedFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var frames []recordedFrame
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var frame recordedFrame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		frames = append(frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("%s: empty trace", path)
+	}
+	return frames, nil
+}
+
+// replayMsg delivers frame idx of the replay trace.
+type replayMsg int
+
+// replayCmd schedules frame idx after its original gap from the previous one.
+func (m model) replayCmd(idx int) tea.Cmd {
+	if idx >= len(m.replay) {
+		return nil
+	}
+	var gap time.Duration
+	if idx > 0 {
+		gap = time.Duration(m.replay[idx].OffsetMS-m.replay[idx-1].OffsetMS) * time.Millisecond
+	}
+	return tea.Tick(max(gap, time.Millisecond), func(time.Time) tea.Msg {
+		return replayMsg(idx)
+	})
+}
@@ -0,0 +1,232 @@
+// ssh remotes (config "remotes"): opencode running on another machine,
+// collected without installing otop there. each fetch runs one ssh per
+// host with a small sh script that prints the same ps, lsof and tmux
+// output the local collection reads, plus the otop plugin's PID files,
+// and the rows it yields carry the host name.
+//
+// session data comes from a copy of the remote database mirrored into
+// the state dir at most every remoteDBRefresh (a sqlite3 backup taken on
+// the host, streamed over ssh), registered as an extra database source
+// labeled with the host. sessions show without details until the first
+// copy lands.
+//
+// hosts are ssh aliases, so keys, ports and jump hosts come from
+// ~/.ssh/config. ssh runs in batch mode: a host that wants a password
+// just contributes nothing.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRemoteDB = "~/.local/share/opencode/opencode.db"
+	remoteDBRefresh = 30 * time.Second
+)
+
+// remoteConfig is one ssh host from the config file.
+type remoteConfig struct {
+	host string // ssh alias or user@host
+	db   string // opencode.db path on the host; ~ is the remote home
+}
+
+var sshOptions = []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5"}
+
+// remoteMirrorPath is where a host's database is mirrored locally.
+func remoteMirrorPath(host string) string {
+	return filepath.Join(otopStateDir(), "remotes", host, "opencode.db")
+}
+
+// remoteProcesses collects the opencode processes of every configured
+// remote, in parallel. unreachable hosts are skipped.
+func remoteProcesses() []processInfo {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		found []processInfo
+	)
+	for _, r := range display.remotes {
+		wg.Add(1)
+		go func(r remoteConfig) {
+			defer wg.Done()
+			mirrorRemoteDB(r)
+			done := timeStep("ssh: " + r.host)
+			procs := collectRemote(r)
+			done()
+			mu.Lock()
+			found = append(found, procs...)
+			mu.Unlock()
+		}(r)
+	}
+	wg.Wait()
+	return found
+}
+
+// collectRemote runs the collection script on one host and builds its
+// process rows.
+func collectRemote(r remoteConfig) []processInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "ssh", append(sshOptions, r.host, "sh", "-s")...)
+	cmd.Stdin = strings.NewReader(remoteScript(r.db))
+	out, err := cmd.Output()
+	if err != nil && len(out) == 0 {
+		return nil
+	}
+	sections := splitRemoteSections(string(out))
+
+	raw, all := parsePS(sections["ps"])
+	pids := make([]int, len(raw))
+	opencodePIDs := make(map[int]bool, len(raw))
+	for i, p := range raw {
+		pids[i] = p.pid
+		opencodePIDs[p.pid] = true
+	}
+	lsof := parseLsof(sections["lsof"], pids)
+	panes := parseTmuxPanes(sections["tmux"])
+
+	sessionIDs := make(map[int]string)
+	for _, line := range strings.Split(sections["pids"], "\n") {
+		pid, sid, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if n, err := strconv.Atoi(pid); ok && err == nil && strings.HasPrefix(sid, "ses_") {
+			sessionIDs[n] = sid
+		}
+	}
+
+	procs := make([]processInfo, 0, len(raw))
+	for _, p := range raw {
+		proc := newProcessInfo(p, lsof[p.pid], sessionIDs[p.pid], descendants(all, p.pid, opencodePIDs))
+		proc.host = r.host
+		proc.apiPort = 0 // listening on the remote's loopback, out of reach
+		if pane, ok := panes[p.tty]; ok {
+			proc.tmuxSession = pane.session
+			proc.tmuxWindow = pane.window
+		}
+		procs = append(procs, proc)
+	}
+	return procs
+}
+
+// remoteScript is the sh script run on a host. each part of the output
+// starts with an "@@name" line; lsof is only asked about PIDs whose
+// binary is opencode, like the local filter.
+func remoteScript(db string) string {
+	return "db=" + remoteShellPath(db) + `
+echo @@ps
+ps axo ` + psFormat + `
+echo @@pids
+for f in "$(dirname "$db")"/otop/*; do [ -f "$f" ] && echo "${f##*/} $(cat "$f")"; done
+echo @@lsof
+pids=$(ps axo pid=,args= | awk '{n=$2; sub(".*/", "", n)} n == "opencode" {print $1}' | paste -sd, -)
+[ -n "$pids" ] && lsof -P -n -p "$pids" 2>/dev/null
+echo @@tmux
+t=$(printf '\t')
+tmux list-panes -a -F "#{pane_tty}$t#{session_name}$t#{window_name}" 2>/dev/null
+true
+`
+}
+
+// remoteShellPath quotes a path for the remote shell, leaving a leading
+// ~/ to expand to the remote home.
+func remoteShellPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(path)
+}
+
+// splitRemoteSections splits the script's output by its "@@name" lines.
+func splitRemoteSections(out string) map[string]string {
+	sections := make(map[string]string)
+	var name string
+	var b strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if next, ok := strings.CutPrefix(line, "@@"); ok {
+			sections[name] = b.String()
+			name = next
+			b.Reset()
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	sections[name] = b.String()
+	return sections
+}
+
+var (
+	remoteMirrorMu   sync.Mutex
+	remoteMirrorBusy = make(map[string]bool)
+	remoteMirrorAt   = make(map[string]time.Time)
+)
+
+// mirrorRemoteDB starts a background copy of a host's database if the
+// last one is older than remoteDBRefresh and none is running.
+func mirrorRemoteDB(r remoteConfig) {
+	remoteMirrorMu.Lock()
+	defer remoteMirrorMu.Unlock()
+	if remoteMirrorBusy[r.host] || time.Since(remoteMirrorAt[r.host]) < remoteDBRefresh {
+		return
+	}
+	remoteMirrorBusy[r.host] = true
+	go func() {
+		copyRemoteDB(r)
+		remoteMirrorMu.Lock()
+		remoteMirrorBusy[r.host] = false
+		remoteMirrorAt[r.host] = time.Now()
+		remoteMirrorMu.Unlock()
+	}()
+}
+
+// copyRemoteDB copies the host's database into the mirror directory.
+// the database and its WAL can't be copied separately without risking a
+// mismatched pair, so sqlite3 on the host takes a consistent backup into
+// a temp file first and that one file comes over. it replaces the mirror
+// whole, through a temp file, so a reader never opens half a copy.
+func copyRemoteDB(r remoteConfig) {
+	dest := remoteMirrorPath(r.host)
+	if os.MkdirAll(filepath.Dir(dest), 0o755) != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	tmp := dest + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(sshOptions, "-C", r.host, "sh", "-s")...)
+	cmd.Stdin = strings.NewReader(remoteBackupScript(r.db))
+	cmd.Stdout = f
+	err = cmd.Run()
+	f.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return
+	}
+	os.Remove(dest + "-wal") // left by mirrors copied file by file
+	os.Rename(tmp, dest)
+}
+
+// remoteBackupScript backs up db with sqlite3 and writes the copy to
+// stdout. a missing sqlite3 fails the script, leaving the last mirror.
+func remoteBackupScript(db string) string {
+	return "db=" + remoteShellPath(db) + `
+tmp=$(mktemp "${TMPDIR:-/tmp}/otop.XXXXXX") || exit 1
+trap 'rm -f "$tmp"' EXIT
+sqlite3 "$db" ".backup '$tmp'" >&2 && cat "$tmp"
+`
+}
@@ -0,0 +1,107 @@
+// weekly retrospective: `otop retro` prints a one-screen summary of how
+// the agent fleet performed over the past N days (default 7).
+//
+// sections: top sessions by cost, by duration, by error count, projects
+// ranked by activity, and day-by-day totals. everything is computed from
+// message rows created inside the window, not session timestamps, so a
+// long-lived session only counts for the work it did this week.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const retroTopN = 5
+
+// retroCommand prints the retrospective report to stdout.
+func retroCommand(days int) {
+	since := time.Now().AddDate(0, 0, -days)
+	sinceMS := since.UnixMilli()
+
+	sessions, err := queryRetroSessions(sinceMS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	projects, _ := queryRetroProjects(sinceMS)
+	dayTotals, _ := queryRetroDays(sinceMS)
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf(" otop retro · last %d days (since %s)", days, since.Format("Mon Jan 2"))))
+	fmt.Println()
+
+	retroPrintSessions("TOP BY COST", sessions, func(a, b retroSession) int {
+		return cmp.Compare(b.cost, a.cost)
+	}, func(s retroSession) string {
+		return formatCost(s.cost)
+	})
+	retroPrintSessions("TOP BY DURATION", sessions, func(a, b retroSession) int {
+		return cmp.Compare(b.durationMS, a.durationMS)
+	}, func(s retroSession) string {
+		return formatDuration(s.durationMS)
+	})
+
+	var errored []retroSession
+	for _, s := range sessions {
+		if s.errors > 0 {
+			errored = append(errored, s)
+		}
+	}
+	retroPrintSessions("TOP BY ERRORS", errored, func(a, b retroSession) int {
+		return cmp.Compare(b.errors, a.errors)
+	}, func(s retroSession) string {
+		return fmt.Sprintf("%d err", s.errors)
+	})
+
+	fmt.Println(panelStyle.Render(" PROJECTS"))
+	if len(projects) == 0 {
+		fmt.Println(dimStyle.Render("  (no activity)"))
+	}
+	for _, p := range projects[:min(len(projects), retroTopN*2)] {
+		fmt.Printf("  %-40s %s\n",
+			truncOrPad(shortPath(p.directory, 40), 40),
+			dimStyle.Render(fmt.Sprintf("%3d sessions  %5d msgs  %8s", p.sessions, p.messages, formatCost(p.cost))))
+	}
+	fmt.Println()
+
+	fmt.Println(panelStyle.Render(" DAILY"))
+	var total retroDay
+	for _, d := range dayTotals {
+		fmt.Printf("  %s  %3d sessions  %5d msgs  ctx:%-7s out:%-7s %8s\n",
+			d.day, d.sessions, d.messages,
+			formatTokens(d.tokensIn), formatTokens(d.tokensOut), formatCost(d.cost))
+		total.messages += d.messages
+		total.tokensIn += d.tokensIn
+		total.tokensOut += d.tokensOut
+		total.cost += d.cost
+	}
+	fmt.Println(dimStyle.Render(strings.Repeat("─", 72)))
+	fmt.Printf("  %-10s  %3d sessions  %5d msgs  ctx:%-7s out:%-7s %8s\n",
+		"total", len(sessions), total.messages,
+		formatTokens(total.tokensIn), formatTokens(total.tokensOut), formatCost(total.cost))
+}
+
+// retroPrintSessions prints the top sessions of a ranking as one section.
+func retroPrintSessions(label string, sessions []retroSession, rank func(a, b retroSession) int, value func(retroSession) string) {
+	fmt.Println(panelStyle.Render(" " + label))
+	if len(sessions) == 0 {
+		fmt.Println(dimStyle.Render("  (none)"))
+		fmt.Println()
+		return
+	}
+
+	sorted := slices.Clone(sessions)
+	slices.SortStableFunc(sorted, rank)
+	for _, s := range sorted[:min(len(sorted), retroTopN)] {
+		fmt.Printf("  %-9s %s %s\n",
+			value(s),
+			truncOrPad(s.title, 44),
+			dimStyle.Render(shortPath(s.directory, 30)))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,169 @@
+// round history for the detail view (R). a round runs from a user
+// message to the last assistant message before the next one; the tab
+// lists every round of the session with how long it took, the models
+// that answered, tool calls, tokens and cost, so slow turns stand out
+// in a way the single ROUND column (time since the last prompt) can't.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// roundSummary is one user→assistant round.
+type roundSummary struct {
+	start     int64 // user message created
+	end       int64 // newest assistant completion, 0 while running
+	running   bool  // an assistant message hasn't completed yet
+	models    []string
+	toolCalls int
+	tokensIn  int64 // input plus cache reads, summed over replies
+	tokensOut int64
+	cost      float64
+}
+
+// roundRow is one message as the rounds query returns it.
+type roundRow struct {
+	role      string
+	created   int64
+	completed int64
+	model     string
+	tokensIn  int64
+	tokensOut int64
+	cost      float64
+	toolCalls int
+}
+
+// querySessionRounds reads a session's rounds from the first database
+// that has its messages.
+func querySessionRounds(sessionID string) ([]roundSummary, error) {
+	var errs []error
+	for _, src := range allDBSources() {
+		rows, err := querySessionRoundsFrom(src.path, sessionID)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(rows) > 0 {
+			return buildRounds(rows), nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// querySessionRoundsFrom reads every message of a session with its tool
+// call count, oldest first.
+func querySessionRoundsFrom(path, sessionID string) ([]roundRow, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			json_extract(m.data, '$.role'),
+			m.time_created,
+			coalesce(json_extract(m.data, '$.time.completed'), 0),
+			json_extract(m.data, '$.modelID'),
+			coalesce(json_extract(m.data, '$.tokens.input'), 0)
+			   + coalesce(json_extract(m.data, '$.tokens.cache.read'), 0),
+			coalesce(json_extract(m.data, '$.tokens.output'), 0),
+			coalesce(json_extract(m.data, '$.cost'), 0),
+			count(p.id)
+		FROM message m
+		LEFT JOIN part p ON p.message_id = m.id AND json_extract(p.data, '$.type') = 'tool'
+		WHERE m.session_id = ?
+		GROUP BY m.id
+		ORDER BY m.time_created
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("rounds: %w", err)
+	}
+	defer rows.Close()
+
+	var result []roundRow
+	for rows.Next() {
+		var r roundRow
+		var role, model sql.NullString
+		if rows.Scan(&role, &r.created, &r.completed, &model, &r.tokensIn, &r.tokensOut, &r.cost, &r.toolCalls) == nil {
+			r.role, r.model = role.String, model.String
+			result = append(result, r)
+		}
+	}
+	return result, rows.Err()
+}
+
+// buildRounds groups messages (oldest first) into rounds. assistant
+// messages before the first user message start a round of their own.
+func buildRounds(rows []roundRow) []roundSummary {
+	var rounds []roundSummary
+	for _, r := range rows {
+		if r.role == "user" || len(rounds) == 0 {
+			rounds = append(rounds, roundSummary{start: r.created})
+		}
+		if r.role != "assistant" {
+			continue
+		}
+		cur := &rounds[len(rounds)-1]
+		if r.model != "" && !slices.Contains(cur.models, r.model) {
+			cur.models = append(cur.models, r.model)
+		}
+		cur.toolCalls += r.toolCalls
+		cur.tokensIn += r.tokensIn
+		cur.tokensOut += r.tokensOut
+		cur.cost += r.cost
+		cur.running = r.completed == 0
+		cur.end = max(cur.end, r.completed)
+	}
+	return rounds
+}
+
+// formatRounds renders the rounds tab, oldest first like the db view.
+func formatRounds(rounds []roundSummary) []string {
+	if len(rounds) == 0 {
+		return []string{"  (no rounds)"}
+	}
+	lines := []string{dimStyle.Render(fmt.Sprintf(" %4s  %-8s  %-9s  %5s  %7s  %7s  %7s  %s",
+		"#", "START", "DURATION", "TOOLS", "CTX", "OUT", "COST", "MODELS"))}
+	nowMS := time.Now().UnixMilli()
+	for i, r := range rounds {
+		duration := formatDuration(r.end - r.start)
+		if r.running || r.end == 0 {
+			duration = formatDuration(nowMS-r.start) + "…"
+		}
+		models := make([]string, len(r.models))
+		for j, model := range r.models {
+			models[j] = shortModel(model)
+		}
+		lines = append(lines, fmt.Sprintf(" %4d  %-8s  %-9s  %5d  %7s  %7s  %7s  %s",
+			i+1, time.UnixMilli(r.start).Format("15:04:05"), duration, r.toolCalls,
+			formatTokens(r.tokensIn), formatTokens(r.tokensOut), formatCost(r.cost), strings.Join(models, ",")))
+	}
+	return lines
+}
+
+// roundsLines renders the rounds tab for a session, or the error.
+func roundsLines(sessionID string) []string {
+	rounds, err := querySessionRounds(sessionID)
+	if err != nil && len(rounds) == 0 {
+		return []string{"  " + err.Error()}
+	}
+	return formatRounds(rounds)
+}
+
+// detailRoundsCmd switches the detail view to the rounds tab.
+func detailRoundsCmd(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		return detailToggleMsg{lines: roundsLines(sessionID), source: "rounds"}
+	}
+}
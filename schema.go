@@ -0,0 +1,134 @@
+// schema detection. opencode migrates its database as it evolves, and a
+// renamed column makes every query fail or come back empty, which looks
+// like nothing is running. each database is inspected once per run:
+// the tables and columns otop's queries need are checked with
+// pragma table_info, and known variants are adapted to:
+//
+//   - session.permission is missing before per-session permission rules;
+//     every session then counts as interactive
+//   - the todo table is missing before todos; the t panel stays empty
+//
+// anything else missing makes the schema unsupported, shown as a banner
+// with the schema version (applied drizzle migrations, or user_version).
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// requiredSchema lists the columns otop's queries read, by table.
+var requiredSchema = map[string][]string{
+	"session": {"id", "title", "directory", "project_id", "version", "time_created", "time_updated"},
+	"message": {"id", "session_id", "data", "time_created"},
+	"part":    {"id", "message_id", "session_id", "data", "time_created"},
+}
+
+// schemaInfo is what inspecting one database found.
+type schemaInfo struct {
+	version       int64
+	missing       []string // "table" or "table.column" otop needs but didn't find
+	hasPermission bool     // session.permission
+	hasTodo       bool     // todo table
+}
+
+var (
+	schemaMu    sync.Mutex
+	schemaCache = make(map[string]schemaInfo)
+)
+
+// schemaFor returns the schema of the database at path, inspecting it
+// on first use. a database that can't be inspected is assumed current
+// (and retried next time), so the failure shows as the query error it
+// causes instead.
+func schemaFor(path string) schemaInfo {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if info, ok := schemaCache[path]; ok {
+		return info
+	}
+	info, err := inspectSchema(path)
+	if err != nil {
+		return schemaInfo{hasPermission: true, hasTodo: true}
+	}
+	schemaCache[path] = info
+	return info
+}
+
+// inspectSchema reads the tables and columns of the database at path.
+func inspectSchema(path string) (schemaInfo, error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return schemaInfo{}, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	columns := func(table string) ([]string, error) {
+		rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			if rows.Scan(&name) == nil {
+				names = append(names, name)
+			}
+		}
+		return names, rows.Err()
+	}
+
+	var info schemaInfo
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM __drizzle_migrations`).Scan(&info.version); err != nil {
+		if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&info.version); err != nil {
+			return schemaInfo{}, err
+		}
+	}
+
+	for _, table := range []string{"session", "message", "part"} {
+		have, err := columns(table)
+		if err != nil {
+			return schemaInfo{}, err
+		}
+		if len(have) == 0 {
+			info.missing = append(info.missing, table)
+			continue
+		}
+		for _, col := range requiredSchema[table] {
+			if !slices.Contains(have, col) {
+				info.missing = append(info.missing, table+"."+col)
+			}
+		}
+		if table == "session" {
+			info.hasPermission = slices.Contains(have, "permission")
+		}
+	}
+	todo, err := columns("todo")
+	if err != nil {
+		return schemaInfo{}, err
+	}
+	info.hasTodo = len(todo) > 0
+	return info, nil
+}
+
+// schemaProblem returns an error naming the first configured database
+// whose schema otop can't read, or nil.
+func schemaProblem() error {
+	for _, src := range allDBSources() {
+		if _, ok := storageRoot(src.path); ok {
+			continue
+		}
+		if info := schemaFor(src.path); len(info.missing) > 0 {
+			return fmt.Errorf("unsupported opencode schema v%d in %s (missing %s)",
+				info.version, src.label, strings.Join(info.missing, ", "))
+		}
+	}
+	return nil
+}
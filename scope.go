@@ -0,0 +1,79 @@
+// directory scoping (--cwd, c key).
+//
+// when a scope is set, the list, stats, and status-change notices only
+// cover sessions whose directory is the scope or below it. like the
+// column picker, the toggle edits a package-level setting in place so
+// the next fetch picks it up; it's atomic because fetches run in
+// their own goroutines.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// scope holds the directory otop is restricted to (a string).
+var scope atomic.Value
+
+// scopeDir returns the current scope; "" means everything.
+func scopeDir() string {
+	dir, _ := scope.Load().(string)
+	return dir
+}
+
+// scopeToCwd restricts otop to the current directory.
+func scopeToCwd() {
+	if wd, err := os.Getwd(); err == nil {
+		scope.Store(filepath.Clean(wd))
+	}
+}
+
+// toggleScope switches between the current directory and no scope.
+func toggleScope() {
+	if scopeDir() != "" {
+		scope.Store("")
+		return
+	}
+	scopeToCwd()
+}
+
+// dirInScope reports whether dir is the scope or below it.
+func dirInScope(dir string) bool {
+	root := scopeDir()
+	if root == "" {
+		return true
+	}
+	dir = filepath.Clean(dir)
+	return dir == root || strings.HasPrefix(dir, root+string(filepath.Separator))
+}
+
+// sessionInScope checks a session's directory, falling back to the
+// process cwd for processes without a session.
+func sessionInScope(cs correlatedSession) bool {
+	if cs.session != nil && cs.session.directory != "" {
+		return dirInScope(cs.session.directory)
+	}
+	return dirInScope(cs.process.cwd)
+}
+
+// scopeSQL returns a condition on the session table (and its args)
+// matching the scope and the watch filters (watch.go), or "" when
+// neither is set. appended to stats queries.
+func scopeSQL() (string, []any) {
+	cond, args := watchSQL()
+	root := scopeDir()
+	if root == "" {
+		return cond, args
+	}
+	prefix := root + string(filepath.Separator)
+	// sqlite's substr counts characters, not bytes
+	scoped := "(s.directory = ? OR substr(s.directory, 1, ?) = ?)"
+	if cond != "" {
+		scoped += " AND " + cond
+	}
+	return scoped, append([]any{root, utf8.RuneCountInString(prefix), prefix}, args...)
+}
@@ -0,0 +1,83 @@
+// scroll position for lists longer than a page. the list and detail
+// views draw a thin scrollbar down the right edge of their rows and put
+// the visible range ("12–24/57") in the footer, so it's clear how much
+// is off-screen. neither shows when everything fits.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	scrollTrack = "│"
+	scrollThumb = "┃"
+)
+
+// scrollbar returns one glyph per row for a bar height rows tall over a
+// list of total items showing page items from offset. nil when the whole
+// list fits.
+func scrollbar(offset, page, total, height int) []string {
+	if total <= page || height <= 0 {
+		return nil
+	}
+	thumb := max(1, height*page/total)
+	start := min(height-thumb, height*offset/total)
+	if offset+page >= total {
+		start = height - thumb // pin to the bottom on the last page
+	}
+	bar := make([]string, height)
+	for i := range bar {
+		if i >= start && i < start+thumb {
+			bar[i] = scrollThumb
+		} else {
+			bar[i] = dimStyle.Render(scrollTrack)
+		}
+	}
+	return bar
+}
+
+// scrollPosition returns the visible range, e.g. "12–24/57", or "" when
+// the whole list fits.
+func scrollPosition(offset, page, total int) string {
+	if total <= page {
+		return ""
+	}
+	return fmt.Sprintf("%d–%d/%d", offset+1, min(offset+page, total), total)
+}
+
+// withScrollbar fits each line to width-1 columns and appends its
+// scrollbar glyph. lines are returned unchanged when bar is nil.
+func withScrollbar(lines []string, bar []string, width int) []string {
+	if bar == nil {
+		return lines
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		glyph := " "
+		if i < len(bar) {
+			glyph = bar[i]
+		}
+		out[i] = truncOrPad(line, width-1) + glyph
+	}
+	return out
+}
+
+// listScrollPosition is scrollPosition for the session list.
+func (m model) listScrollPosition() string {
+	return scrollPosition(m.scrollOffset, m.listPageSize(), len(m.getVisibleSessions()))
+}
+
+// detailPosition is scrollPosition for the detail view's content.
+func (m model) detailPosition(rows int) string {
+	return scrollPosition(m.detailScroll, rows, len(m.detailLines))
+}
+
+// joinLines writes lines to b, each followed by a newline.
+func joinLines(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
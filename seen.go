@@ -0,0 +1,68 @@
+// unseen-output markers. opening a session's detail view records its
+// lastMessageTime as seen; rows whose agent has written a message since
+// get a "●" ahead of the title, like unread chats. a session otop hasn't
+// seen before starts out read, so launching doesn't mark everything.
+// the seen times are saved with the UI state.
+
+package main
+
+// unseenMarker prefixes the title of rows with new output.
+const unseenMarker = "● "
+
+// markSeen records the session's newest message as seen.
+func (m model) markSeen(s *sessionInfo) {
+	if s != nil && m.seen != nil {
+		m.seen[s.sessionID] = max(m.seen[s.sessionID], s.lastMessageTime)
+	}
+}
+
+// updateSeen baselines sessions appearing for the first time and keeps
+// the one open in the detail view read.
+func (m model) updateSeen(correlated []correlatedSession) {
+	for _, cs := range correlated {
+		if cs.session == nil {
+			continue
+		}
+		if _, ok := m.seen[cs.session.sessionID]; !ok {
+			m.markSeen(cs.session)
+		}
+		if m.detailMode && m.detailSession != nil && m.detailSession.session != nil &&
+			m.detailSession.session.sessionID == cs.session.sessionID {
+			m.markSeen(cs.session)
+		}
+	}
+}
+
+// unseen reports whether the agent has written since the session was
+// last viewed. the user's own prompts don't count.
+func (m model) unseen(cs correlatedSession) bool {
+	s := cs.session
+	if s == nil || s.lastMessageRole != "assistant" {
+		return false
+	}
+	seen, ok := m.seen[s.sessionID]
+	return ok && s.lastMessageTime > seen
+}
+
+// unseenPrefix returns unseenMarker for rows with new output, or "".
+func (m model) unseenPrefix(cs correlatedSession) string {
+	if m.unseen(cs) {
+		return unseenMarker
+	}
+	return ""
+}
+
+// liveSeen returns the seen times of the sessions currently listed, for
+// saving; sessions that went away are baselined again if they return.
+func (m model) liveSeen() map[string]int64 {
+	live := make(map[string]int64)
+	for _, cs := range m.sessions {
+		if cs.session == nil {
+			continue
+		}
+		if t, ok := m.seen[cs.session.sessionID]; ok {
+			live[cs.session.sessionID] = t
+		}
+	}
+	return live
+}
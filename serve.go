@@ -2,65 +2,326 @@
 //
 // serves the same correlated session data as the TUI, but as JSON
 // over HTTP so the phone can poll it via adb reverse port forwarding.
+// collection runs on its own interval in the background; handlers
+// serve the latest snapshot with freshness headers.
 
 package main
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// serveConfig holds the `otop serve` flags.
+type serveConfig struct {
+	port           int
+	requirePairing bool          // session endpoints only answer paired devices (see pair.go)
+	interval       time.Duration // how often the background collector runs
+	staleAfter     time.Duration // snapshots older than this are flagged stale
+	allowOrigins   []string      // browser origins /ws accepts besides its own (see ws.go)
+}
+
+// serveSnapshot is one collection cycle, shared by all handlers.
+type serveSnapshot struct {
+	correlated  []correlatedSession
+	todayStats  aggStats
+	globalStats aggStats
+	collectedAt time.Time
+	dbErr       error
+}
+
+// the latest snapshot. handlers never trigger a collection themselves,
+// so any number of polling clients cost the same as one.
+var (
+	snapshotMu    sync.RWMutex
+	snapshot      *serveSnapshot
+	snapshotReady = make(chan struct{})
+	serveOpts     serveConfig
+
+	markSnapshotReady = sync.OnceFunc(func() { close(snapshotReady) })
+)
+
 // serveCommand starts an HTTP server that exposes session data as JSON.
-func serveCommand(port int) {
-	http.HandleFunc("/sessions", handleSessions)
-	http.HandleFunc("/sessions/", handleSessionAction)
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+func serveCommand(cfg serveConfig) {
+	serveOpts = cfg
+	bus.subscribe(recordTransition)
+	restoredPayload = loadPersistedSnapshot()
+	stopHistory := func() {}
+	if display.history {
+		if stop, err := startHistory(); err != nil {
+			fmt.Printf("warning: history: %v\n", err)
+		} else {
+			stopHistory = stop
+		}
+	}
+	persistSnapshotOnExit(stopHistory)
+	go collectLoop(cfg.interval)
+
+	mux := newAPIMux(cfg.requirePairing, true)
+	mux.HandleFunc("/pair", handlePair)
+
+	addr := fmt.Sprintf(":%d", cfg.port)
+	fmt.Printf("otop serve on %s (collecting every %s)\n", addr, cfg.interval)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Printf("error: %v\n", err)
+	}
+}
+
+// newAPIMux routes the snapshot/action endpoints shared by `otop serve`
+// and the TUI's unix socket (see socket.go). over tcp without pairing,
+// nothing authenticates the caller, so the session actions (which drive
+// agents that have a shell) and /ws only answer loopback clients.
+func newAPIMux(requirePairing, tcp bool) *http.ServeMux {
+	sessions, actions, project, ws := handleSessions, handleSessionAction, handleProject, handleWS
+	if requirePairing {
+		sessions = requirePairedDevice(sessions)
+		actions = requirePairedDevice(actions)
+		project = requirePairedDevice(project)
+		ws = requirePairedDevice(ws)
+	} else if tcp {
+		actions = loopbackOnly(actions)
+		ws = loopbackOnly(ws)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", sessions)
+	mux.HandleFunc("/sessions/", actions)
+	mux.HandleFunc("/project", project)
+	mux.HandleFunc("/ws", ws)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	return mux
+}
 
-	addr := fmt.Sprintf(":%d", port)
-	fmt.Printf("otop serve on %s\n", addr)
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		fmt.Printf("error: %v\n", err)
+// loopbackOnly wraps a handler so it only answers clients on this
+// machine (including adb reverse forwards, which arrive on loopback).
+func loopbackOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "this endpoint needs --require-pairing from other machines", http.StatusForbidden)
+			return
+		}
+		next(w, r)
 	}
 }
 
-// handleSessions returns the full correlated session list as JSON.
-// includes all fields the phone needs: process info, session state,
-// last output, tokens, todos, and timestamps for freshness calculation.
-func handleSessions(w http.ResponseWriter, r *http.Request) {
+// isLoopback reports whether a request's RemoteAddr is a loopback address.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// collectLoop refreshes the shared snapshot on a fixed interval and
+// publishes the status transitions between collections, as the TUI does.
+func collectLoop(interval time.Duration) {
+	var prev map[string]string
+	for {
+		snap := collectSnapshot()
+		storeSnapshot(snap)
+		for _, n := range detectTransitions(prev, snap.correlated) {
+			bus.publish(transitionEvent{sessionID: n.sessionID, notice: n})
+		}
+		prev = sessionStatuses(snap.correlated)
+		bus.publish(snapshotEvent{fetchResult{
+			correlated:  snap.correlated,
+			todayStats:  snap.todayStats,
+			globalStats: snap.globalStats,
+			dbErr:       snap.dbErr,
+		}})
+		time.Sleep(interval)
+	}
+}
+
+// storeSnapshot replaces the shared snapshot. the first store closes
+// snapshotReady so early requests can wait for it.
+func storeSnapshot(snap *serveSnapshot) {
+	snapshotMu.Lock()
+	snapshot = snap
+	snapshotMu.Unlock()
+	markSnapshotReady()
+}
+
+// collectSnapshot runs correlation and stats queries concurrently.
+func collectSnapshot() *serveSnapshot {
 	var (
-		correlated  []correlatedSession
-		todayStats  aggStats
-		globalStats aggStats
-		wg          sync.WaitGroup
+		snap serveSnapshot
+		wg   sync.WaitGroup
+		errs [2]error
 	)
+	nextSnapshotCycle()
 
-	wg.Add(3)
+	wg.Add(2)
 
 	go func() {
 		defer wg.Done()
-		_, correlated = correlateAllSessions()
+		_, snap.correlated, errs[0] = correlateAllSessions()
 	}()
 
 	go func() {
 		defer wg.Done()
-		todayStats = queryTodayStats()
+		snap.todayStats, snap.globalStats, errs[1] = backendStats()
 	}()
 
+	wg.Wait()
+	snap.dbErr = errors.Join(errs[:]...)
+	snap.collectedAt = time.Now()
+	return &snap
+}
+
+// latestSnapshot waits for the first collection, then returns the newest.
+// returns nil if the request is cancelled before any data exists.
+func latestSnapshot(ctx context.Context) *serveSnapshot {
+	select {
+	case <-snapshotReady:
+	case <-ctx.Done():
+		return nil
+	}
+	snapshotMu.RLock()
+	defer snapshotMu.RUnlock()
+	return snapshot
+}
+
+// -- snapshot persistence --
+// on SIGINT/SIGTERM the last payload and the ring of recent transitions
+// (bus.go) are written to disk; the next start serves the payload
+// (marked stale) until its own first collection completes and carries
+// on with the ring, so a quick pm2 restart doesn't hand clients an
+// empty list or forget what just finished.
+
+// restoredPayload is the previous run's payload, nil if none was saved.
+var restoredPayload map[string]any
+
+// persistedServeState is the on-disk format of the saved snapshot.
+type persistedServeState struct {
+	Payload map[string]any `json:"payload"`
+	Events  []recentEvent  `json:"events"`
+}
+
+// recentEvent is a transition as /sessions and the saved state list it.
+type recentEvent struct {
+	SessionID string `json:"session_id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	AtMS      int64  `json:"at_ms"`
+}
+
+// recentEventsPayload converts the transition ring for JSON.
+func recentEventsPayload(notices []statusNotice) []recentEvent {
+	events := make([]recentEvent, 0, len(notices))
+	for _, n := range notices {
+		events = append(events, recentEvent{SessionID: n.sessionID, Title: n.title, Status: n.status, AtMS: n.at.UnixMilli()})
+	}
+	return events
+}
+
+func persistedSnapshotPath() string {
+	return filepath.Join(otopStateDir(), "serve-snapshot.json")
+}
+
+// loadPersistedSnapshot reads the previous run's state, refills the
+// transition ring from it and returns its payload flagged stale.
+func loadPersistedSnapshot() map[string]any {
+	data, err := os.ReadFile(persistedSnapshotPath())
+	if err != nil {
+		return nil
+	}
+	var state persistedServeState
+	if json.Unmarshal(data, &state) != nil || state.Payload == nil {
+		return nil
+	}
+	var notices []statusNotice
+	for _, e := range state.Events {
+		notices = append(notices, statusNotice{sessionID: e.SessionID, title: e.Title, status: e.Status, at: time.UnixMilli(e.AtMS)})
+	}
+	restoreTransitions(notices)
+	state.Payload["stale"] = true
+	return state.Payload
+}
+
+// persistSnapshotOnExit installs a signal handler that saves the latest
+// payload and the transition ring, and runs stopHistory so the pending
+// history sample is written, before exiting.
+func persistSnapshotOnExit(stopHistory func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		defer wg.Done()
-		globalStats = queryGlobalStats()
+		<-sigCh
+		snapshotMu.RLock()
+		snap := snapshot
+		snapshotMu.RUnlock()
+		if snap != nil {
+			state := persistedServeState{Payload: sessionsPayload(snap), Events: recentEventsPayload(recentTransitions())}
+			if data, err := json.Marshal(state); err == nil {
+				_ = os.MkdirAll(otopStateDir(), 0o700)
+				_ = os.WriteFile(persistedSnapshotPath(), data, 0o600)
+			}
+		}
+		stopHistory()
+		os.Exit(0)
 	}()
+}
+
+// setFreshnessHeaders tells clients how old the snapshot is.
+// X-Otop-Stale is "true" once the age exceeds --stale-after.
+func setFreshnessHeaders(w http.ResponseWriter, snap *serveSnapshot) {
+	age := time.Since(snap.collectedAt)
+	w.Header().Set("X-Otop-Collected-At", fmt.Sprintf("%d", snap.collectedAt.UnixMilli()))
+	w.Header().Set("X-Otop-Snapshot-Age-Ms", fmt.Sprintf("%d", age.Milliseconds()))
+	w.Header().Set("X-Otop-Stale", fmt.Sprintf("%t", age > serveOpts.staleAfter))
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(serveOpts.interval.Seconds())))
+}
+
+// handleSessions returns the full correlated session list as JSON.
+// until the first collection finishes, a payload restored from the
+// previous run is served instead, marked stale.
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	select {
+	case <-snapshotReady:
+	default:
+		if restoredPayload != nil {
+			w.Header().Set("X-Otop-Stale", "true")
+			json.NewEncoder(w).Encode(restoredPayload)
+			return
+		}
+	}
+
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+	setFreshnessHeaders(w, snap)
+	json.NewEncoder(w).Encode(sessionsPayload(snap))
+}
+
+// sessionsPayload builds the /sessions response from a snapshot.
+// includes all fields the phone needs: process info, session state,
+// last output, tokens, todos, and timestamps for freshness calculation.
+func sessionsPayload(snap *serveSnapshot) map[string]any {
+	correlated := snap.correlated
+	todayStats := snap.todayStats
+	globalStats := snap.globalStats
 
-	wg.Wait()
 	nowMS := time.Now().UnixMilli()
 
 	var sessions []map[string]any
@@ -82,24 +343,50 @@ func handleSessions(w http.ResponseWriter, r *http.Request) {
 		}
 
 		entry := map[string]any{
-			"session_id":          cs.session.sessionID,
-			"title":               cs.session.title,
-			"status":              status,
-			"model":               shortModel(cs.session.model),
-			"last_output":         cs.session.lastOutput,
-			"directory":           cs.session.directory,
-			"message_count":       cs.session.messageCount,
-			"total_input_tokens":  cs.session.totalInputTokens,
-			"total_output_tokens": cs.session.totalOutputTokens,
-			"total_cache_read":    cs.session.totalCacheRead,
-			"last_message_time":   cs.session.lastMessageTime,
-			"uptime_ms":           uptimeMS,
-			"round_ms":            roundMS,
-			"cpu_percent":         cs.process.cpuPercent,
-			"mem_mb":              cs.process.memMB,
-			"pid":                 cs.process.pid,
-			"tty":                 cs.process.tty,
-			"interactive":         cs.session.interactive,
+			"session_id":           cs.session.sessionID,
+			"source":               cs.session.source,
+			"title":                cs.session.title,
+			"status":               status,
+			"model":                shortModel(cs.session.model),
+			"model_id":             cs.session.model,
+			"model_short":          shortModel(cs.session.model),
+			"last_output":          cs.session.lastOutput,
+			"last_output_full":     cs.session.lastOutputFull,
+			"cost":                 cs.session.totalCost,
+			"directory":            cs.session.directory,
+			"message_count":        cs.session.messageCount,
+			"round_count":          cs.session.roundCount,
+			"tool_calls":           cs.session.toolCalls,
+			"total_input_tokens":   cs.session.totalInputTokens,
+			"total_output_tokens":  cs.session.totalOutputTokens,
+			"total_cache_read":     cs.session.totalCacheRead,
+			"total_cache_write":    cs.session.totalCacheWrite,
+			"cache_hit_percent":    cacheHitPercent(cs.session),
+			"last_message_time":    cs.session.lastMessageTime,
+			"uptime_ms":            uptimeMS,
+			"uptime_human":         formatDuration(uptimeMS),
+			"round_ms":             roundMS,
+			"round_human":          formatDuration(roundMS),
+			"cpu_percent":          cs.process.cpuPercent,
+			"mem_mb":               cs.process.memMB,
+			"children_cpu_percent": cs.process.totalCPU() - cs.process.cpuPercent,
+			"children_mem_mb":      cs.process.totalMemMB() - cs.process.memMB,
+			"pid":                  cs.process.pid,
+			"tty":                  cs.process.tty,
+			"container":            cs.process.container,
+			"host":                 cs.process.host,
+			"api_port":             cs.process.apiPort,
+			"interactive":          cs.session.interactive,
+			"last_error":           cs.session.lastError,
+			"avg_latency_ms":       cs.session.avgLatencyMS,
+			"tokens_per_sec":       cs.session.tokensPerSec,
+			"throughput_rounds":    cs.session.throughputRounds,
+			"compactions":          cs.session.compactions,
+			"last_compaction_ms":   cs.session.lastCompaction,
+			"last_user_input_ms":   cs.session.lastUserInput,
+			"time_created_ms":      cs.session.timeCreated,
+			"time_updated_ms":      cs.session.timeUpdated,
+			"todo_summary":         todoSummary(cs.session.activeTodos),
 		}
 
 		// include todos if present
@@ -119,8 +406,9 @@ func handleSessions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := map[string]any{
-		"timestamp": nowMS,
-		"sessions":  sessions,
+		"timestamp":    nowMS,
+		"collected_at": snap.collectedAt.UnixMilli(),
+		"sessions":     sessions,
 		"today": map[string]any{
 			"session_count": todayStats.sessionCount,
 			"message_count": todayStats.messageCount,
@@ -133,23 +421,45 @@ func handleSessions(w http.ResponseWriter, r *http.Request) {
 			"total_input":   globalStats.totalInput,
 			"total_output":  globalStats.totalOutput,
 		},
+		"events": recentEventsPayload(recentTransitions()),
+		"stale":  time.Since(snap.collectedAt) > serveOpts.staleAfter,
+	}
+	if snap.dbErr != nil {
+		response["db_error"] = snap.dbErr.Error()
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(w).Encode(response)
+	return response
+}
+
+// sessionActions are the verbs accepted on /sessions/<id>/<action>.
+var sessionActions = map[string]bool{
+	"fork":    true,
+	"jump":    true,
+	"abort":   true,
+	"compact": true,
+	"prompt":  true,
+	"screen":  true,
 }
 
 // handleSessionAction routes sub-resource actions on /sessions/<id>/<action>.
-// currently supports: POST /sessions/<id>/fork
+// supports POST fork, jump, and the opencode API actions abort, compact,
+// and prompt (body: {"text": "..."}), plus GET screen.
 func handleSessionAction(w http.ResponseWriter, r *http.Request) {
-	// path: /sessions/<id>/fork
+	// path: /sessions/<id>/<action>
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(parts) != 3 || parts[0] != "sessions" || parts[2] != "fork" {
+	if len(parts) != 3 || parts[0] != "sessions" || !sessionActions[parts[2]] {
 		http.NotFound(w, r)
 		return
 	}
 
+	if parts[2] == "screen" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleScreen(w, r, parts[1])
+		return
+	}
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -161,11 +471,21 @@ func handleSessionAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch parts[2] {
+	case "jump":
+		handleJump(w, r, sessionID)
+		return
+	case "abort", "compact", "prompt":
+		handleAPIAction(w, r, sessionID, parts[2])
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 90*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "opencode", "run", "--fork", "-s", sessionID, "--format", "json", "(forked)")
 	output, err := cmd.Output()
+	publishAction("api", "fork", sessionID, err)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("fork failed: %v", err), http.StatusInternalServerError)
 		return
@@ -191,3 +511,112 @@ func handleSessionAction(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	json.NewEncoder(w).Encode(map[string]string{"session_id": newSessionID})
 }
+
+// runningSession finds the process running sessionID in the snapshot.
+func runningSession(snap *serveSnapshot, sessionID string) (correlatedSession, bool) {
+	for _, cs := range snap.correlated {
+		if cs.session != nil && cs.session.sessionID == sessionID {
+			return cs, true
+		}
+	}
+	return correlatedSession{}, false
+}
+
+// handleAPIAction forwards abort/compact/prompt to the session's
+// opencode server.
+func handleAPIAction(w http.ResponseWriter, r *http.Request, sessionID, action string) {
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+	cs, ok := runningSession(snap, sessionID)
+	if !ok {
+		http.Error(w, "session not running", http.StatusNotFound)
+		return
+	}
+
+	var err error
+	switch action {
+	case "abort":
+		err = abortSession(cs.process, sessionID)
+	case "compact":
+		err = compactSession(cs.process, cs.session)
+	case "prompt":
+		var body struct {
+			Text string `json:"text"`
+		}
+		if json.NewDecoder(r.Body).Decode(&body) != nil || body.Text == "" {
+			http.Error(w, "body must be {\"text\": \"...\"}", http.StatusBadRequest)
+			return
+		}
+		err = sendPrompt(cs.process, sessionID, body.Text)
+	}
+	publishAction("api", action, sessionID, err)
+	if errors.Is(err, errNoAPI) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJump focuses the pane of the process running sessionID.
+func handleJump(w http.ResponseWriter, r *http.Request, sessionID string) {
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+	cs, ok := runningSession(snap, sessionID)
+	if !ok {
+		http.Error(w, "session not running", http.StatusNotFound)
+		return
+	}
+	provider := focusPane(cs.process)
+	if provider == "" {
+		publishAction("api", "jump", sessionID, errors.New("no pane provider could focus the session"))
+		http.Error(w, "no pane provider could focus the session", http.StatusConflict)
+		return
+	}
+	publishAction("api", "jump", sessionID, nil)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"provider": provider})
+}
+
+// handleScreen returns the session's pane capture as plain text, like the
+// TUI detail view. ?ansi=1 keeps color escapes (tmux only). the provider
+// that captured it is in X-Otop-Pane-Provider.
+func handleScreen(w http.ResponseWriter, r *http.Request, sessionID string) {
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+	cs, ok := runningSession(snap, sessionID)
+	if !ok {
+		http.Error(w, "session not running", http.StatusNotFound)
+		return
+	}
+
+	var (
+		lines    []string
+		provider string
+	)
+	if ansi, _ := strconv.ParseBool(r.URL.Query().Get("ansi")); ansi && cs.process.host == "" {
+		if lines = captureTmuxPaneANSI(cs.process.tty); lines != nil {
+			provider = "tmux"
+		}
+	}
+	if lines == nil {
+		lines, provider = capturePane(cs.process)
+	}
+	if lines == nil {
+		http.Error(w, "no pane provider could capture the session", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Otop-Pane-Provider", provider)
+	w.Write([]byte(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,88 @@
+// incremental session refresh. getSessionInfoFrom runs about eight
+// queries per session, most of them aggregates over every message, yet
+// most sessions sit idle between fetches. each result is kept with a
+// cheap change stamp (the session's time_updated and its newest part);
+// while the stamp holds and the session was idle when cached, the copy
+// is returned instead of re-querying.
+//
+// only idle sessions are reused: a response in flight changes message
+// data (finish, tokens) without necessarily touching either stamp.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// sessionStamp is what has to stay equal for a cached session to be reused.
+type sessionStamp struct {
+	updated  int64 // session.time_updated
+	lastPart int64 // newest part write (created or updated)
+}
+
+type sessionCacheKey struct {
+	path, sessionID string
+}
+
+type sessionCacheEntry struct {
+	stamp sessionStamp
+	info  sessionInfo
+}
+
+// sessionCacheLimit bounds the cache; past it the cache starts over.
+const sessionCacheLimit = 1000
+
+var (
+	sessionCacheMu sync.Mutex
+	sessionCache   = make(map[sessionCacheKey]sessionCacheEntry)
+)
+
+// readSessionStamp reads a session's change stamp. found is false when
+// the session doesn't exist in db.
+func readSessionStamp(ctx context.Context, db *sql.DB, sessionID string) (stamp sessionStamp, found bool, err error) {
+	var updated, lastPart sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT s.time_updated, (SELECT max(max(time_created, coalesce(time_updated, 0))) FROM part WHERE session_id = s.id)
+		FROM session s WHERE s.id = ?
+	`, sessionID).Scan(&updated, &lastPart)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sessionStamp{}, false, nil
+	}
+	if err != nil {
+		return sessionStamp{}, false, err
+	}
+	return sessionStamp{updated: updated.Int64, lastPart: lastPart.Int64}, true, nil
+}
+
+// cachedSession returns a copy of the cached session if its stamp still
+// matches.
+func cachedSession(path, sessionID string, stamp sessionStamp) (*sessionInfo, bool) {
+	sessionCacheMu.Lock()
+	defer sessionCacheMu.Unlock()
+	entry, ok := sessionCache[sessionCacheKey{path, sessionID}]
+	if !ok || entry.stamp != stamp {
+		return nil, false
+	}
+	info := entry.info
+	return &info, true
+}
+
+// cacheSession stores a fully read session for reuse, if it's idle: the
+// last message is a finished assistant reply that isn't handing off to
+// a tool.
+func cacheSession(path string, stamp sessionStamp, s *sessionInfo) {
+	key := sessionCacheKey{path, s.sessionID}
+	sessionCacheMu.Lock()
+	defer sessionCacheMu.Unlock()
+	if s.lastMessageRole != "assistant" || s.lastFinish == nil || *s.lastFinish == "tool-calls" || s.pendingTool != "" {
+		delete(sessionCache, key)
+		return
+	}
+	if len(sessionCache) >= sessionCacheLimit {
+		clear(sessionCache)
+	}
+	sessionCache[key] = sessionCacheEntry{stamp: stamp, info: *s}
+}
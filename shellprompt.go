@@ -0,0 +1,40 @@
+// `otop prompt`: a shell prompt segment. prints "●2" when sessions are
+// working in the current directory (a session whose directory is $PWD
+// or a parent of it), and nothing otherwise, so prompts stay clean.
+//
+// starship:
+//
+//	[custom.otop]
+//	command = "otop prompt"
+//	when = true
+//
+// reads from the running TUI's socket when there is one.
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// promptCommand prints the working-session count for the current
+// directory, or nothing.
+func promptCommand() {
+	pwd := os.Getenv("PWD")
+	if pwd == "" {
+		pwd, _ = os.Getwd()
+	}
+
+	working := 0
+	for _, s := range liveStatuses() {
+		if s.directory == "" || !activeStatuses[s.status] {
+			continue
+		}
+		if dirUnder(pwd, s.directory) {
+			working++
+		}
+	}
+	if working > 0 {
+		fmt.Printf("%s%d\n", statusGlyphs["generating"], working)
+	}
+}
@@ -0,0 +1,170 @@
+// `otop show <session-id>`: a one-session report on stdout, for scripts
+// and for "what is ses_xyz doing" over ssh without a TTY. prints the
+// session's metadata, status, tokens, cost, todos and its last messages,
+// as plain text or (--format json) one JSON object. a session that isn't
+// running is still reported from the database, with status "stopped".
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// showCommand prints the report for one session.
+func showCommand(sessionID, format string, messages int) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", format)
+	}
+
+	var (
+		session *sessionInfo
+		proc    *processInfo
+	)
+	_, correlated, _ := correlateAllSessions()
+	for _, cs := range correlated {
+		if cs.session != nil && !cs.process.isToolProcess && cs.session.sessionID == sessionID {
+			session, proc = cs.session, &cs.process
+			break
+		}
+	}
+	if session == nil {
+		s, err := getSessionInfo(sessionID)
+		if s == nil {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("session %s not found", sessionID)
+		}
+		session = s
+	}
+
+	status := "stopped"
+	if proc != nil {
+		status = inferStatus(session, proc.cpuPercent)
+	}
+	var recent []messageDetail
+	if messages > 0 {
+		recent = getRecentMessages(sessionID, messages, 0)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(showJSON(session, proc, status, recent))
+	}
+	fmt.Print(showText(session, proc, status, recent))
+	return nil
+}
+
+// showJSON builds the JSON form of the report.
+func showJSON(s *sessionInfo, proc *processInfo, status string, recent []messageDetail) map[string]any {
+	todos := []map[string]string{}
+	for _, t := range s.activeTodos {
+		todos = append(todos, map[string]string{"content": t.content, "status": t.status, "priority": t.priority})
+	}
+	msgs := []map[string]any{}
+	for _, msg := range recent {
+		msgs = append(msgs, map[string]any{
+			"role":         msg.role,
+			"finish":       msg.finish,
+			"model":        msg.model,
+			"tokens_in":    msg.tokensIn + msg.cacheRead,
+			"tokens_out":   msg.tokensOut,
+			"time_created": msg.timeCreated,
+			"text":         msg.textPreview,
+		})
+	}
+	entry := map[string]any{
+		"session_id":          s.sessionID,
+		"source":              s.source,
+		"title":               s.title,
+		"directory":           s.directory,
+		"status":              status,
+		"running":             proc != nil,
+		"model_id":            s.model,
+		"model_short":         shortModel(s.model),
+		"agent":               s.agent,
+		"version":             s.version,
+		"time_created":        s.timeCreated,
+		"time_updated":        s.timeUpdated,
+		"last_message_time":   s.lastMessageTime,
+		"message_count":       s.messageCount,
+		"round_count":         s.roundCount,
+		"tool_calls":          s.toolCalls,
+		"total_input_tokens":  s.totalInputTokens,
+		"total_output_tokens": s.totalOutputTokens,
+		"total_cache_read":    s.totalCacheRead,
+		"total_cache_write":   s.totalCacheWrite,
+		"cache_hit_percent":   cacheHitPercent(s),
+		"cost":                s.totalCost,
+		"compactions":         s.compactions,
+		"last_error":          s.lastError,
+		"todos":               todos,
+		"messages":            msgs,
+	}
+	if proc != nil {
+		entry["pid"] = proc.pid
+		entry["tty"] = proc.tty
+		entry["cpu_percent"] = proc.cpuPercent
+		entry["mem_mb"] = proc.memMB
+	}
+	return entry
+}
+
+// showText builds the plain-text form of the report.
+func showText(s *sessionInfo, proc *processInfo, status string, recent []messageDetail) string {
+	var b strings.Builder
+	nowMS := time.Now().UnixMilli()
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-10s %s\n", label+":", value)
+		}
+	}
+	at := func(ms int64) string {
+		if ms <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%s (%s ago)", time.UnixMilli(ms).Format("2006-01-02 15:04:05"), formatDuration(nowMS-ms))
+	}
+
+	fmt.Fprintf(&b, "%s  %s\n\n", s.sessionID, s.title)
+	field("status", status)
+	field("directory", s.directory)
+	field("source", s.source)
+	field("model", s.model)
+	field("agent", s.agent)
+	field("version", s.version)
+	field("created", at(s.timeCreated))
+	field("last msg", at(s.lastMessageTime))
+	if proc != nil {
+		field("process", fmt.Sprintf("pid %d  %s  cpu %.1f%%  mem %.0fMB", proc.pid, proc.tty, proc.cpuPercent, proc.memMB))
+	}
+	field("messages", fmt.Sprintf("%d in %d rounds", s.messageCount, s.roundCount))
+	field("tokens", fmt.Sprintf("ctx %s  out %s  cache %.0f%%",
+		formatTokens(s.totalInputTokens), formatTokens(s.totalOutputTokens), cacheHitPercent(s)))
+	field("tools", toolSummary(s, 5))
+	field("cost", formatCost(s.totalCost))
+	if s.compactions > 0 {
+		field("compacted", fmt.Sprint(s.compactions))
+	}
+	field("error", s.lastError)
+
+	if len(s.activeTodos) > 0 {
+		b.WriteString("\ntodos:\n")
+		for _, t := range s.activeTodos {
+			fmt.Fprintf(&b, "  [%s] %s\n", t.status, t.content)
+		}
+	}
+
+	if len(recent) > 0 {
+		b.WriteString("\nmessages:\n")
+		for _, line := range formatDBMessages(recent) {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+// snapshot-copy mode (config "snapshot"). sqlite's WAL mode relies on
+// shared memory and file locks that NFS and SSHFS don't provide, so
+// reading a live opencode.db over them can hang or return torn pages.
+// with snapshots on, each database (and its -wal) is copied to a local
+// temp directory, the WAL is folded in, and queries run against the
+// copy; the copy is renewed every `every` refresh cycles, so data can be
+// that many cycles old.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// snapshotConfig controls snapshot-copy mode.
+type snapshotConfig struct {
+	enabled bool
+	every   int // refresh cycles between copies; 1 = every refresh
+}
+
+// snapshotCycle counts refresh cycles (fetchAll, serve collections).
+var snapshotCycle atomic.Int64
+
+var (
+	snapshotCopyMu sync.Mutex
+	snapshotCopied = make(map[string]int64) // source path -> cycle copied in
+)
+
+// nextSnapshotCycle marks the start of a refresh cycle.
+func nextSnapshotCycle() {
+	snapshotCycle.Add(1)
+}
+
+// snapshotPath returns where the copy of the database at path lives.
+func snapshotPath(path string) string {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	return filepath.Join(otopRuntimeDir(), "snapshots", fmt.Sprintf("%016x.db", h.Sum64()))
+}
+
+// openSnapshot opens the local copy of the database at path, renewing
+// it first if it's older than snapshot.every cycles. the copy has its
+// WAL folded in, so it opens immutable: connections never write to it,
+// and ones still open on the previous copy keep reading that file after
+// a renewal replaces it.
+func openSnapshot(path string) (*sql.DB, error) {
+	snap := snapshotPath(path)
+	if err := refreshSnapshot(path, snap); err != nil {
+		return nil, err
+	}
+	return sql.Open("sqlite", "file:"+snap+"?mode=ro&immutable=1")
+}
+
+// refreshSnapshot copies path to snap when the copy is stale. other
+// queries wait on the copy rather than read a half-renewed one.
+func refreshSnapshot(path, snap string) error {
+	snapshotCopyMu.Lock()
+	defer snapshotCopyMu.Unlock()
+
+	cycle := snapshotCycle.Load()
+	if copied, ok := snapshotCopied[path]; ok && cycle-copied < int64(max(1, display.snapshot.every)) {
+		if _, err := os.Stat(snap); err == nil {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(snap), 0o700); err != nil {
+		return err
+	}
+	if err := buildSnapshot(path, snap+".part"); err != nil {
+		return fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	if err := os.Rename(snap+".part", snap); err != nil {
+		return fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	snapshotCopied[path] = cycle
+	return nil
+}
+
+// buildSnapshot copies the database at path and its WAL to dst, then
+// checkpoints the WAL into the copy and switches it out of WAL mode, so
+// dst is a single self-contained file. a missing WAL just means
+// everything is checkpointed already.
+func buildSnapshot(path, dst string) error {
+	os.Remove(dst + "-wal")
+	os.Remove(dst + "-shm")
+	if err := copyFileAtomic(path, dst); err != nil {
+		return err
+	}
+	if err := copyFileAtomic(path+"-wal", dst+"-wal"); err != nil {
+		os.Remove(dst + "-wal")
+	}
+	db, err := sql.Open("sqlite", "file:"+dst)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("PRAGMA journal_mode=DELETE")
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	os.Remove(dst + "-wal")
+	os.Remove(dst + "-shm")
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+// copyFileAtomic copies src to dst through a temp file and rename.
+func copyFileAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".part"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,95 @@
+// unix socket API: while the TUI runs it serves the same endpoints as
+// `otop serve` (GET /sessions, POST /sessions/<id>/fork|jump) on
+// $XDG_RUNTIME_DIR/otop.sock, backed by the TUI's own fetches. scripts
+// and editor plugins get current state without HTTP ports or a second
+// collection:
+//
+//	curl --unix-socket $XDG_RUNTIME_DIR/otop.sock http://otop/sessions
+//
+// the socket is created 0600; filesystem permissions are the auth.
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// socketPath returns where the TUI listens.
+func socketPath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "otop.sock")
+	}
+	return filepath.Join(otopStateDir(), "otop.sock")
+}
+
+// startSocketAPI listens on socketPath unless another live instance
+// already does. returns a function that closes and removes the socket.
+func startSocketAPI() (stop func()) {
+	path := socketPath()
+	if conn, err := net.DialTimeout("unix", path, 200*time.Millisecond); err == nil {
+		conn.Close()
+		return func() {} // owned by another otop
+	}
+	_ = os.Remove(path) // stale socket from a crashed instance
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return func() {}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return func() {}
+	}
+	_ = os.Chmod(path, 0o600)
+
+	serveOpts = serveConfig{interval: refreshInterval, staleAfter: 10 * time.Second}
+	srv := &http.Server{Handler: newAPIMux(false, false)}
+	go srv.Serve(ln)
+	unsubscribe := bus.subscribe(func(ev event) {
+		if snap, ok := ev.(snapshotEvent); ok {
+			publishSnapshot(snap.result)
+		}
+	})
+
+	return func() {
+		unsubscribe()
+		srv.Close()
+		_ = os.Remove(path)
+	}
+}
+
+// publishSnapshot hands a TUI fetch to the socket handlers. subscribed
+// to snapshotEvents by startSocketAPI.
+func publishSnapshot(result fetchResult) {
+	storeSnapshot(snapshotFromResult(result))
+}
+
+// snapshotFromResult wraps a fetch as a snapshot collected now.
+func snapshotFromResult(result fetchResult) *serveSnapshot {
+	return &serveSnapshot{
+		correlated:  result.correlated,
+		todayStats:  result.todayStats,
+		globalStats: result.globalStats,
+		collectedAt: time.Now(),
+		dbErr:       result.dbErr,
+	}
+}
+
+// socketClient talks HTTP to a running TUI over its socket. requests use
+// any host, e.g. http://otop/sessions. the timeout is short: callers
+// fall back to collecting directly when no TUI answers.
+func socketClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath())
+			},
+		},
+	}
+}
@@ -0,0 +1,157 @@
+// spawning new sessions (n).
+//
+// n opens a directory prompt. candidates are the project directories
+// opencode has seen (from the db, most recently active first), narrowed
+// and ranked by a fuzzy match on what's typed (fuzzy.go); a typed path
+// to an existing directory is used as is. enter launches opencode
+// in a new tmux window there and schedules an early refresh so the new
+// session shows up without waiting for the next tick.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spawnPickerRows is how many candidate directories are listed.
+const spawnPickerRows = 8
+
+type spawnDirsMsg []string
+
+type spawnedMsg struct {
+	dir string
+	err error
+}
+
+// spawnRefreshMsg fires shortly after a spawn to pick the new process up.
+type spawnRefreshMsg struct{}
+
+// spawnCandidates filters the known directories by the typed text,
+// best fuzzy match first (most recently active first among equals).
+func (m model) spawnCandidates() []string {
+	if m.spawnText == "" {
+		return m.spawnDirs[:min(spawnPickerRows, len(m.spawnDirs))]
+	}
+	type scored struct {
+		dir   string
+		score int
+	}
+	// fuzzyScore wants the pattern lowercased already
+	pattern := strings.ToLower(m.spawnText)
+	var found []scored
+	for _, dir := range m.spawnDirs {
+		if score, _, ok := fuzzyScore(pattern, dir); ok {
+			found = append(found, scored{dir, score})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return cmp.Compare(b.score, a.score) })
+	var matches []string
+	for _, f := range found[:min(spawnPickerRows, len(found))] {
+		matches = append(matches, f.dir)
+	}
+	return matches
+}
+
+func loadSpawnDirsCmd() tea.Msg {
+	dirs, _ := queryProjectDirs()
+	return spawnDirsMsg(dirs)
+}
+
+// spawnSessionCmd starts opencode in a new tmux window in dir.
+func spawnSessionCmd(dir string) tea.Cmd {
+	return func() tea.Msg {
+		if os.Getenv("TMUX") == "" || !tmuxInstalled() {
+			return spawnedMsg{dir: dir, err: fmt.Errorf("spawning needs otop to run inside tmux")}
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return spawnedMsg{dir: dir, err: fmt.Errorf("not a directory: %s", dir)}
+		}
+		err := exec.Command("tmux", "new-window", "-c", dir, "-n", filepath.Base(dir), "opencode").Run()
+		return spawnedMsg{dir: dir, err: err}
+	}
+}
+
+func (m model) handleSpawnKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.spawnActive = false
+	case tea.KeyEnter:
+		m.spawnActive = false
+		// a typed path to an existing directory wins over the candidates
+		// unless one was picked with the arrows
+		dir := expandHome(m.spawnText)
+		if info, err := os.Stat(dir); m.spawnCursor > 0 || dir == "" || err != nil || !info.IsDir() {
+			if c := m.spawnCandidates(); m.spawnCursor < len(c) {
+				dir = expandHome(c[m.spawnCursor])
+			}
+		}
+		if dir == "" {
+			return m, nil
+		}
+		return m, spawnSessionCmd(dir)
+	case tea.KeyTab:
+		if c := m.spawnCandidates(); m.spawnCursor < len(c) {
+			m.spawnText = c[m.spawnCursor]
+			m.spawnCursor = 0
+		}
+	case tea.KeyUp, tea.KeyCtrlP:
+		m.spawnCursor = max(m.spawnCursor-1, 0)
+	case tea.KeyDown, tea.KeyCtrlN:
+		m.spawnCursor = min(m.spawnCursor+1, max(0, len(m.spawnCandidates())-1))
+	case tea.KeyBackspace:
+		if r := []rune(m.spawnText); len(r) > 0 {
+			m.spawnText = string(r[:len(r)-1])
+			m.spawnCursor = 0
+		}
+	case tea.KeySpace:
+		m.spawnText += " "
+		m.spawnCursor = 0
+	case tea.KeyRunes:
+		m.spawnText += string(msg.Runes)
+		m.spawnCursor = 0
+	}
+	return m, nil
+}
+
+// renderSpawnPicker lists candidates, highlighting the one enter picks.
+// always spawnPickerRows lines so the list height doesn't jump.
+func (m model) renderSpawnPicker() string {
+	var b strings.Builder
+	candidates := m.spawnCandidates()
+	for i := 0; i < spawnPickerRows; i++ {
+		line := ""
+		if i < len(candidates) {
+			line = "  " + shortPath(candidates[i], max(10, m.width-4))
+		}
+		line = truncOrPad(line, m.width)
+		if i == m.spawnCursor && i < len(candidates) {
+			line = selectStyle.Render(line)
+		} else {
+			line = dimStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderSpawnBar replaces the footer while the directory prompt is open.
+func (m model) renderSpawnBar() string {
+	return headerStyle.Width(m.width).Render(" new session in: " + m.spawnText + "█")
+}
+
+// spawnRefreshCmd waits for the new process to write its pid file.
+func spawnRefreshCmd() tea.Cmd {
+	return tea.Tick(1500*time.Millisecond, func(time.Time) tea.Msg {
+		return spawnRefreshMsg{}
+	})
+}
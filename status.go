@@ -0,0 +1,148 @@
+// `otop status`: one-line session summary for tmux status-right.
+//
+//	set -g status-right '#(otop status)'
+//	set -g status-interval 5
+//
+// counts come from the running TUI over its socket when there is one,
+// otherwise from a direct collection. the output is a --format template;
+// the default colors each group with tmux #[fg=...] codes.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultStatusFormat renders "3 gen / 1 wait / 5 idle".
+const defaultStatusFormat = "#[fg=green]{gen} gen#[default] / #[fg=magenta]{wait} wait#[default] / {idle} idle"
+
+// statusLineGroups maps template placeholders to the statuses they count.
+// gen is the agent working, wait is the agent needing you.
+var statusLineGroups = []struct {
+	key      string
+	statuses []string
+}{
+	{"gen", []string{"generating", "tool use", "busy", "thinking", "queued"}},
+	{"wait", []string{"asking", "truncated", "stuck", "error"}},
+	{"idle", []string{"idle"}},
+}
+
+// statusCommand prints format with {gen}, {wait}, {idle}, {total}, and
+// any raw status ({generating}, {asking}, ...) replaced by counts.
+func statusCommand(format string) {
+	counts := make(map[string]int)
+	for _, s := range liveStatuses() {
+		if !dirInScope(s.directory) {
+			continue
+		}
+		counts[s.status]++
+		for _, g := range statusLineGroups {
+			if slices.Contains(g.statuses, s.status) {
+				counts[g.key]++
+			}
+		}
+		counts["total"]++
+	}
+
+	fmt.Println(expandStatusFormat(format, counts))
+}
+
+// expandStatusFormat replaces {key} with its count. tmux's own #{...}
+// formats are left alone so they can be mixed in.
+func expandStatusFormat(format string, counts map[string]int) string {
+	return expandPlaceholders(format, func(key string) string {
+		return strconv.Itoa(counts[key])
+	})
+}
+
+// expandPlaceholders replaces each {key} in format with value(key),
+// skipping tmux's #{...}.
+func expandPlaceholders(format string, value func(key string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(format, "{")
+		end := strings.Index(format[max(start, 0):], "}")
+		if start < 0 || end < 0 {
+			b.WriteString(format)
+			return b.String()
+		}
+		end += start
+		b.WriteString(format[:start])
+		if start > 0 && format[start-1] == '#' {
+			b.WriteString(format[start : end+1])
+		} else {
+			b.WriteString(value(format[start+1 : end]))
+		}
+		format = format[end+1:]
+	}
+}
+
+// liveStatus is one interactive session's directory and status.
+type liveStatus struct {
+	directory string
+	status    string
+}
+
+// liveStatuses lists the sessions the TUI shows by default, asking a
+// running TUI first and collecting directly when none answers.
+func liveStatuses() []liveStatus {
+	if statuses, ok := statusesFromSocket(); ok {
+		return statuses
+	}
+	return statusesFromCollection()
+}
+
+// statusesFromSocket reads statuses from a running TUI. ok is false if
+// none answered.
+func statusesFromSocket() ([]liveStatus, bool) {
+	resp, err := socketClient().Get("http://otop/sessions")
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	var payload struct {
+		Sessions []struct {
+			Status      string `json:"status"`
+			Directory   string `json:"directory"`
+			Interactive bool   `json:"interactive"`
+		} `json:"sessions"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&payload) != nil {
+		return nil, false
+	}
+	var statuses []liveStatus
+	for _, s := range payload.Sessions {
+		if s.Interactive {
+			statuses = append(statuses, liveStatus{s.Directory, s.Status})
+		}
+	}
+	return statuses, true
+}
+
+// statusesFromCollection collects directly.
+func statusesFromCollection() []liveStatus {
+	if firstMissingDB() != "" {
+		return nil
+	}
+	_, correlated, err := correlateAllSessions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: db error: %v\n", err)
+	}
+	var statuses []liveStatus
+	for _, cs := range correlated {
+		if cs.process.isToolProcess || cs.session == nil || !cs.session.interactive {
+			continue
+		}
+		statuses = append(statuses, liveStatus{cs.session.directory, inferStatus(cs.session, cs.process.cpuPercent)})
+	}
+	return statuses
+}
@@ -0,0 +1,286 @@
+// JSON file storage: the layout opencode used before it moved to sqlite.
+// when a database path doesn't exist but a storage/ directory sits next
+// to it, the session, stats, and detail queries read these files instead:
+//
+//	storage/session/<projectID>/<sessionID>.json
+//	storage/message/<sessionID>/<messageID>.json
+//	storage/part/<messageID>/<partID>.json
+//	storage/todo/<sessionID>.json
+//
+// message and part files hold the same JSON as the sqlite data column.
+// IDs sort in creation order, so file names give the ordering that
+// time_created gives in sql. every query re-reads the files; fine for
+// the session counts these builds were used with.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// storageSession is one session file.
+type storageSession struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"projectID"`
+	Directory string `json:"directory"`
+	Title     string `json:"title"`
+	Version   string `json:"version"`
+	Time      struct {
+		Created int64 `json:"created"`
+		Updated int64 `json:"updated"`
+	} `json:"time"`
+}
+
+// storageRoot returns the storage directory for a database path when
+// the database is missing and the file layout is there instead.
+func storageRoot(path string) (string, bool) {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return "", false
+	}
+	root := filepath.Join(filepath.Dir(path), "storage")
+	if info, err := os.Stat(filepath.Join(root, "session")); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return root, true
+}
+
+// readJSONFile decodes one file into v.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readJSONDir decodes every .json file in dir, in file name order.
+func readJSONDir(dir string) []map[string]any {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	slices.Sort(paths)
+	var result []map[string]any
+	for _, p := range paths {
+		var d map[string]any
+		if readJSONFile(p, &d) == nil {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// storageSessions reads every session file.
+func storageSessions(root string) []storageSession {
+	paths, _ := filepath.Glob(filepath.Join(root, "session", "*", "*.json"))
+	var result []storageSession
+	for _, p := range paths {
+		var s storageSession
+		if readJSONFile(p, &s) == nil && s.ID != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// storageMessages reads a session's messages, oldest first.
+func storageMessages(root, sessionID string) []map[string]any {
+	return readJSONDir(filepath.Join(root, "message", sessionID))
+}
+
+// storageParts reads a message's parts, oldest first.
+func storageParts(root, messageID string) []map[string]any {
+	return readJSONDir(filepath.Join(root, "part", messageID))
+}
+
+// getSessionInfoFromStorage is getSessionInfoFrom for the file layout.
+// returns nil, nil if the session doesn't exist.
+func getSessionInfoFromStorage(root, sessionID string) (*sessionInfo, error) {
+	paths, _ := filepath.Glob(filepath.Join(root, "session", "*", sessionID+".json"))
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	var s storageSession
+	if err := readJSONFile(paths[0], &s); err != nil {
+		return nil, err
+	}
+
+	session := &sessionInfo{
+		sessionID:   s.ID,
+		title:       s.Title,
+		directory:   s.Directory,
+		projectID:   s.ProjectID,
+		version:     s.Version,
+		interactive: true, // these builds had no per-session permission rules
+		timeCreated: s.Time.Created,
+		timeUpdated: s.Time.Updated,
+	}
+	if session.title == "" {
+		session.title = "(untitled)"
+	}
+
+	msgs := storageMessages(root, sessionID)
+	session.messageCount = len(msgs)
+	var rounds []roundMessage
+	for _, d := range msgs {
+		role := jsonStr(d, "role")
+		if role == "assistant" {
+			session.totalInputTokens += jsonInt(d, "tokens", "input") + jsonInt(d, "tokens", "cache", "read")
+			session.totalOutputTokens += jsonInt(d, "tokens", "output")
+			session.totalCacheRead += jsonInt(d, "tokens", "cache", "read")
+			session.totalCacheWrite += jsonInt(d, "tokens", "cache", "write")
+			session.totalCost += jsonFloat(d, "cost")
+		}
+		if role == "user" {
+			session.roundCount++
+			session.roundStartTime = jsonInt(d, "time", "created")
+			if storageTypedInput(root, jsonStr(d, "id")) {
+				session.lastUserInput = session.roundStartTime
+			}
+		}
+		if summary, _ := d["summary"].(bool); summary {
+			session.compactions++
+			session.lastCompaction = jsonInt(d, "time", "created")
+		}
+		rounds = append(rounds, roundMessage{
+			role:      role,
+			created:   jsonInt(d, "time", "created"),
+			completed: jsonInt(d, "time", "completed"),
+			tokensOut: jsonInt(d, "tokens", "output"),
+		})
+	}
+	session.toolUsage = storageToolUsage(root, sessionID, msgs)
+	session.toolCalls = totalToolCalls(session.toolUsage)
+	rounds = rounds[max(0, len(rounds)-100):]
+	session.avgLatencyMS, session.tokensPerSec, session.throughputRounds = roundThroughput(rounds)
+
+	if len(msgs) > 0 {
+		last := msgs[len(msgs)-1]
+		session.lastMessageRole = jsonStr(last, "role")
+		if session.lastMessageRole == "" {
+			session.lastMessageRole = "?"
+		}
+		if finish, ok := last["finish"].(string); ok {
+			session.lastFinish = &finish
+		}
+		session.model = cmp.Or(jsonStr(last, "modelID"), "?")
+		session.provider = jsonStr(last, "providerID")
+		// older builds called the agent "mode"
+		session.agent = cmp.Or(jsonStr(last, "agent"), jsonStr(last, "mode"), "?")
+		session.lastMessageTime = jsonInt(last, "time", "created")
+		if session.lastMessageRole == "assistant" {
+			errObj, _ := last["error"].(map[string]any)
+			errData, _ := errObj["data"].(map[string]any)
+			session.lastError = formatMessageError(jsonStr(errObj, "name"), jsonStr(errData, "message"))
+		}
+	}
+
+	// newest parts: streaming activity, pending tool, and last output,
+	// from the last few messages back
+	for i := len(msgs) - 1; i >= max(0, len(msgs)-5); i-- {
+		parts := storageParts(root, jsonStr(msgs[i], "id"))
+		for j := len(parts) - 1; j >= 0; j-- {
+			p := parts[j]
+			t := max(jsonInt(p, "time", "start"), jsonInt(p, "time", "end"))
+			session.lastPartTime = max(session.lastPartTime, t)
+			if jsonStr(p, "type") == "tool" && session.pendingTool == "" {
+				if state, _ := p["state"].(map[string]any); jsonStr(state, "status") == "running" {
+					session.pendingTool = jsonStr(p, "tool")
+				}
+			}
+			if jsonStr(p, "type") == "text" && jsonStr(msgs[i], "role") == "assistant" && session.lastOutputFull == "" {
+				text := strings.TrimSpace(jsonStr(p, "text"))
+				session.lastOutputFull = text
+				for _, line := range reverseLines(text) {
+					if line = strings.TrimSpace(line); line != "" {
+						session.lastOutput = line
+						break
+					}
+				}
+			}
+		}
+	}
+
+	var todos []struct {
+		Content  string `json:"content"`
+		Status   string `json:"status"`
+		Priority string `json:"priority"`
+	}
+	_ = readJSONFile(filepath.Join(root, "todo", sessionID+".json"), &todos)
+	for _, t := range todos {
+		session.activeTodos = append(session.activeTodos, todoItem{content: t.Content, status: t.Status, priority: t.Priority})
+	}
+
+	return session, nil
+}
+
+// storageTypedInput reports whether a user message has text that isn't
+// synthetic, i.e. the user typed it.
+func storageTypedInput(root, messageID string) bool {
+	for _, p := range storageParts(root, messageID) {
+		if synthetic, _ := p["synthetic"].(bool); jsonStr(p, "type") == "text" && !synthetic {
+			return true
+		}
+	}
+	return false
+}
+
+// storageStats sums stats over sessions updated after sinceMS (0 = all)
+// that pass the scope and watch filters, like the sql stats queries.
+func storageStats(root string, sinceMS int64) aggStats {
+	var stats aggStats
+	for _, s := range storageSessions(root) {
+		if s.Time.Updated <= sinceMS || !dirInScope(s.Directory) || !watchingDir(s.Directory) || !watchingSession(s.ID) {
+			continue
+		}
+		stats.sessionCount++
+		for _, d := range storageMessages(root, s.ID) {
+			stats.messageCount++
+			if jsonStr(d, "role") == "assistant" {
+				stats.totalInput += jsonInt(d, "tokens", "input") + jsonInt(d, "tokens", "cache", "read")
+				stats.totalOutput += jsonInt(d, "tokens", "output")
+			}
+		}
+	}
+	return stats
+}
+
+// storageTodayStats is queryTodayStatsFrom for the file layout.
+func storageTodayStats(root string) aggStats {
+	return storageStats(root, time.Now().Truncate(24*time.Hour).UnixMilli())
+}
+
+// getRecentMessagesFromStorage is getRecentMessagesFrom for the file
+// layout. returns messages oldest first.
+func getRecentMessagesFromStorage(root, sessionID string, limit, offset int) []messageDetail {
+	msgs := storageMessages(root, sessionID)
+	end := max(0, len(msgs)-offset)
+	msgs = msgs[max(0, end-limit):end]
+	var result []messageDetail
+	for _, d := range msgs {
+		msg := messageDetail{
+			role:        jsonStr(d, "role"),
+			finish:      jsonStr(d, "finish"),
+			model:       jsonStr(d, "modelID"),
+			tokensIn:    jsonInt(d, "tokens", "input"),
+			tokensOut:   jsonInt(d, "tokens", "output"),
+			cacheRead:   jsonInt(d, "tokens", "cache", "read"),
+			timeCreated: jsonInt(d, "time", "created"),
+		}
+		for _, p := range storageParts(root, jsonStr(d, "id")) {
+			if jsonStr(p, "type") == "text" {
+				text := jsonStr(p, "text")
+				if len(text) > 200 {
+					text = text[:200]
+				}
+				msg.textPreview = text
+				break
+			}
+		}
+		result = append(result, msg)
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+// `otop tail <session-id>`: follow a session's transcript like tail -f.
+// the part table is polled every second and each assistant text part
+// and tool call is printed once it has finished landing: text when its
+// end time is set, tools when they complete or fail. a text part that
+// never gets an end time (the stream was cut off) is printed anyway once
+// a newer message exists or it hasn't grown for tailTextTimeout, so it
+// doesn't hold back everything after it. `-n` sets how many
+// earlier entries to print first; --format jsonl prints one object per
+// entry for piping into other tools. sqlite databases only.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// tailInterval is how often the part table is polled.
+const tailInterval = time.Second
+
+// tailTextTimeout is how long a text part without an end time may go
+// without growing before it's printed as is.
+const tailTextTimeout = 30 * time.Second
+
+// tailEntry is one finished part, ready to print.
+type tailEntry struct {
+	ID     string `json:"id"`
+	Time   int64  `json:"time"`
+	Kind   string `json:"kind"` // "text" or "tool"
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status,omitempty"` // tool: completed or error
+	Text   string `json:"text"`
+}
+
+// tailCommand prints a session's last entries, then follows it until
+// interrupted.
+func tailCommand(sessionID, format string, last int, follow bool) error {
+	if format != "text" && format != "jsonl" {
+		return fmt.Errorf("unknown format %q (want text or jsonl)", format)
+	}
+	path, err := sessionDBPath(sessionID)
+	if err != nil {
+		return err
+	}
+
+	printed := make(map[string]bool)
+	emit := func(e tailEntry) {
+		printed[e.ID] = true
+		if format == "jsonl" {
+			_ = json.NewEncoder(os.Stdout).Encode(e)
+			return
+		}
+		fmt.Println(formatTailEntry(e))
+	}
+
+	entries, pendingFrom, err := queryTailEntries(path, sessionID, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries[max(0, len(entries)-last):] {
+		emit(e)
+	}
+	for _, e := range entries {
+		printed[e.ID] = true
+	}
+
+	for follow {
+		time.Sleep(tailInterval)
+		nextSnapshotCycle()
+		entries, next, err := queryTailEntries(path, sessionID, pendingFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			continue
+		}
+		for _, e := range entries {
+			if !printed[e.ID] {
+				emit(e)
+			}
+		}
+		pendingFrom = next
+	}
+	return nil
+}
+
+// sessionDBPath returns the path of the first database that has the
+// session.
+func sessionDBPath(sessionID string) (string, error) {
+	for _, src := range allDBSources() {
+		if _, ok := storageRoot(src.path); ok {
+			continue
+		}
+		db, err := openDBAt(src.path)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := queryContext()
+		_, found, err := readSessionStamp(ctx, db, sessionID)
+		cancel()
+		db.Close()
+		if err == nil && found {
+			return src.path, nil
+		}
+	}
+	return "", fmt.Errorf("session %s not found", sessionID)
+}
+
+// queryTailEntries reads the finished assistant text and tool parts
+// created at or after sinceMS, oldest first; unfinished text parts count
+// as finished once abandoned (tailTextTimeout). next is where the
+// following poll should start: the oldest part still in progress, else
+// the newest part seen.
+func queryTailEntries(path, sessionID string, sinceMS int64) (entries []tailEntry, next int64, err error) {
+	db, err := openDBAt(path)
+	if err != nil {
+		return nil, sinceMS, err
+	}
+	defer db.Close()
+
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.data, p.time_created, coalesce(p.time_updated, p.time_created),
+			EXISTS (SELECT 1 FROM message n WHERE n.session_id = m.session_id AND n.time_created > m.time_created)
+		FROM part p
+		JOIN message m ON m.id = p.message_id
+		WHERE p.session_id = ?
+		  AND p.time_created >= ?
+		  AND json_extract(m.data, '$.role') = 'assistant'
+		  AND json_extract(p.data, '$.type') IN ('text', 'tool')
+		ORDER BY p.time_created, p.id
+	`, sessionID, sinceMS)
+	if err != nil {
+		return nil, sinceMS, fmt.Errorf("tail: %w", err)
+	}
+	defer rows.Close()
+
+	next = sinceMS
+	pending := int64(-1)
+	for rows.Next() {
+		var id, dataStr string
+		var created, updated sql.NullInt64
+		var newer bool
+		if rows.Scan(&id, &dataStr, &created, &updated, &newer) != nil {
+			continue
+		}
+		next = max(next, created.Int64)
+		var d map[string]any
+		if json.Unmarshal([]byte(dataStr), &d) != nil {
+			continue
+		}
+		e, done := tailEntryFromPart(id, created.Int64, d)
+		if !done && e.Kind == "text" &&
+			(newer || time.Since(time.UnixMilli(updated.Int64)) > tailTextTimeout) {
+			e.Time = max(e.Time, updated.Int64)
+			done = true
+		}
+		if !done {
+			if pending < 0 {
+				pending = created.Int64
+			}
+			continue
+		}
+		if e.Text != "" || e.Kind == "tool" {
+			entries = append(entries, e)
+		}
+	}
+	if pending >= 0 {
+		next = pending
+	}
+	return entries, next, rows.Err()
+}
+
+// tailEntryFromPart builds the entry for a part. done is false while the
+// part is still being written.
+func tailEntryFromPart(id string, created int64, d map[string]any) (tailEntry, bool) {
+	e := tailEntry{ID: id, Time: created, Kind: jsonStr(d, "type")}
+	if e.Kind == "text" {
+		if synthetic, _ := d["synthetic"].(bool); synthetic {
+			return e, true
+		}
+		end := jsonInt(d, "time", "end")
+		e.Time = max(e.Time, end)
+		e.Text = strings.TrimSpace(jsonStr(d, "text"))
+		return e, end > 0
+	}
+
+	state, _ := d["state"].(map[string]any)
+	e.Tool = jsonStr(d, "tool")
+	e.Status = jsonStr(state, "status")
+	if e.Status != "completed" && e.Status != "error" {
+		return e, false
+	}
+	e.Time = max(e.Time, jsonInt(state, "time", "end"))
+	e.Text = jsonStr(state, "title")
+	if e.Status == "error" {
+		e.Text = jsonStr(state, "error")
+	}
+	return e, true
+}
+
+// formatTailEntry renders an entry for the text format.
+func formatTailEntry(e tailEntry) string {
+	ts := time.UnixMilli(e.Time).Format("15:04:05")
+	if e.Kind == "tool" {
+		line := fmt.Sprintf("%s  → %s", ts, e.Tool)
+		if e.Status == "error" {
+			line += " (error)"
+		}
+		if e.Text != "" {
+			line += ": " + strings.ReplaceAll(e.Text, "\n", " ")
+		}
+		return line
+	}
+	return ts + "  " + strings.ReplaceAll(e.Text, "\n", "\n          ")
+}
@@ -0,0 +1,354 @@
+// terminal emulator pane providers: wezterm, kitty, and iTerm2.
+//
+// fallbacks for sessions that aren't inside a multiplexer. each
+// terminal exposes a way to list panes, dump their text, and focus them:
+//   - wezterm: `wezterm cli list --format json` (keyed by tty_name),
+//     `wezterm cli get-text` / `activate-pane --pane-id N`
+//   - kitty: `kitty @ ls` (keyed by foreground process PID),
+//     `kitty @ get-text` / `focus-window --match id:N`.
+//     requires allow_remote_control.
+//   - iTerm2: AppleScript via osascript (keyed by session tty). only
+//     queried while iTerm2 is already running so otop never launches it,
+//     and only when some process is still without a pane. the session
+//     list is read once per refresh cycle; capture and focus only script
+//     ttys it has.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -- wezterm --
+
+type weztermProvider struct{}
+
+var weztermInstalled = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("wezterm")
+	return err == nil
+})
+
+// weztermPane is the subset of `wezterm cli list --format json` we use.
+type weztermPane struct {
+	PaneID    int    `json:"pane_id"`
+	TTYName   string `json:"tty_name"`
+	Title     string `json:"title"`
+	Workspace string `json:"workspace"`
+}
+
+func (weztermProvider) name() string    { return "wezterm" }
+func (weztermProvider) available() bool { return weztermInstalled() }
+
+// weztermPanes lists wezterm panes keyed by TTY name (e.g. "ttys005").
+func weztermPanes() map[string]weztermPane {
+	if !weztermInstalled() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "wezterm", "cli", "list", "--format", "json").Output()
+	if err != nil {
+		return nil
+	}
+	var panes []weztermPane
+	if json.Unmarshal(out, &panes) != nil {
+		return nil
+	}
+	result := make(map[string]weztermPane)
+	for _, p := range panes {
+		result[strings.TrimPrefix(p.TTYName, "/dev/")] = p
+	}
+	return result
+}
+
+func (weztermProvider) locate(procs []processInfo) map[int]paneLocation {
+	panes := weztermPanes()
+	if panes == nil {
+		return nil
+	}
+	result := make(map[int]paneLocation)
+	for _, proc := range procs {
+		if p, ok := panes[proc.tty]; ok {
+			result[proc.pid] = paneLocation{provider: "wezterm", session: p.Workspace, window: p.Title}
+		}
+	}
+	return result
+}
+
+func (weztermProvider) capture(proc processInfo) []string {
+	pane, ok := weztermPanes()[proc.tty]
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "wezterm", "cli", "get-text", "--pane-id", strconv.Itoa(pane.PaneID)).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(out), "\n")
+}
+
+func (weztermProvider) focus(proc processInfo) bool {
+	pane, ok := weztermPanes()[proc.tty]
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return exec.CommandContext(ctx, "wezterm", "cli", "activate-pane", "--pane-id", strconv.Itoa(pane.PaneID)).Run() == nil
+}
+
+// -- kitty --
+
+type kittyProvider struct{}
+
+var kittyInstalled = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("kitty")
+	return err == nil
+})
+
+// kittyWindow is a kitty window (pane) flattened out of `kitty @ ls`.
+type kittyWindow struct {
+	id       int
+	osWindow int
+	tabTitle string
+	title    string
+}
+
+func (kittyProvider) name() string    { return "kitty" }
+func (kittyProvider) available() bool { return kittyInstalled() }
+
+// kittyWindows maps foreground process PIDs to kitty windows.
+// kitty doesn't report TTYs, but it does list each window's
+// foreground processes, which includes opencode itself.
+func kittyWindows() map[int]kittyWindow {
+	if !kittyInstalled() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "kitty", "@", "ls").Output()
+	if err != nil {
+		return nil
+	}
+
+	var osWindows []struct {
+		ID   int `json:"id"`
+		Tabs []struct {
+			Title   string `json:"title"`
+			Windows []struct {
+				ID                  int    `json:"id"`
+				Title               string `json:"title"`
+				ForegroundProcesses []struct {
+					PID int `json:"pid"`
+				} `json:"foreground_processes"`
+			} `json:"windows"`
+		} `json:"tabs"`
+	}
+	if json.Unmarshal(out, &osWindows) != nil {
+		return nil
+	}
+
+	result := make(map[int]kittyWindow)
+	for _, osw := range osWindows {
+		for _, tab := range osw.Tabs {
+			for _, w := range tab.Windows {
+				for _, fg := range w.ForegroundProcesses {
+					result[fg.PID] = kittyWindow{id: w.ID, osWindow: osw.ID, tabTitle: tab.Title, title: w.Title}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (kittyProvider) locate(procs []processInfo) map[int]paneLocation {
+	windows := kittyWindows()
+	if windows == nil {
+		return nil
+	}
+	result := make(map[int]paneLocation)
+	for _, proc := range procs {
+		if w, ok := windows[proc.pid]; ok {
+			result[proc.pid] = paneLocation{provider: "kitty", session: w.tabTitle, window: w.title}
+		}
+	}
+	return result
+}
+
+func (kittyProvider) capture(proc processInfo) []string {
+	w, ok := kittyWindows()[proc.pid]
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "kitty", "@", "get-text", "--match", "id:"+strconv.Itoa(w.id)).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(out), "\n")
+}
+
+func (kittyProvider) focus(proc processInfo) bool {
+	w, ok := kittyWindows()[proc.pid]
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return exec.CommandContext(ctx, "kitty", "@", "focus-window", "--match", "id:"+strconv.Itoa(w.id)).Run() == nil
+}
+
+// -- iTerm2 --
+
+type itermProvider struct{}
+
+var itermInstalled = sync.OnceValue(func() bool {
+	if runtime.GOOS != "darwin" {
+		return false
+	}
+	if _, err := exec.LookPath("osascript"); err != nil {
+		return false
+	}
+	_, err := os.Stat("/Applications/iTerm.app")
+	return err == nil
+})
+
+func (itermProvider) name() string    { return "iterm" }
+func (itermProvider) available() bool { return itermInstalled() }
+
+// itermScript runs an AppleScript body against the iTerm2 session whose
+// tty matches, guarded so a closed iTerm2 isn't launched. inside body,
+// s/t/w are the matching session, tab, and window.
+func itermScript(tty, body string) (string, bool) {
+	script := `if application "iTerm2" is running then
+	tell application "iTerm2"
+		repeat with w in windows
+			repeat with t in tabs of w
+				repeat with s in sessions of t
+					if tty of s is "/dev/` + tty + `" then
+						` + body + `
+					end if
+				end repeat
+			end repeat
+		end repeat
+	end tell
+end if
+return ""`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "osascript", "-e", script).Output()
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
+
+var (
+	itermSessionsMu    sync.Mutex
+	itermSessionsCycle = int64(-1) // refresh cycle itermSessionsCache was listed in
+	itermSessionsCache map[string]string
+)
+
+// itermSessions maps TTY names to iTerm2 session names, listed with one
+// osascript call per refresh cycle like tmuxPanes. nil if iTerm2 isn't
+// running or the script fails.
+func itermSessions() map[string]string {
+	itermSessionsMu.Lock()
+	defer itermSessionsMu.Unlock()
+	if cycle := snapshotCycle.Load(); cycle != itermSessionsCycle {
+		itermSessionsCache = listItermSessions()
+		itermSessionsCycle = cycle
+	}
+	return itermSessionsCache
+}
+
+// listItermSessions runs the osascript behind itermSessions.
+func listItermSessions() map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "osascript", "-e", `if application "iTerm2" is running then
+	tell application "iTerm2"
+		set out to ""
+		repeat with w in windows
+			repeat with t in tabs of w
+				repeat with s in sessions of t
+					set out to out & (tty of s) & tab & (name of s) & linefeed
+				end repeat
+			end repeat
+		end repeat
+		return out
+	end tell
+end if
+return ""`).Output()
+	if err != nil {
+		return nil
+	}
+
+	names := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		tty, name, ok := strings.Cut(line, "\t")
+		if ok {
+			names[strings.TrimPrefix(tty, "/dev/")] = name
+		}
+	}
+	return names
+}
+
+func (itermProvider) locate(procs []processInfo) map[int]paneLocation {
+	// earlier providers have already claimed their processes
+	if !slices.ContainsFunc(procs, func(p processInfo) bool { return p.tmuxSession == "" }) {
+		return nil
+	}
+	names := itermSessions()
+	result := make(map[int]paneLocation)
+	for _, proc := range procs {
+		if name, ok := names[proc.tty]; ok {
+			result[proc.pid] = paneLocation{provider: "iterm", session: "iTerm2", window: name}
+		}
+	}
+	return result
+}
+
+func (itermProvider) capture(proc processInfo) []string {
+	if _, ok := itermSessions()[proc.tty]; !ok {
+		return nil
+	}
+	out, ok := itermScript(proc.tty, "return contents of s")
+	if !ok || out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+func (itermProvider) focus(proc processInfo) bool {
+	if _, ok := itermSessions()[proc.tty]; !ok {
+		return false
+	}
+	out, ok := itermScript(proc.tty, `tell w to select
+						tell t to select
+						tell s to select
+						activate
+						return "ok"`)
+	return ok && strings.TrimSpace(out) == "ok"
+}
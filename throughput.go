@@ -0,0 +1,67 @@
+// response latency and generation speed over recent rounds.
+//
+// a round is a user message and the assistant messages that answer it
+// (several when tools are involved). latency is user message to the last
+// assistant message completing; speed is the round's output tokens over
+// the time the assistant messages spent generating. rounds still in
+// progress are skipped.
+
+package main
+
+// throughputRounds is how many recent complete rounds are averaged.
+const throughputRounds = 5
+
+// roundMessage is the slice of a message row the averages need.
+type roundMessage struct {
+	role      string
+	created   int64
+	completed int64 // 0 while streaming
+	tokensOut int64
+}
+
+// roundThroughput averages the last throughputRounds complete rounds of
+// msgs (oldest first). returns zeros when no round has completed.
+func roundThroughput(msgs []roundMessage) (avgLatencyMS int64, tokensPerSec float64, rounds int) {
+	type round struct {
+		start, end, genMS, out int64
+		open                   bool
+	}
+	var all []round
+	for _, msg := range msgs {
+		switch msg.role {
+		case "user":
+			all = append(all, round{start: msg.created})
+		case "assistant":
+			if len(all) == 0 {
+				continue // history starts mid-round
+			}
+			r := &all[len(all)-1]
+			if msg.completed == 0 {
+				r.open = true
+				continue
+			}
+			r.end = max(r.end, msg.completed)
+			r.genMS += msg.completed - msg.created
+			r.out += msg.tokensOut
+		}
+	}
+
+	var latency, genMS, out int64
+	for i := len(all) - 1; i >= 0 && rounds < throughputRounds; i-- {
+		r := all[i]
+		if r.open || r.end == 0 {
+			continue
+		}
+		latency += r.end - r.start
+		genMS += r.genMS
+		out += r.out
+		rounds++
+	}
+	if rounds == 0 {
+		return 0, 0, 0
+	}
+	if genMS > 0 {
+		tokensPerSec = float64(out) / (float64(genMS) / 1000)
+	}
+	return latency / int64(rounds), tokensPerSec, rounds
+}
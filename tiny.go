@@ -0,0 +1,115 @@
+// narrow-terminal fallbacks.
+//
+// below tinyWidth columns the normal layout can't fit its fixed
+// columns, so the list collapses to one glyph + title + round time per
+// row. below minWidth x minHeight nothing useful fits and a "too small"
+// notice is shown instead.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	tinyWidth = 60 // narrower than this uses the tiny list
+	minWidth  = 20 // narrower than this (or shorter than minHeight) shows "too small"
+	minHeight = 3
+)
+
+// statusGlyphs are single-cell stand-ins for status names.
+var statusGlyphs = map[string]string{
+	"generating": "●",
+	"tool use":   "●",
+	"busy":       "●",
+	"thinking":   "◐",
+	"queued":     "◐",
+	"asking":     "?",
+	"idle":       "○",
+	"truncated":  "!",
+	"stuck":      "!",
+	"error":      "✗",
+	"stale":      "·",
+	"unknown":    "-",
+	"no-session": "-",
+}
+
+// tiny reports whether the list should use the narrow layout.
+// a zero width means no size message has arrived yet.
+func (m model) tiny() bool {
+	return m.width > 0 && m.width < tinyWidth
+}
+
+// tooSmall reports whether the terminal can't fit any layout.
+func (m model) tooSmall() bool {
+	return m.width > 0 && (m.width < minWidth || m.height < minHeight)
+}
+
+func (m model) renderTooSmall() string {
+	msg := fmt.Sprintf("too small %dx%d", m.width, m.height)
+	if len(msg) > m.width {
+		msg = "small"
+	}
+	return transStyle.Render(truncOrPad(msg, m.width))
+}
+
+// renderTinyView lists sessions as "<glyph> <title> <round>" rows.
+func (m model) renderTinyView() string {
+	if !m.ready {
+		return "loading..."
+	}
+	var b strings.Builder
+
+	visible := m.getVisibleSessions()
+	pageSize := max(1, m.height-m.listOverhead())
+	end := min(m.scrollOffset+pageSize, len(visible))
+	nowMS := time.Now().UnixMilli()
+
+	for i := m.scrollOffset; i < end; i++ {
+		cs := visible[i]
+		status, title, round := "unknown", cs.process.cmdline, ""
+		if cs.session != nil {
+			status = inferStatus(cs.session, cs.process.cpuPercent)
+			title = sessionTitle(cs.session)
+			if cs.session.roundStartTime > 0 {
+				round = formatDuration(nowMS - cs.session.roundStartTime)
+			}
+		}
+		glyph := statusGlyph(status)
+
+		titleW := max(1, m.width-2-len(round)-1)
+		row := " " + truncOrPad(title, titleW) + " " + round
+		if m.selectMode && i == m.cursor {
+			b.WriteString(selectStyle.Render(glyph + row))
+		} else {
+			b.WriteString(statusStyleFor(status).Render(glyph) + row)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.renderTinyFooter(len(visible)))
+	return b.String()
+}
+
+// renderTinyFooter shows a flash or prompt if one is up, else a count.
+func (m model) renderTinyFooter(count int) string {
+	if m.promptActive {
+		return m.renderPromptBar()
+	}
+	if m.spawnActive {
+		return m.renderSpawnBar()
+	}
+	if m.filterActive {
+		return headerStyle.Render(truncOrPad(" /"+m.filterText, m.width))
+	}
+	if m.flashMsg != "" && time.Since(m.flashTime) < 1500*time.Millisecond {
+		return activeStyle.Bold(true).Render(truncOrPad(" "+m.flashMsg, m.width))
+	}
+	footer := fmt.Sprintf(" %d sessions", count)
+	if m.paused {
+		footer += " · paused"
+	}
+	return dimStyle.Render(truncOrPad(footer, m.width))
+}
@@ -0,0 +1,89 @@
+// todo progress: the t panel scrolls (J/K) and can hide finished items
+// (T), and the one-line TODO column shows done/total so agent progress
+// is visible without opening the panel.
+
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+const todoPanelRows = 6 // items shown at once; the panel is 8 lines with the rule and header
+
+// todoProgress counts completed todos against all that weren't cancelled.
+func todoProgress(todos []todoItem) (done, total int) {
+	for _, t := range todos {
+		switch t.status {
+		case "completed":
+			done++
+			total++
+		case "cancelled":
+		default:
+			total++
+		}
+	}
+	return done, total
+}
+
+// todoSummary counts todos by status and picks out the one in progress,
+// for the JSON outputs (`otop sessions`, /sessions).
+func todoSummary(todos []todoItem) map[string]any {
+	counts := map[string]int{"pending": 0, "in_progress": 0, "completed": 0, "cancelled": 0}
+	current := ""
+	for _, t := range todos {
+		counts[t.status]++
+		if t.status == "in_progress" && current == "" {
+			current = t.content
+		}
+	}
+	return map[string]any{
+		"pending":     counts["pending"],
+		"in_progress": counts["in_progress"],
+		"completed":   counts["completed"],
+		"cancelled":   counts["cancelled"],
+		"total":       len(todos),
+		"current":     current,
+	}
+}
+
+// progressBar renders done/total as a bar width cells wide.
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(width, done*width/total)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// panelTodos returns the session's todos the panel lists.
+func (m model) panelTodos(s *sessionInfo) []todoItem {
+	if !m.hideDoneTodos {
+		return s.activeTodos
+	}
+	return slices.DeleteFunc(slices.Clone(s.activeTodos), func(t todoItem) bool {
+		return t.status == "completed" || t.status == "cancelled"
+	})
+}
+
+// todoOffset returns the panel's first visible item for the session,
+// clamped to n items. scrolling is kept per session so moving the
+// cursor elsewhere starts the next list at the top.
+func (m model) todoOffset(sessionID string, n int) int {
+	if sessionID != m.todoScrollSID {
+		return 0
+	}
+	return max(0, min(m.todoScroll, n-todoPanelRows))
+}
+
+// scrollTodos moves the todo panel of the selected session by delta.
+func (m *model) scrollTodos(delta int) {
+	cs, ok := m.selectedSession()
+	if !ok || cs.session == nil {
+		return
+	}
+	n := len(m.panelTodos(cs.session))
+	offset := m.todoOffset(cs.session.sessionID, n)
+	m.todoScrollSID = cs.session.sessionID
+	m.todoScroll = max(0, min(offset+delta, n-todoPanelRows))
+}
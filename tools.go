@@ -0,0 +1,183 @@
+// tool-call statistics. a session's tool parts, counted by tool name,
+// say how hands-on the agent has been: a long chat with few calls is
+// mostly talk, hundreds of bash and edit calls mean it's been busy in
+// the tree. the total goes in the TOOLS column and the busiest tools
+// in the detail view's info bar and metadata block.
+//
+// counts only grow, so each session's are kept between fetches along
+// with how far they got (the newest part counted, or in the file layout
+// the finished messages counted), and a fetch only counts what came
+// after. a revert deletes messages; a drop in the message count starts
+// the session's counts over.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// toolCount is how many times a session called one tool.
+type toolCount struct {
+	tool  string
+	count int
+}
+
+// toolCountsEntry is a session's cached tool counts.
+type toolCountsEntry struct {
+	counts   map[string]int
+	since    int64  // db: newest part.time_created counted
+	through  int    // file layout: leading messages counted, all finished
+	lastID   string // file layout: id of the last of those
+	messages int    // message count when cached
+}
+
+var (
+	toolCountsMu    sync.Mutex
+	toolCountsCache = make(map[sessionCacheKey]toolCountsEntry)
+)
+
+// cachedToolCounts returns the session's cached counts, or an empty
+// entry if there are none or the session lost messages since.
+func cachedToolCounts(key sessionCacheKey, messages int) toolCountsEntry {
+	toolCountsMu.Lock()
+	defer toolCountsMu.Unlock()
+	entry, ok := toolCountsCache[key]
+	if !ok || messages < entry.messages {
+		return toolCountsEntry{counts: make(map[string]int)}
+	}
+	entry.counts = maps.Clone(entry.counts)
+	return entry
+}
+
+// cacheToolCounts stores a session's counts, starting the cache over
+// past sessionCacheLimit like the session cache.
+func cacheToolCounts(key sessionCacheKey, entry toolCountsEntry) {
+	toolCountsMu.Lock()
+	defer toolCountsMu.Unlock()
+	if len(toolCountsCache) >= sessionCacheLimit {
+		clear(toolCountsCache)
+	}
+	toolCountsCache[key] = entry
+}
+
+// queryToolUsage counts a session's tool calls by tool, busiest first,
+// reading only the parts created since the cached counts.
+func queryToolUsage(ctx context.Context, db *sql.DB, path, sessionID string, messages int) ([]toolCount, error) {
+	key := sessionCacheKey{path, sessionID}
+	entry := cachedToolCounts(key, messages)
+	rows, err := db.QueryContext(ctx, `
+		SELECT coalesce(json_extract(data, '$.tool'), '?'), count(*), max(time_created)
+		FROM part
+		WHERE session_id = ?
+		  AND time_created > ?
+		  AND json_extract(data, '$.type') = 'tool'
+		GROUP BY 1
+	`, sessionID, entry.since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	since := entry.since
+	for rows.Next() {
+		var (
+			tool    string
+			n       int
+			created int64
+		)
+		if err := rows.Scan(&tool, &n, &created); err != nil {
+			return nil, err
+		}
+		entry.counts[tool] += n
+		since = max(since, created)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	entry.since, entry.messages = since, messages
+	cacheToolCounts(key, entry)
+	return sortToolUsage(entry.counts), nil
+}
+
+// storageToolUsage is queryToolUsage for the file layout. parts have no
+// creation time there, but a finished message gets no new ones, so the
+// leading run of finished messages is cached and only the messages
+// after it have their parts read.
+func storageToolUsage(root, sessionID string, msgs []map[string]any) []toolCount {
+	key := sessionCacheKey{root, sessionID}
+	entry := cachedToolCounts(key, len(msgs))
+	if entry.through > len(msgs) || (entry.through > 0 && jsonStr(msgs[entry.through-1], "id") != entry.lastID) {
+		entry = toolCountsEntry{counts: make(map[string]int)}
+	}
+	pending := make(map[string]int)
+	for i := entry.through; i < len(msgs); i++ {
+		d := msgs[i]
+		finished := true
+		counts := entry.counts
+		if jsonStr(d, "role") == "assistant" {
+			finished = jsonInt(d, "time", "completed") > 0
+			if !finished || entry.through < i {
+				counts = pending
+			}
+			for _, p := range storageParts(root, jsonStr(d, "id")) {
+				if jsonStr(p, "type") == "tool" {
+					counts[cmp.Or(jsonStr(p, "tool"), "?")]++
+				}
+			}
+		}
+		if finished && entry.through == i {
+			entry.through, entry.lastID = i+1, jsonStr(d, "id")
+		}
+	}
+	entry.messages = len(msgs)
+	cacheToolCounts(key, entry)
+
+	total := maps.Clone(entry.counts)
+	for tool, n := range pending {
+		total[tool] += n
+	}
+	return sortToolUsage(total)
+}
+
+// sortToolUsage turns per-tool counts into toolCounts, busiest first.
+func sortToolUsage(counts map[string]int) []toolCount {
+	var usage []toolCount
+	for tool, n := range counts {
+		usage = append(usage, toolCount{tool, n})
+	}
+	slices.SortFunc(usage, func(a, b toolCount) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.tool, b.tool))
+	})
+	return usage
+}
+
+// totalToolCalls sums the per-tool counts.
+func totalToolCalls(usage []toolCount) int {
+	n := 0
+	for _, tc := range usage {
+		n += tc.count
+	}
+	return n
+}
+
+// toolSummary lists the top n tools, e.g. "bash: 42, edit: 18", with
+// the rest lumped together. "" if the session called none.
+func toolSummary(s *sessionInfo, n int) string {
+	if len(s.toolUsage) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, tc := range s.toolUsage[:min(n, len(s.toolUsage))] {
+		parts = append(parts, fmt.Sprintf("%s: %d", tc.tool, tc.count))
+	}
+	if rest := len(s.toolUsage) - n; rest > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more", rest))
+	}
+	return strings.Join(parts, ", ")
+}
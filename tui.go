@@ -7,7 +7,9 @@
 package main
 
 import (
+	"errors"
 	"os/exec"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -21,8 +23,12 @@ type dataMsg fetchResult
 type tickMsg time.Time
 
 type detailRefreshMsg struct {
-	lines  []string
-	source string
+	sessionID string // session the refresh was for; stale ones are dropped
+	pid       int
+	lines     []string
+	source    string
+	logErr    string // newest ERROR line in the process's log
+	edit      *editPreview
 }
 
 type detailToggleMsg struct {
@@ -30,8 +36,45 @@ type detailToggleMsg struct {
 	source string
 }
 
+// detailOlderMsg carries an older page of db history for the detail view.
+type detailOlderMsg struct {
+	sessionID string
+	messages  []messageDetail
+}
+
+// detailDBPage is how many db messages the detail view fetches at a time.
+const detailDBPage = 30
+
 type tickerTickMsg struct{}
 
+// wallMsg carries one capture per pid for the wall grid.
+type wallMsg map[int][]string
+
+// apiResultMsg reports the outcome of an opencode API action.
+type apiResultMsg struct {
+	action    string
+	sessionID string
+	err       error
+}
+
+// pairCodeMsg carries a pairing code issued for the Q screen.
+type pairCodeMsg struct {
+	code string
+	err  error
+}
+
+// focusResultMsg reports which provider focused the pane ("" = none).
+type focusResultMsg struct {
+	sessionID string
+	provider  string
+}
+
+type previewMsg struct {
+	pid    int
+	lines  []string
+	source string
+}
+
 // -- model --
 
 type model struct {
@@ -40,39 +83,156 @@ type model struct {
 	height int
 
 	// data from last fetch
-	sessions    []correlatedSession
-	todayStats  aggStats
-	globalStats aggStats
-	mcpConfig   map[string]any
+	sessions     []correlatedSession
+	todayStats   aggStats
+	globalStats  aggStats
+	mcpConfig    map[string]any
+	mcpHealth    []mcpServerHealth // probed while the m panel is open (mcp.go)
+	mcpHealthAt  time.Time
+	mcpHealthDir string // directory the probe covered; "" = global
 
 	// list view state
-	cursor           int
-	scrollOffset     int
-	sortColIdx       int
-	sortReverse      bool
-	filterText       string
-	filterActive     bool
+	cursor       int
+	scrollOffset int
+	sortColIdx   int
+	sortThen     []string // tie-breaking sort keys after the column's (+ cycles the first)
+	sortReverse  bool
+	filterText   string
+	filterActive bool
+	presetName   string // filter preset the filter text came from (filter.go)
+	pendingG     bool   // first g of gg seen
+
+	// new session prompt (n): typed directory, candidates from the db
+	spawnActive bool
+	spawnText   string
+	spawnDirs   []string
+	spawnCursor int
+
+	// prompt box (:): message being typed for promptTarget. promptKind
+	// "alias" or "note" means it's editing that instead (R, N)
+	promptActive     bool
+	promptKind       string
+	promptText       string
+	promptTarget     correlatedSession
 	showAllProcesses bool
 	showAllSessions  bool
 	showTodos        bool
+	hideDoneTodos    bool   // T: leave completed/cancelled items out of the todo panel
+	todoScroll       int    // J/K offset into the todo panel
+	todoScrollSID    string // session todoScroll applies to
 	showMCPs         bool
+	showHeatmap      bool
+	heatmap          [heatmapDays][24]int
+	heatmapAt        time.Time
+	showUsage        bool
+	usage            []periodStats // today, this week, all time
+	usageAt          time.Time
+	showModelUsage   bool
+	budgetSpend      [2]float64     // today, this week
+	budgetLevels     [2]budgetLevel // as of the last load, for crossing notices
+	budgetAt         time.Time
+	budgetLoaded     bool
+	modelUsage       []modelUsage // today, most expensive first
+	modelUsageAt     time.Time
 
 	// detail view state
 	detailMode    bool
 	detailScroll  int
 	detailLines   []string
 	detailSession *correlatedSession
-	detailSource  string // "tmux" or "db"
+	detailSource  string // pane provider name ("tmux", "zellij") or "db"
+	detailInvoc   invocation
+	detailLogErr  string
+	detailEdit    *editPreview    // newest tool call, when it edited a file (editpreview.go)
+	detailProcs   bool            // P: child process breakdown under the info bar
+	detailMeta    bool            // I: session metadata block under the info bar
+	detailOlder   []messageDetail // db history before the newest page, loaded with O
+
+	// preview split state (v): bottom half shows the selected
+	// session's pane capture (or db messages) without entering detail mode
+	previewMode   bool
+	previewPID    int
+	previewLines  []string
+	previewSource string
+
+	// wall mode (w): grid of live pane captures for all visible sessions
+	wallMode     bool
+	wallCaptures map[int][]string
+
+	// column picker state (C). edits display.columns and
+	// oneLineColumnOrder in place so changes apply immediately.
+	pickerMode   bool
+	pickerCursor int
 
 	// view vs select mode
 	// view mode: no cursor highlight, just watching
 	// select mode: cursor visible, nav/enter/yank work
 	selectMode bool
 
+	// inboxMode lists only sessions waiting on a reply (inbox.go)
+	inboxMode bool
+
+	// showArchived expands the rows folded into the stale row (archive.go)
+	showArchived bool
+
+	// groupTmux groups the list by tmux session under headers (groups.go)
+	groupTmux bool
+
+	// paused freezes fetching so the list stops reshuffling while reading.
+	// any key that forces a refresh also resumes.
+	paused bool
+
+	// status-change notices: last known status per session ID, and the
+	// transitions detected from it (see notify.go)
+	prevStatus map[string]string
+	notices    []statusNotice
+
+	// lastMessageTime per session ID as of its last detail view (seen.go)
+	seen map[string]int64
+
+	// newest opencode version running, per host (version.go)
+	newestVersions map[string]string
+
+	// processes sharing a session, keyed by host/sessionID (ambiguous.go)
+	ambiguous map[string][]processInfo
+
 	// flash message (e.g. after yank)
 	flashMsg  string
 	flashTime time.Time
 
+	// fetch debouncing: a slow collection (stalled lsof, locked db) must
+	// not let ticks stack more collections behind it. while one is in
+	// flight, further requests set fetchQueued and run once it lands.
+	fetching     bool
+	fetchQueued  bool
+	lastFetchDur time.Duration
+	lastTimings  []stepTiming
+
+	// debug overlay (D): last fetch's timings and correlation tiers
+	debugMode bool
+
+	// pairing screen (Q): a one-time code and its QR (pair.go)
+	pairMode bool
+	pairCode string
+
+	// error from the last fetch's db queries; shown as a banner so blank
+	// rows aren't mistaken for idle sessions
+	dbErr     error
+	schemaErr error // unsupported opencode schema (schema.go)
+
+	// PIDs of other otop TUIs running at startup (see instance.go)
+	otherInstances []int
+
+	// one-shot render to stdout (--once): no footer
+	once bool
+
+	// the one-line ticker loop is scheduled; it stops itself when the
+	// layout switches to two-line (L)
+	tickerRunning bool
+
+	// recorded trace driving the TUI instead of collection (--replay)
+	replay []recordedFrame
+
 	ready bool
 }
 
@@ -86,13 +246,19 @@ func newModel() model {
 	}
 	return model{
 		sortColIdx:  sortIdx,
+		sortThen:    display.defaultSortThen,
 		sortReverse: display.defaultSortReverse,
+		fetching:    true, // Init starts the first fetch
+		seen:        make(map[string]int64),
 	}
 }
 
 func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{fetchCmd, tickCmd()}
-	if display.oneLine && display.ticker.rateMS > 0 {
+	if m.replay != nil {
+		cmds[0] = m.replayCmd(0)
+	}
+	if m.tickerRunning {
 		cmds = append(cmds, tickerTickCmd())
 	}
 	return tea.Batch(cmds...)
@@ -101,9 +267,40 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.promptActive {
+			return m.handlePromptKey(msg)
+		}
+		if m.spawnActive {
+			return m.handleSpawnKey(msg)
+		}
 		if m.detailMode {
 			return m.handleDetailKey(msg)
 		}
+		if m.pickerMode {
+			return m.handlePickerKey(msg)
+		}
+		if m.debugMode {
+			switch msg.String() {
+			case "esc", "D", "q":
+				m.debugMode = false
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.pairMode {
+			switch msg.String() {
+			case "esc", "Q", "q":
+				m.pairMode = false
+				m.pairCode = ""
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.wallMode {
+			return m.handleWallKey(msg)
+		}
 		if m.filterActive {
 			return m.handleFilterKey(msg)
 		}
@@ -114,22 +311,52 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case dataMsg:
 		return m.handleData(fetchResult(msg))
+	case replayMsg:
+		updated, cmd := m.handleData(m.replay[msg].result())
+		return updated, tea.Batch(cmd, updated.(model).replayCmd(int(msg)+1))
 	case tickMsg:
 		var cmds []tea.Cmd
 		cmds = append(cmds, tickCmd())
-		if m.detailMode && m.detailSource == "tmux" {
+		if m.detailMode && m.detailSource != "db" {
 			cmds = append(cmds, m.refreshDetailCmd())
 		}
-		if !m.detailMode {
-			cmds = append(cmds, fetchCmd)
+		if !m.detailMode && !m.paused {
+			cmds = append(cmds, m.requestFetch())
+		}
+		if m.previewMode && !m.detailMode {
+			cmds = append(cmds, m.previewCmd())
+		}
+		if m.wallMode {
+			cmds = append(cmds, m.wallCmd())
 		}
 		return m, tea.Batch(cmds...)
 	case detailRefreshMsg:
+		if m.detailSession == nil || m.detailSession.process.pid != msg.pid || detailSessionID(m.detailSession) != msg.sessionID {
+			return m, nil
+		}
+		m.detailLogErr = msg.logErr
+		m.detailEdit = msg.edit
 		m.detailLines = msg.lines
 		if msg.source != "" {
 			m.detailSource = msg.source
 		}
 		return m, nil
+	case detailOlderMsg:
+		if m.detailSession == nil || m.detailSession.session == nil || m.detailSession.session.sessionID != msg.sessionID {
+			return m, nil
+		}
+		if len(msg.messages) == 0 {
+			m.flashMsg = "no older messages"
+			m.flashTime = time.Now()
+			return m, nil
+		}
+		m.detailOlder = append(msg.messages, m.detailOlder...)
+		if m.detailSource == "db" {
+			older := formatDBMessages(msg.messages)
+			m.detailLines = append(older, m.detailLines...)
+			m.detailScroll += len(older) // keep the same lines on screen
+		}
+		return m, nil
 	case detailToggleMsg:
 		if len(msg.lines) > 0 {
 			m.detailLines = msg.lines
@@ -138,34 +365,309 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case tickerTickMsg:
+		if !display.oneLine {
+			m.tickerRunning = false // L restarts it
+			return m, nil
+		}
 		return m, tickerTickCmd()
+	case wallMsg:
+		m.wallCaptures = msg
+		return m, nil
+	case editorResultMsg:
+		if msg.err != nil {
+			m.flashMsg = "editor: " + msg.err.Error()
+		} else {
+			m.flashMsg = "opened " + msg.dir
+		}
+		m.flashTime = time.Now()
+		return m, nil
+	case heatmapMsg:
+		m.heatmap = msg.counts
+		m.heatmapAt = time.Now()
+		return m, nil
+	case mcpHealthMsg:
+		if dir, _ := m.mcpScope(); dir == msg.dir {
+			m.mcpHealth = msg.servers
+		}
+		return m, nil
+	case usageMsg:
+		m.usage = msg.rows
+		m.usageAt = time.Now()
+		return m, nil
+	case budgetMsg:
+		if msg.err != nil && msg.today == 0 && msg.week == 0 {
+			return m, nil // keep the last spend rather than show zero
+		}
+		m.budgetSpend = [2]float64{msg.today, msg.week}
+		levels := [2]budgetLevel{
+			levelFor(msg.today, display.budget.daily),
+			levelFor(msg.week, display.budget.weekly),
+		}
+		if fresh := budgetNotices(m.budgetLevels, levels, m.budgetLoaded); len(fresh) > 0 {
+			m.notices = append(m.liveNotices(), fresh...)
+			bus.publish(budgetEvent{notices: fresh})
+		}
+		m.budgetLevels = levels
+		m.budgetLoaded = true
+		return m, nil
+	case modelUsageMsg:
+		m.modelUsage = msg.rows
+		m.modelUsageAt = time.Now()
+		m.adjustScroll() // the panel's height follows the model count
+		return m, nil
+	case spawnDirsMsg:
+		m.spawnDirs = msg
+		return m, nil
+	case spawnedMsg:
+		publishAction("tui", "spawn", "", msg.err)
+		if msg.err != nil {
+			m.flashMsg = "spawn: " + msg.err.Error()
+			m.flashTime = time.Now()
+			return m, nil
+		}
+		m.flashMsg = "started opencode in " + shortPath(msg.dir, 30)
+		m.flashTime = time.Now()
+		return m, spawnRefreshCmd()
+	case spawnRefreshMsg:
+		cmd := m.requestFetch()
+		return m, cmd
+	case promptSentMsg:
+		publishAction("tui", "prompt", msg.sessionID, msg.err)
+		if msg.err != nil {
+			m.flashMsg = "prompt: " + msg.err.Error()
+		} else {
+			m.flashMsg = "sent to " + msg.title + " via " + msg.via
+		}
+		m.flashTime = time.Now()
+		return m, nil
+	case apiResultMsg:
+		publishAction("tui", msg.action, msg.sessionID, msg.err)
+		if msg.err != nil {
+			m.flashMsg = msg.action + ": " + msg.err.Error()
+		} else {
+			m.flashMsg = msg.action + " sent"
+		}
+		m.flashTime = time.Now()
+		return m, nil
+	case focusResultMsg:
+		if msg.provider == "" {
+			publishAction("tui", "jump", msg.sessionID, errors.New("no pane to jump to"))
+			m.flashMsg = "no pane to jump to"
+		} else {
+			publishAction("tui", "jump", msg.sessionID, nil)
+			m.flashMsg = "jumped via " + msg.provider
+		}
+		m.flashTime = time.Now()
+		return m, nil
+	case pairCodeMsg:
+		if msg.err != nil {
+			m.pairMode = false
+			m.flashMsg = "pair: " + msg.err.Error()
+			m.flashTime = time.Now()
+			return m, nil
+		}
+		m.pairCode = msg.code
+		return m, nil
+	case previewMsg:
+		m.previewPID = msg.pid
+		m.previewLines = msg.lines
+		m.previewSource = msg.source
+		return m, nil
 	}
 	return m, nil
 }
 
 func (m model) View() string {
+	if m.tooSmall() {
+		return m.renderTooSmall()
+	}
 	if m.detailMode {
 		return m.renderDetailView()
 	}
+	if m.pickerMode {
+		return m.renderColumnPicker()
+	}
+	if m.debugMode {
+		return m.renderDebugView()
+	}
+	if m.pairMode {
+		return m.renderPairView()
+	}
+	if m.wallMode {
+		return m.renderWallView()
+	}
+	if m.tiny() {
+		return m.renderTinyView()
+	}
 	return m.renderListView()
 }
 
 // -- key handlers --
 
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
+	afterG := m.pendingG
+	m.pendingG = false
+
+	switch key := msg.String(); key {
 	case "q", "ctrl+c":
 		return m, tea.Quit
 	case "r":
-		return m, fetchCmd
+		m.paused = false
+		cmd := m.requestFetch()
+		return m, cmd
+	case " ":
+		m.paused = !m.paused
+		if !m.paused {
+			cmd := m.requestFetch()
+			return m, cmd
+		}
 	case "t":
 		m.showTodos = !m.showTodos
+	case "T":
+		m.hideDoneTodos = !m.hideDoneTodos
+	case "J":
+		if m.showTodos {
+			m.selectMode = true
+			m.scrollTodos(1)
+		}
+	case "K":
+		if m.showTodos {
+			m.selectMode = true
+			m.scrollTodos(-1)
+		}
 	case "m":
 		m.showMCPs = !m.showMCPs
+		if m.mcpHealthStale() {
+			// called first: it updates m, which the return copies
+			cmd := m.refreshMCPHealth()
+			return m, cmd
+		}
+	case "L":
+		display.oneLine = !display.oneLine
+		m.adjustScroll()
+		if display.oneLine && display.ticker.rateMS > 0 && !m.tickerRunning {
+			m.tickerRunning = true
+			return m, tickerTickCmd()
+		}
+	case "H":
+		m.showHeatmap = !m.showHeatmap
+		m.adjustScroll()
+		if m.heatmapStale() {
+			return m, heatmapCmd
+		}
+	case "S":
+		m.showUsage = !m.showUsage
+		m.adjustScroll()
+		if m.usageStale() {
+			return m, usageCmd
+		}
+	case "M":
+		m.showModelUsage = !m.showModelUsage
+		m.adjustScroll()
+		if m.modelUsageStale() {
+			return m, modelUsageCmd
+		}
 	case "a":
 		m.showAllSessions = !m.showAllSessions
 	case "p":
 		m.showAllProcesses = !m.showAllProcesses
+	case "e":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok {
+			dir := cs.process.cwd
+			if cs.session != nil && cs.session.directory != "" {
+				dir = cs.session.directory
+			}
+			if dir != "" {
+				return m, openEditorCmd(dir)
+			}
+		}
+	case "n":
+		m.spawnActive = true
+		m.spawnText = ""
+		m.spawnCursor = 0
+		return m, loadSpawnDirsCmd
+	case ":":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok {
+			return m.openPrompt(cs), nil
+		}
+	case "R", "N":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok {
+			kind := "alias"
+			if msg.String() == "N" {
+				kind = "note"
+			}
+			return m.openNoteEditor(cs, kind), nil
+		}
+	case "c":
+		toggleScope()
+		m.cursor, m.scrollOffset = 0, 0
+		cmd := m.requestFetch()
+		return m, cmd
+	case "X":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok && cs.session != nil {
+			proc, sid := cs.process, cs.session.sessionID
+			return m, func() tea.Msg { return apiResultMsg{"abort", sid, abortSession(proc, sid)} }
+		}
+	case "Z":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok && cs.session != nil {
+			proc, session := cs.process, cs.session
+			m.flashMsg = "compacting..."
+			m.flashTime = time.Now()
+			return m, func() tea.Msg { return apiResultMsg{"compact", session.sessionID, compactSession(proc, session)} }
+		}
+	case "o":
+		m.selectMode = true
+		if cs, ok := m.selectedSession(); ok {
+			proc, sid := cs.process, ""
+			if cs.session != nil {
+				sid = cs.session.sessionID
+			}
+			return m, func() tea.Msg { return focusResultMsg{sid, focusPane(proc)} }
+		}
+	case "A":
+		if display.archive.after > 0 {
+			m.showArchived = !m.showArchived
+			m.cursor = min(m.cursor, max(0, len(m.getVisibleSessions())-1))
+			m.adjustScroll()
+			return m, m.previewIfMoved()
+		}
+	case "b":
+		m.groupTmux = !m.groupTmux
+		m.adjustScroll()
+	case "@":
+		display.absoluteTimes = !display.absoluteTimes
+		m.flashMsg = "relative times"
+		if display.absoluteTimes {
+			m.flashMsg = "clock times"
+		}
+		m.flashTime = time.Now()
+	case "W":
+		m.inboxMode = !m.inboxMode
+		m.cursor, m.scrollOffset = 0, 0
+		return m, m.previewIfMoved()
+	case "w":
+		if paneCaptureAvailable() {
+			m.wallMode = true
+			return m, m.wallCmd()
+		}
+	case "v":
+		m.previewMode = !m.previewMode
+		m.previewPID = 0
+		m.previewLines = nil
+	case "D":
+		m.debugMode = true
+	case "Q":
+		m.pairMode = true
+		m.pairCode = ""
+		return m, pairCodeCmd()
+	case "C":
+		m.pickerMode = true
+		m.pickerCursor = 0
 	case "y":
 		m.selectMode = true
 		visible := m.getVisibleSessions()
@@ -183,25 +685,28 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.selectMode = true
 		visible := m.getVisibleSessions()
 		if m.cursor < len(visible) {
-			cs := visible[m.cursor]
-			m.detailSession = &cs
-			m.detailScroll = 0
-			m.detailMode = true
-			return m, m.refreshDetailCmd()
+			return m.openDetail(visible[m.cursor])
 		}
 	case ">", ".":
 		m.sortColIdx = (m.sortColIdx + 1) % len(columns)
 	case "<", ",":
 		m.sortColIdx = (m.sortColIdx - 1 + len(columns)) % len(columns)
+	case "+":
+		m.sortThen = nextThenKey(columns[m.sortColIdx].key, m.sortThen)
 	case "s":
 		m.sortReverse = !m.sortReverse
 
+	case "f1", "f2", "f3", "f4":
+		m.applyPreset(int(msg.String()[1] - '1'))
+	case "F":
+		m.cyclePreset()
 	case "/":
 		m.filterActive = true
 		m.filterText = ""
+		m.presetName = ""
 	case "esc":
 		if m.filterText != "" {
-			m.filterText = ""
+			m.presetName, m.filterText = "", ""
 		} else {
 			m.selectMode = false
 		}
@@ -213,6 +718,36 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "k", "up":
 		m.selectMode = true
 		m.cursor = max(m.cursor-1, 0)
+	case "g":
+		if !afterG {
+			m.pendingG = true
+			return m, nil
+		}
+		m.selectMode = true
+		m.cursor = 0
+	case "G", "end":
+		m.selectMode = true
+		m.cursor = max(0, len(m.getVisibleSessions())-1)
+	case "home":
+		m.selectMode = true
+		m.cursor = 0
+	case "ctrl+d", "pgdown":
+		m.selectMode = true
+		m.cursor += max(1, m.listPageSize()/2)
+	case "ctrl+u", "pgup":
+		m.selectMode = true
+		m.cursor = max(m.cursor-max(1, m.listPageSize()/2), 0)
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		// nth row on screen, so it works the same wherever the list is scrolled
+		idx := m.scrollOffset + int(key[0]-'1')
+		visible := m.getVisibleSessions()
+		if idx < len(visible) {
+			m.selectMode = true
+			m.cursor = idx
+			if display.numberKeysOpenDetail {
+				return m.openDetail(visible[idx])
+			}
+		}
 	}
 
 	// clamp cursor after filter/toggle changes
@@ -221,13 +756,18 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	m.cursor = min(m.cursor, maxIdx)
 	m.adjustScroll()
 
-	return m, nil
+	if m.mcpHealthStale() {
+		preview := m.previewIfMoved()
+		cmd := m.refreshMCPHealth()
+		return m, tea.Batch(preview, cmd)
+	}
+	return m, m.previewIfMoved()
 }
 
 func (m model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		m.filterText = ""
+		m.presetName, m.filterText = "", ""
 		m.filterActive = false
 	case "enter":
 		m.filterActive = false
@@ -251,11 +791,34 @@ func (m model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc", "q":
 		m.detailMode = false
-		return m, fetchCmd
+		cmd := m.requestFetch()
+		return m, cmd
 	case "r":
 		return m, m.refreshDetailCmd()
+	case ":":
+		return m.openPrompt(*m.detailSession), nil
 	case "tab":
 		return m, m.toggleDetailSourceCmd()
+	case "P":
+		m.detailProcs = !m.detailProcs
+	case "I":
+		m.detailMeta = !m.detailMeta
+	case "R":
+		if m.detailSession.session == nil {
+			return m, nil
+		}
+		if m.detailSource == "rounds" {
+			return m, m.toggleDetailSourceCmd()
+		}
+		return m, detailRoundsCmd(m.detailSession.session.sessionID)
+	case "O":
+		if m.detailSource == "db" && m.detailSession.session != nil {
+			return m, detailOlderCmd(m.detailSession.session.sessionID, detailDBPage+len(m.detailOlder))
+		}
+	case "h", "left", "[":
+		return m.stepDetail(-1)
+	case "l", "right", "]":
+		return m.stepDetail(1)
 	case "j", "down":
 		maxScroll := max(0, len(m.detailLines)-10)
 		m.detailScroll = min(m.detailScroll+1, maxScroll)
@@ -270,27 +833,180 @@ func (m model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openDetail shows cs in the detail view, fetching its content.
+func (m model) openDetail(cs correlatedSession) (tea.Model, tea.Cmd) {
+	m.detailSession = &cs
+	m.detailScroll = 0
+	m.detailMode = true
+	m.detailLines = nil
+	m.detailLogErr = ""
+	m.detailEdit = nil
+	m.detailOlder = nil
+	var env map[string]string
+	if cs.process.host == "" {
+		env = processEnv(cs.process.pid)
+	}
+	m.detailInvoc = parseInvocation(cs.process.cmdline, env)
+	m.markSeen(cs.session)
+	return m, m.refreshDetailCmd()
+}
+
+// stepDetail moves the detail view to the previous (-1) or next (+1)
+// visible session, keeping the list cursor in step so esc lands on it.
+func (m model) stepDetail(delta int) (tea.Model, tea.Cmd) {
+	visible := m.getVisibleSessions()
+	if len(visible) == 0 {
+		return m, nil
+	}
+	m.cursor = max(0, min(m.cursor+delta, len(visible)-1))
+	m.adjustScroll()
+	return m.openDetail(visible[m.cursor])
+}
+
+func (m model) handleWallKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "w":
+		m.wallMode = false
+		cmd := m.requestFetch()
+		return m, cmd
+	case "r":
+		cmd := m.requestFetch()
+		return m, tea.Batch(cmd, m.wallCmd())
+	}
+	return m, nil
+}
+
+func (m model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := len(oneLineColumnOrder) - 1
+	switch msg.String() {
+	case "esc", "q", "C", "enter":
+		m.pickerMode = false
+	case "j", "down":
+		m.pickerCursor = min(m.pickerCursor+1, last)
+	case "k", "up":
+		m.pickerCursor = max(m.pickerCursor-1, 0)
+	case " ", "x":
+		key := oneLineColumnOrder[m.pickerCursor].key
+		display.columns.setEnabled(key, !display.columns.isEnabled(key))
+	case "J", "shift+down":
+		if m.pickerCursor < last {
+			i := m.pickerCursor
+			oneLineColumnOrder[i], oneLineColumnOrder[i+1] = oneLineColumnOrder[i+1], oneLineColumnOrder[i]
+			m.pickerCursor++
+		}
+	case "K", "shift+up":
+		if m.pickerCursor > 0 {
+			i := m.pickerCursor
+			oneLineColumnOrder[i], oneLineColumnOrder[i-1] = oneLineColumnOrder[i-1], oneLineColumnOrder[i]
+			m.pickerCursor--
+		}
+	}
+	return m, nil
+}
+
 // -- data handling --
 
 func (m model) handleData(result fetchResult) (tea.Model, tea.Cmd) {
+	m.fetching = false
+	m.lastFetchDur = result.elapsed
+	m.lastTimings = result.timings
+	bus.publish(snapshotEvent{result})
+	var cmds []tea.Cmd
+	if m.fetchQueued {
+		m.fetchQueued = false
+		cmds = append(cmds, m.requestFetch())
+	}
+
+	// a fetch that was in flight when pausing shouldn't reshuffle the list
+	if m.paused && m.ready {
+		return m, tea.Batch(cmds...)
+	}
 	m.sessions = result.correlated
 	m.todayStats = result.todayStats
 	m.globalStats = result.globalStats
 	m.mcpConfig = result.mcpConfig
+	m.dbErr = result.dbErr
+	m.schemaErr = result.schemaErr
 	m.ready = true
 
+	if fresh := detectTransitions(m.prevStatus, result.correlated); len(fresh) > 0 {
+		m.notices = append(m.liveNotices(), fresh...)
+		for _, n := range fresh {
+			bus.publish(transitionEvent{sessionID: n.sessionID, notice: n})
+		}
+	}
+	m.prevStatus = sessionStatuses(result.correlated)
+	m.updateSeen(result.correlated)
+	m.newestVersions = newestVersions(result.correlated)
+	m.ambiguous = sessionClaims(result.correlated)
+
 	// clamp cursor after data change
 	visible := m.getVisibleSessions()
 	maxIdx := max(0, len(visible)-1)
 	m.cursor = min(m.cursor, maxIdx)
 	m.adjustScroll()
 
-	return m, nil
+	if m.heatmapStale() {
+		m.heatmapAt = time.Now() // don't queue another load while this one runs
+		cmds = append(cmds, heatmapCmd)
+	}
+	if m.usageStale() {
+		m.usageAt = time.Now()
+		cmds = append(cmds, usageCmd)
+	}
+	if m.modelUsageStale() {
+		m.modelUsageAt = time.Now()
+		cmds = append(cmds, modelUsageCmd)
+	}
+	if m.budgetStale() {
+		m.budgetAt = time.Now()
+		cmds = append(cmds, budgetCmd)
+	}
+	if m.mcpHealthStale() {
+		cmds = append(cmds, m.refreshMCPHealth())
+	}
+
+	cmds = append(cmds, m.previewIfMoved())
+	return m, tea.Batch(cmds...)
 }
 
 // -- filtering + sorting --
 
 func (m model) getVisibleSessions() []correlatedSession {
+	filtered := m.filteredSessions()
+	if m.inboxMode {
+		return inboxSessions(filtered)
+	}
+	if !m.showArchived {
+		filtered = slices.DeleteFunc(filtered, archived)
+	}
+
+	keys := m.sortKeys()
+	sort.SliceStable(filtered, func(i, j int) bool {
+		cmp := compareSessions(keys, filtered[i], filtered[j])
+		if m.sortReverse {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	if q := parseFilter(m.filterText); q.mode == "fz" && q.text != "" {
+		// fuzzy results rank by score; the sort column breaks ties
+		slices.SortStableFunc(filtered, func(a, b correlatedSession) int {
+			return fuzzyRowScore(q.text, b) - fuzzyRowScore(q.text, a)
+		})
+	}
+	if m.grouping() {
+		groupByTmux(filtered)
+	}
+
+	return filtered
+}
+
+// filteredSessions applies the process, interactive, scope, and text
+// filters, unsorted.
+func (m model) filteredSessions() []correlatedSession {
 	var filtered []correlatedSession
 	for _, cs := range m.sessions {
 		if !m.showAllProcesses && (cs.process.isToolProcess || cs.session == nil) {
@@ -299,54 +1015,127 @@ func (m model) getVisibleSessions() []correlatedSession {
 		if !m.showAllSessions && cs.session != nil && !cs.session.interactive {
 			continue
 		}
-		if m.filterText != "" {
-			needle := strings.ToLower(m.filterText)
-			matches := false
-			if cs.session != nil {
-				matches = strings.Contains(strings.ToLower(cs.session.title), needle) ||
-					strings.Contains(strings.ToLower(cs.session.model), needle) ||
-					strings.Contains(strings.ToLower(cs.session.sessionID), needle) ||
-					strings.Contains(strings.ToLower(inferStatus(cs.session, cs.process.cpuPercent)), needle)
-			}
-			matches = matches ||
-				strings.Contains(strings.ToLower(cs.process.cwd), needle) ||
-				strings.Contains(strings.ToLower(cs.process.tty), needle)
-			if !matches {
-				continue
-			}
+		if !sessionInScope(cs) {
+			continue
+		}
+		if m.filterText != "" && !filterMatches(m.filterText, cs) {
+			continue
 		}
 		filtered = append(filtered, cs)
 	}
+	return filtered
+}
 
-	key := columns[m.sortColIdx].key
-	sort.SliceStable(filtered, func(i, j int) bool {
-		cmp := compareSessions(key, filtered[i], filtered[j])
-		if m.sortReverse {
-			return cmp > 0
+// sortLabel names the sort keys for display, e.g. "ROUND>STATUS".
+func (m model) sortLabel() string {
+	label := columns[m.sortColIdx].label
+	for _, key := range m.sortKeys()[1:] {
+		label += ">" + columnLabel(key)
+	}
+	return label
+}
+
+// sortKeys returns the sort column's key followed by the tie-breakers.
+func (m model) sortKeys() []string {
+	primary := columns[m.sortColIdx].key
+	keys := []string{primary}
+	for _, k := range m.sortThen {
+		if k != primary {
+			keys = append(keys, k)
 		}
-		return cmp < 0
-	})
+	}
+	return keys
+}
 
-	return filtered
+// nextThenKey cycles the first tie-breaking key through the columns
+// other than the primary, then back to none.
+func nextThenKey(primary string, then []string) []string {
+	current := ""
+	if len(then) > 0 {
+		current = then[0]
+	}
+	var candidates []string
+	for _, col := range columns {
+		if col.key != primary {
+			candidates = append(candidates, col.key)
+		}
+	}
+	i := slices.Index(candidates, current) + 1 // -1 (none) starts at 0
+	if i >= len(candidates) {
+		return nil
+	}
+	return []string{candidates[i]}
 }
 
-func (m *model) adjustScroll() {
-	overhead := m.listOverhead()
+// selectedSession returns the session under the cursor, if any.
+func (m model) selectedSession() (correlatedSession, bool) {
+	visible := m.getVisibleSessions()
+	if m.cursor < len(visible) {
+		return visible[m.cursor], true
+	}
+	return correlatedSession{}, false
+}
+
+// listPageSize returns how many sessions fit in the list at once.
+func (m model) listPageSize() int {
 	linesPerSession := 3
-	if display.oneLine {
+	if display.oneLine || m.tiny() {
 		linesPerSession = 1
 	}
-	pageSize := max(1, (m.height-overhead)/linesPerSession)
+	return max(1, (m.height-m.listOverhead())/linesPerSession)
+}
+
+func (m *model) adjustScroll() {
+	pageSize := m.listPageSize()
 	if m.cursor >= m.scrollOffset+pageSize {
 		m.scrollOffset = m.cursor - pageSize + 1
 	}
 	if m.cursor < m.scrollOffset {
 		m.scrollOffset = m.cursor
 	}
+	// with group headers the page size depends on where the page starts
+	for m.scrollOffset < m.cursor && m.cursor >= m.scrollOffset+m.listPageSize() {
+		m.scrollOffset++
+	}
 }
 
 // -- commands --
 
+// requestFetch starts a fetch unless one is already in flight, in which
+// case it queues a single follow-up. returns nil when queued.
+func (m *model) requestFetch() tea.Cmd {
+	if m.replay != nil {
+		return nil // frames arrive on the trace's schedule
+	}
+	if m.fetching {
+		m.fetchQueued = true
+		return nil
+	}
+	m.fetching = true
+	if display.paneIdle.enabled {
+		setPaneIdleTargets(m.onScreenPIDs())
+	}
+	return fetchCmd
+}
+
+// onScreenPIDs returns the PIDs of the rows on the current page, the
+// selected row and the detail view's process.
+func (m model) onScreenPIDs() map[int]bool {
+	pids := make(map[int]bool)
+	visible := m.getVisibleSessions()
+	end := min(m.scrollOffset+m.listPageSize(), len(visible))
+	for i := min(m.scrollOffset, end); i < end; i++ {
+		pids[visible[i].process.pid] = true
+	}
+	if m.selectMode && m.cursor < len(visible) {
+		pids[visible[m.cursor].process.pid] = true
+	}
+	if m.detailSession != nil {
+		pids[m.detailSession.process.pid] = true
+	}
+	return pids
+}
+
 func fetchCmd() tea.Msg {
 	return dataMsg(fetchAll())
 }
@@ -366,18 +1155,95 @@ func tickerTickCmd() tea.Cmd {
 func (m model) refreshDetailCmd() tea.Cmd {
 	proc := m.detailSession.process
 	session := m.detailSession.session
+	sessionID := detailSessionID(m.detailSession)
+	older := m.detailOlder
+	source := m.detailSource
+	return func() tea.Msg {
+		msg := detailRefreshMsg{sessionID: sessionID, pid: proc.pid, logErr: lastLogError(proc.logPath)}
+		if session != nil {
+			msg.edit = lastEditPreview(session.sessionID)
+		}
+		if source == "rounds" && session != nil {
+			msg.lines = roundsLines(session.sessionID)
+			return msg
+		}
+		if lines, source := capturePane(proc); lines != nil {
+			msg.lines, msg.source = lines, source
+			return msg
+		}
+		if session != nil {
+			msg.lines = formatDBMessages(append(slices.Clip(older), getRecentMessages(session.sessionID, detailDBPage, 0)...))
+			msg.source = "db"
+			return msg
+		}
+		msg.lines = []string{"  (no data)"}
+		return msg
+	}
+}
+
+// detailSessionID is the id of the detail view's session, or "" for a
+// process without one.
+func detailSessionID(cs *correlatedSession) string {
+	if cs.session == nil {
+		return ""
+	}
+	return cs.session.sessionID
+}
+
+// detailOlderCmd fetches the page of db history before the offset
+// newest messages.
+func detailOlderCmd(sessionID string, offset int) tea.Cmd {
+	return func() tea.Msg {
+		return detailOlderMsg{sessionID: sessionID, messages: getRecentMessages(sessionID, detailDBPage, offset)}
+	}
+}
+
+// previewIfMoved refreshes the preview pane when the cursor has moved
+// to a different process than the one currently shown.
+func (m model) previewIfMoved() tea.Cmd {
+	if !m.previewMode {
+		return nil
+	}
+	cs, ok := m.selectedSession()
+	if !ok || cs.process.pid == m.previewPID {
+		return nil
+	}
+	return m.previewCmd()
+}
+
+func (m model) previewCmd() tea.Cmd {
+	cs, ok := m.selectedSession()
+	if !ok {
+		return nil
+	}
+	proc := cs.process
+	session := cs.session
 	return func() tea.Msg {
-		lines := captureTmuxPane(proc.tty)
-		if lines != nil {
-			return detailRefreshMsg{lines: lines, source: "tmux"}
+		if lines, source := capturePane(proc); lines != nil {
+			return previewMsg{pid: proc.pid, lines: lines, source: source}
 		}
 		if session != nil {
-			return detailRefreshMsg{
-				lines:  formatDBMessages(getRecentMessages(session.sessionID, 30)),
+			return previewMsg{
+				pid:    proc.pid,
+				lines:  formatDBMessages(getRecentMessages(session.sessionID, 10, 0)),
 				source: "db",
 			}
 		}
-		return detailRefreshMsg{lines: []string{"  (no data)"}}
+		return previewMsg{pid: proc.pid, lines: []string{"  (no data)"}}
+	}
+}
+
+// wallCmd captures the panes of every session that fits on the wall.
+func (m model) wallCmd() tea.Cmd {
+	cells := m.wallSessions()
+	return func() tea.Msg {
+		captures := make(wallMsg, len(cells))
+		for _, cs := range cells {
+			if lines, _ := capturePane(cs.process); lines != nil {
+				captures[cs.process.pid] = lines
+			}
+		}
+		return captures
 	}
 }
 
@@ -385,20 +1251,20 @@ func (m model) toggleDetailSourceCmd() tea.Cmd {
 	currentSource := m.detailSource
 	proc := m.detailSession.process
 	session := m.detailSession.session
+	older := m.detailOlder
 	return func() tea.Msg {
-		if currentSource == "tmux" {
+		if currentSource != "db" {
 			if session != nil {
 				return detailToggleMsg{
-					lines:  formatDBMessages(getRecentMessages(session.sessionID, 30)),
+					lines:  formatDBMessages(append(slices.Clip(older), getRecentMessages(session.sessionID, detailDBPage, 0)...)),
 					source: "db",
 				}
 			}
 			return detailToggleMsg{lines: []string{"  (no session data)"}, source: "db"}
 		}
-		// try tmux
-		lines := captureTmuxPane(proc.tty)
-		if lines != nil {
-			return detailToggleMsg{lines: lines, source: "tmux"}
+		// try the pane
+		if lines, source := capturePane(proc); lines != nil {
+			return detailToggleMsg{lines: lines, source: source}
 		}
 		return detailToggleMsg{} // stay on current
 	}
@@ -6,6 +6,8 @@
 
 package main
 
+import "time"
+
 // processInfo represents an opencode process found via ps.
 type processInfo struct {
 	pid           int
@@ -15,37 +17,102 @@ type processInfo struct {
 	tty           string
 	tmuxSession   string // tmux session name this process is running in
 	tmuxWindow    string // tmux window name
+	container     string // docker container name, for processes found inside one (containers.go)
+	host          string // ssh remote the process runs on, "" for local (remote.go)
 	cwd           string
 	cmdline       string
-	sessionID     string // from otop plugin PID file
-	startTimeMS   int64  // from log filename via lsof (uptime display)
-	isToolProcess bool   // true for `opencode run` (LSPs, wrappers)
+	logPath       string             // opencode log file, from lsof (may be unlinked)
+	apiPort       int                // opencode's local HTTP server, 0 if not listening
+	sessionID     string             // from otop plugin PID file
+	startTimeMS   int64              // from log filename via lsof, or docker top's etime (uptime display)
+	isToolProcess bool               // true for `opencode run` (LSPs, wrappers)
+	children      []childProcess     // descendants (LSPs, shells, node), depth first
+	version       string             // opencode version of the running binary, "" if unknown (version.go)
+	candidates    []sessionCandidate // sessions that matched about as well as sessionID (ambiguous.go)
+	guessed       bool               // sessionID was picked from a container's mounts, not a PID file (containers.go)
+	matchScore    float64            // how well a guessed session fits the process (overlapScore)
+}
+
+// sessionCandidate is a session a heuristic match considered.
+type sessionCandidate struct {
+	id       string
+	title    string
+	created  int64
+	updated  int64
+	messages int     // messages in the session
+	recent   int     // of those, written since the process started
+	score    float64 // how well its activity fits the process (overlapScore)
+}
+
+// childProcess is one process below an opencode process.
+type childProcess struct {
+	pid        int
+	depth      int // 1 = direct child
+	cpuPercent float64
+	memMB      float64
+	args       string
+}
+
+// totalCPU is the process's CPU plus its children's. inferStatus keeps
+// using cpuPercent alone: a busy LSP doesn't mean the agent is working.
+func (p processInfo) totalCPU() float64 {
+	total := p.cpuPercent
+	for _, c := range p.children {
+		total += c.cpuPercent
+	}
+	return total
+}
+
+// totalMemMB is the process's RSS plus its children's.
+func (p processInfo) totalMemMB() float64 {
+	total := p.memMB
+	for _, c := range p.children {
+		total += c.memMB
+	}
+	return total
 }
 
 // sessionInfo represents a session from opencode's sqlite db.
 type sessionInfo struct {
 	sessionID         string
+	source            string // label of the database it came from (see dbSource)
 	title             string
 	directory         string
 	projectID         string
 	model             string
+	provider          string // providerID of the last message, for API calls
 	agent             string
 	messageCount      int
 	totalInputTokens  int64
 	totalOutputTokens int64
 	totalCacheRead    int64
+	totalCacheWrite   int64
 	totalCost         float64
 	lastFinish        *string // nil when null in db
 	lastMessageRole   string
 	lastMessageTime   int64
+	lastError         string // error on the last assistant message ("name: message"), if it failed
+	lastPartTime      int64  // newest part write; parts appear and grow while a message streams
 	timeCreated       int64
 	timeUpdated       int64
 	roundStartTime    int64
-	lastOutput        string
+	lastOutput        string // last non-empty line of lastOutputFull
+	lastOutputFull    string // full text of the most recent assistant text part
 	activeTodos       []todoItem
 	version           string
-	interactive       bool   // false when permission is not null
-	pendingTool       string // name of currently-running tool (from part table), empty if none
+	interactive       bool    // false when permission is not null
+	pendingTool       string  // name of currently-running tool (from part table), empty if none
+	paneIdle          bool    // tmux pane shows the prompt again (display.paneIdle heuristic)
+	avgLatencyMS      int64   // user message to final answer, recent rounds (throughput.go)
+	tokensPerSec      float64 // output tokens per second of generation, same rounds
+	throughputRounds  int     // rounds averaged; 0 = none completed yet
+	compactions       int     // times opencode summarized the context away
+	lastCompaction    int64   // when the newest compaction happened, 0 if never
+	roundForecast     float64 // estimated cost of the running round so far (forecast.go)
+	lastUserInput     int64   // newest user message with typed text, not a synthetic one
+	roundCount        int     // user messages, i.e. prompts; tool chatter doesn't count
+	toolCalls         int     // tool parts over the whole session (tools.go)
+	toolUsage         []toolCount
 }
 
 // todoItem represents a single todo from a session's todo list.
@@ -67,6 +134,10 @@ type fetchResult struct {
 	todayStats  aggStats
 	globalStats aggStats
 	mcpConfig   map[string]any
+	elapsed     time.Duration // wall time of the whole collection
+	timings     []stepTiming  // per-step wall times (debug overlay)
+	dbErr       error         // query failures; data may be partial
+	schemaErr   error         // a database whose schema otop can't read (schema.go)
 }
 
 // aggStats holds aggregate token/message statistics.
@@ -77,6 +148,18 @@ type aggStats struct {
 	totalOutput  int64
 }
 
+// periodStats holds usage totals for one window of the stats panel.
+// tokensIn is fresh input only; cache reads and writes are separate.
+type periodStats struct {
+	sessions   int // sessions with a message in the window
+	messages   int
+	tokensIn   int64
+	tokensOut  int64
+	cacheRead  int64
+	cacheWrite int64
+	cost       float64
+}
+
 // messageDetail holds a single message for the detail view.
 type messageDetail struct {
 	role        string
@@ -88,3 +171,51 @@ type messageDetail struct {
 	timeCreated int64
 	textPreview string
 }
+
+// messageRecord holds one message row for `otop export-messages`.
+// timeCompleted is 0 while a message is still streaming.
+type messageRecord struct {
+	id            string
+	role          string
+	agent         string
+	provider      string
+	model         string
+	finish        string
+	tokensIn      int64
+	tokensOut     int64
+	reasoning     int64
+	cacheRead     int64
+	cacheWrite    int64
+	cost          float64
+	timeCreated   int64
+	timeCompleted int64
+}
+
+// retroSession is one session's activity over the retro window.
+type retroSession struct {
+	sessionID  string
+	title      string
+	directory  string
+	messages   int
+	errors     int
+	cost       float64
+	durationMS int64 // first to last message inside the window
+}
+
+// retroProject aggregates retro activity per directory.
+type retroProject struct {
+	directory string
+	sessions  int
+	messages  int
+	cost      float64
+}
+
+// retroDay holds one calendar day of totals for the retro view.
+type retroDay struct {
+	day       string // YYYY-MM-DD, local time
+	sessions  int
+	messages  int
+	tokensIn  int64
+	tokensOut int64
+	cost      float64
+}
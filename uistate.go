@@ -0,0 +1,124 @@
+// persistent UI state: sort, filter, panel toggles, and layout are saved
+// to $XDG_DATA_HOME/otop/ui-state.json when the TUI quits and restored on
+// the next start, so otop opens the way it was left. a missing or
+// unreadable file just means defaults.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// uiState is the saved subset of the model.
+type uiState struct {
+	SortKey          string   `json:"sort_key"`
+	SortReverse      bool     `json:"sort_reverse"`
+	SortThen         []string `json:"sort_then,omitempty"`
+	Filter           string   `json:"filter"`
+	FilterPreset     string   `json:"filter_preset,omitempty"`
+	ShowTodos        bool     `json:"show_todos"`
+	HideDoneTodos    bool     `json:"hide_done_todos"`
+	ShowMCPs         bool     `json:"show_mcps"`
+	ShowHeatmap      bool     `json:"show_heatmap"`
+	ShowUsage        bool     `json:"show_usage"`
+	ShowModelUsage   bool     `json:"show_model_usage"`
+	ShowAllSessions  bool     `json:"show_all_sessions"`
+	ShowAllProcesses bool     `json:"show_all_processes"`
+	OneLine          bool     `json:"one_line"`
+	GroupTmux        bool     `json:"group_tmux"`
+	AbsoluteTimes    *bool    `json:"absolute_times,omitempty"` // only when it differs from the config
+	// lastMessageTime per session as of its last detail view (seen.go)
+	Seen map[string]int64 `json:"seen,omitempty"`
+}
+
+func uiStatePath() string {
+	return filepath.Join(otopStateDir(), "ui-state.json")
+}
+
+// saveUIState writes the model's UI state. errors are ignored: losing
+// the state only costs the user a few keypresses.
+func (m model) saveUIState() {
+	state := uiState{
+		SortKey:          columns[m.sortColIdx].key,
+		SortReverse:      m.sortReverse,
+		SortThen:         m.sortThen,
+		Filter:           m.filterText,
+		FilterPreset:     m.presetName,
+		ShowTodos:        m.showTodos,
+		HideDoneTodos:    m.hideDoneTodos,
+		ShowMCPs:         m.showMCPs,
+		ShowHeatmap:      m.showHeatmap,
+		ShowUsage:        m.showUsage,
+		ShowModelUsage:   m.showModelUsage,
+		ShowAllSessions:  m.showAllSessions,
+		ShowAllProcesses: m.showAllProcesses,
+		OneLine:          display.oneLine,
+		GroupTmux:        m.groupTmux,
+		Seen:             m.liveSeen(),
+	}
+	if display.absoluteTimes != configAbsoluteTimes {
+		state.AbsoluteTimes = &display.absoluteTimes
+	}
+	if envConfig.oneLine != nil {
+		state.OneLine = envConfig.shadowedOneLine
+	}
+	if len(envConfig.sort) > 0 {
+		state.SortKey = envConfig.shadowedSort.SortKey
+		state.SortReverse = envConfig.shadowedSort.SortReverse
+		state.SortThen = envConfig.shadowedSort.SortThen
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(otopStateDir(), 0o755) != nil {
+		return
+	}
+	_ = os.WriteFile(uiStatePath(), data, 0o644)
+}
+
+// configAbsoluteTimes is the config's absolute_times, before the saved
+// state is applied. the @ toggle is only saved while it differs, so the
+// config key keeps working for anyone who never pressed @.
+var configAbsoluteTimes bool
+
+// restoreUIState applies the saved state, if any, to a fresh model.
+func (m *model) restoreUIState() {
+	configAbsoluteTimes = display.absoluteTimes
+	data, err := os.ReadFile(uiStatePath())
+	if err != nil {
+		return
+	}
+	var state uiState
+	if json.Unmarshal(data, &state) != nil {
+		return
+	}
+	for i, col := range columns {
+		if col.key == state.SortKey {
+			m.sortColIdx = i
+			m.sortReverse = state.SortReverse
+			m.sortThen = state.SortThen
+			break
+		}
+	}
+	m.filterText = state.Filter
+	m.presetName = state.FilterPreset
+	m.showTodos = state.ShowTodos
+	m.hideDoneTodos = state.HideDoneTodos
+	m.showMCPs = state.ShowMCPs
+	m.showHeatmap = state.ShowHeatmap
+	m.showUsage = state.ShowUsage
+	m.showModelUsage = state.ShowModelUsage
+	m.showAllSessions = state.ShowAllSessions
+	m.showAllProcesses = state.ShowAllProcesses
+	display.oneLine = state.OneLine
+	m.groupTmux = state.GroupTmux
+	if state.AbsoluteTimes != nil {
+		display.absoluteTimes = *state.AbsoluteTimes
+	}
+	for id, t := range state.Seen {
+		m.seen[id] = t
+	}
+}
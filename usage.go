@@ -0,0 +1,77 @@
+// usage stats panel (S): today, this week, and all time side by side,
+// each with sessions, messages, tokens (fresh input, output, cache reads
+// and writes), and cost. it works in both layouts, unlike the stats bar,
+// and keeps the windows apart instead of mixing today and global numbers.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	usageRefresh = time.Minute // full message scan; totals move slowly
+	usageRows    = 5           // separator + header + today/week/all time
+)
+
+var usageLabels = []string{"today", "this week", "all time"}
+
+// usageMsg carries one row per usageLabels entry.
+type usageMsg struct {
+	rows []periodStats
+	err  error
+}
+
+func usageCmd() tea.Msg {
+	today, week := periodStarts(time.Now())
+	rows, err := queryPeriodStats(today.UnixMilli(), week.UnixMilli())
+	return usageMsg{rows: rows, err: err}
+}
+
+// periodStarts returns local midnight today and on this week's monday.
+func periodStarts(now time.Time) (today, week time.Time) {
+	today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	week = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)) // back to monday
+	return today, week
+}
+
+// usageStale reports whether the panel's data should be reloaded.
+func (m model) usageStale() bool {
+	return m.showUsage && time.Since(m.usageAt) > usageRefresh
+}
+
+// renderUsagePanel draws the table, usageRows lines.
+func (m model) renderUsagePanel() string {
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(strings.Repeat("─", m.width)))
+	b.WriteString("\n")
+
+	const labelW, numW = 10, 9
+	header := fmt.Sprintf("%-*s", labelW, " USAGE")
+	for _, h := range []string{"SESSIONS", "MSGS", "IN", "OUT", "CACHE R", "CACHE W", "COST"} {
+		header += fmt.Sprintf("%*s", numW, h)
+	}
+	b.WriteString(panelStyle.Render(truncOrPad(header, m.width)))
+	b.WriteString("\n")
+
+	for i, label := range usageLabels {
+		line := fmt.Sprintf(" %-*s", labelW-1, label)
+		if i < len(m.usage) {
+			p := m.usage[i]
+			line += fmt.Sprintf("%*d%*d%*s%*s%*s%*s%*s",
+				numW, p.sessions, numW, p.messages,
+				numW, formatTokens(p.tokensIn), numW, formatTokens(p.tokensOut),
+				numW, formatTokens(p.cacheRead), numW, formatTokens(p.cacheWrite),
+				numW, fmt.Sprintf("$%.2f", p.cost))
+		} else {
+			line += fmt.Sprintf("%*s", numW, "...")
+		}
+		b.WriteString(truncOrPad(line, m.width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
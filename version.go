@@ -0,0 +1,153 @@
+// opencode version drift. after an upgrade, agents started before it
+// keep running the old build, and mixed versions behave differently in
+// confusing ways. each refresh finds the newest version among running
+// processes (per host, since remotes have their own installs); ones
+// behind it get a "↓" in the VERSION column and are counted in the
+// footer as ones to restart.
+//
+// the version is the running binary's, not the one the session was
+// created with: opencode stamps that once, so a resumed session would
+// keep its creator's version forever. the session's stamp is only the
+// fallback for processes whose binary can't be asked (remotes,
+// containers).
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// staleVersionMarker follows a version that's behind the newest seen.
+const staleVersionMarker = " ↓"
+
+// compareVersions orders dotted versions numerically ("1.0.9" < "1.0.10").
+// a prerelease suffix sorts before the release it precedes.
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := range max(len(aParts), len(bParts)) {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return cmp.Compare(aPre, bPre)
+}
+
+var (
+	binaryVersionsMu sync.Mutex
+	binaryVersions   = make(map[string]string) // device:inode -> version
+)
+
+// runningVersion asks the binary process pid runs for its version, once
+// per binary. on linux that's /proc/<pid>/exe, which still runs the old
+// build after an upgrade replaced the file; elsewhere it's the path lsof
+// listed. "" if it can't be found or asked.
+func runningVersion(pid int, exe string) string {
+	if runtime.GOOS == "linux" {
+		exe = fmt.Sprintf("/proc/%d/exe", pid)
+	}
+	if exe == "" {
+		return ""
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return ""
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	key := fmt.Sprintf("%d:%d", st.Dev, st.Ino)
+
+	binaryVersionsMu.Lock()
+	defer binaryVersionsMu.Unlock()
+	if v, ok := binaryVersions[key]; ok {
+		return v
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, exe, "--version").Output()
+	var version string
+	if fields := strings.Fields(string(out)); len(fields) > 0 {
+		version = strings.TrimPrefix(fields[len(fields)-1], "v")
+	}
+	binaryVersions[key] = version // failures too, so they aren't retried every refresh
+	return version
+}
+
+// opencodeVersion is the version cs's process runs: its binary's, else
+// the session's stamp.
+func opencodeVersion(cs correlatedSession) string {
+	if cs.process.version != "" {
+		return cs.process.version
+	}
+	if cs.session != nil {
+		return cs.session.version
+	}
+	return ""
+}
+
+// newestVersions returns the newest opencode version among running
+// sessions, keyed by host ("" for local).
+func newestVersions(correlated []correlatedSession) map[string]string {
+	newest := make(map[string]string)
+	for _, cs := range correlated {
+		version := opencodeVersion(cs)
+		if cs.session == nil || cs.process.isToolProcess || version == "" {
+			continue
+		}
+		host := cs.process.host
+		if cur, ok := newest[host]; !ok || compareVersions(version, cur) > 0 {
+			newest[host] = version
+		}
+	}
+	return newest
+}
+
+// versionBehind reports whether a session runs an older opencode than
+// the newest one running on the same host.
+func (m model) versionBehind(cs correlatedSession) bool {
+	version := opencodeVersion(cs)
+	if cs.session == nil || version == "" {
+		return false
+	}
+	newest, ok := m.newestVersions[cs.process.host]
+	return ok && compareVersions(version, newest) < 0
+}
+
+// staleVersionCount counts visible sessions behind the newest version.
+func (m model) staleVersionCount() int {
+	n := 0
+	for _, cs := range m.getVisibleSessions() {
+		if m.versionBehind(cs) {
+			n++
+		}
+	}
+	return n
+}
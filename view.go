@@ -7,8 +7,14 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -45,22 +51,102 @@ func statusStyleFor(status string) lipgloss.Style {
 		return activeStyle
 	case "asking":
 		return askingStyle
-	case "thinking", "queued":
+	case "thinking", "queued", "budget":
 		return transStyle
 	case "idle":
 		return idleStyle
-	case "truncated":
+	case "truncated", "stuck", "error", "over budget":
 		return errorStyle
 	default:
 		return staleStyle
 	}
 }
 
+// -- OSC 8 hyperlinks --
+
+// hyperlinksEnabled resolves display.hyperlinks.mode. "auto" turns links
+// on for terminals known to render OSC 8 and off elsewhere, since some
+// older terminals print the escape sequence as garbage.
+var hyperlinksEnabled = sync.OnceValue(func() bool {
+	switch display.hyperlinks.mode {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	return os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("VTE_VERSION") != ""
+})
+
+// hyperlink wraps already-padded text in an OSC 8 link to target.
+func hyperlink(target, text string) string {
+	if target == "" || !hyperlinksEnabled() {
+		return text
+	}
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// dirLink links text to a directory as a file:// URL.
+func dirLink(dir, text string) string {
+	if dir == "" || dir == "?" || !filepath.IsAbs(dir) {
+		return text
+	}
+	u := url.URL{Scheme: "file", Path: dir}
+	return hyperlink(u.String(), text)
+}
+
+// sessionLink links text to a session via the configured URL template.
+func sessionLink(sessionID, text string) string {
+	if display.hyperlinks.sessionURL == "" {
+		return text
+	}
+	return hyperlink(strings.ReplaceAll(display.hyperlinks.sessionURL, "{id}", sessionID), text)
+}
+
+// visibleGridSlots returns the two-line columns that fit m.width, dropping
+// them in gridDropOrder until TITLE/LAST gets at least minTitleWidth.
+func (m model) visibleGridSlots() []gridSlot {
+	slots := gridSlots
+	for _, drop := range gridDropOrder {
+		if m.width <= 0 || gridTitleWidth(m.width, slots) >= minTitleWidth {
+			break
+		}
+		var kept []gridSlot
+		for _, sl := range slots {
+			if sl.top != drop {
+				kept = append(kept, sl)
+			}
+		}
+		slots = kept
+	}
+	return slots
+}
+
+// gridTitleWidth is the TITLE/LAST width left over beside the given slots.
+func gridTitleWidth(width int, slots []gridSlot) int {
+	fixed := 0
+	for _, sl := range slots {
+		fixed += colGap + sl.width
+	}
+	return max(10, width-fixed-colGap)
+}
+
 // titleWidth computes the flexible TITLE/LAST column width.
 func (m model) titleWidth() int {
-	fixed := colGap + colStatus + colGap + colSID + colGap + colUp +
-		colGap + colCPU + colGap + colCtx + colGap + colModel
-	return max(10, m.width-fixed-colGap)
+	return gridTitleWidth(m.width, m.visibleGridSlots())
+}
+
+// gridLine joins a row's leading cell with the cells of the visible slots.
+// cells maps a slot's top label to its already padded content.
+func (m model) gridLine(lead string, cells map[string]string) string {
+	text := "  " + lead
+	for _, sl := range m.visibleGridSlots() {
+		text += "  " + cells[sl.top]
+	}
+	return text
 }
 
 // -- list view rendering --
@@ -80,11 +166,36 @@ func (m model) renderListView() string {
 		b.WriteString(m.renderStatsBar())
 		b.WriteString("\n")
 	}
+	if budgetEnabled() {
+		b.WriteString(m.renderBudgetLine())
+		b.WriteString("\n")
+	}
+	if m.schemaErr != nil {
+		b.WriteString(errorStyle.Bold(true).Render(truncOrPad(" "+m.schemaErr.Error(), m.width)))
+		b.WriteString("\n")
+	}
+	if m.dbErr != nil {
+		b.WriteString(m.renderDBErrorBanner())
+		b.WriteString("\n")
+	}
 	visible := m.getVisibleSessions()
 
+	overhead := m.listOverhead()
+	linesPerSession := 3
+	if display.oneLine {
+		linesPerSession = 1
+	}
+	pageSize := max(1, (m.height-overhead)/linesPerSession)
+
+	// rows leave the last column for the scrollbar when the list overflows
+	rows := m
+	if len(visible) > pageSize {
+		rows.width = m.width - 1
+	}
+
 	// resolve column widths from actual content (shrink-wrap)
-	cols := resolvedOneLineColumns(visible)
-	flexWidth := m.oneLineFlexWidth(cols)
+	cols := rows.fitOneLineColumns(resolvedOneLineColumns(visible))
+	flexWidth := rows.oneLineFlexWidth(cols)
 
 	if display.showColumnHeaders {
 		if display.oneLine {
@@ -96,27 +207,34 @@ func (m model) renderListView() string {
 		b.WriteString("\n")
 	}
 
-	overhead := m.listOverhead()
-	linesPerSession := 3
-	if display.oneLine {
-		linesPerSession = 1
-	}
-	pageSize := max(1, (m.height-overhead)/linesPerSession)
-
+	var page strings.Builder
 	end := min(m.scrollOffset+pageSize, len(visible))
 	for i := m.scrollOffset; i < end; i++ {
 		isSelected := m.selectMode && i == m.cursor
 		cs := visible[i]
+		if m.grouping() && (i == m.scrollOffset || tmuxGroup(visible[i-1]) != tmuxGroup(cs)) {
+			page.WriteString(rows.renderGroupHeader(tmuxGroup(cs), visible))
+			page.WriteString("\n")
+		}
 		if display.oneLine {
-			b.WriteString(m.renderSessionOneLine(cs, isSelected, cols, flexWidth))
-			b.WriteString("\n")
+			page.WriteString(rows.renderSessionOneLine(cs, isSelected, cols, flexWidth))
+			page.WriteString("\n")
 		} else {
-			b.WriteString(m.renderSessionRow1(cs, isSelected))
-			b.WriteString("\n")
-			b.WriteString(m.renderSessionRow2(cs, isSelected))
-			b.WriteString("\n\n")
+			page.WriteString(rows.renderSessionRow1(cs, isSelected))
+			page.WriteString("\n")
+			page.WriteString(rows.renderSessionRow2(cs, isSelected))
+			page.WriteString("\n\n")
 		}
 	}
+	if page.Len() > 0 {
+		lines := strings.Split(strings.TrimSuffix(page.String(), "\n"), "\n")
+		joinLines(&b, withScrollbar(lines, scrollbar(m.scrollOffset, pageSize, len(visible), len(lines)), m.width))
+	}
+
+	if n := m.archivedCount(); n > 0 {
+		b.WriteString(m.renderArchiveRow(n))
+		b.WriteString("\n")
+	}
 
 	if m.selectMode {
 		b.WriteString(m.renderDetailLine())
@@ -129,6 +247,25 @@ func (m model) renderListView() string {
 	if m.showMCPs {
 		b.WriteString(m.renderMCPsPanel())
 	}
+	if m.showUsage {
+		b.WriteString(m.renderUsagePanel())
+	}
+	if m.showModelUsage {
+		b.WriteString(m.renderModelUsagePanel())
+	}
+	if m.showHeatmap {
+		b.WriteString(m.renderHeatmapPanel())
+	}
+	if m.previewMode {
+		b.WriteString(m.renderPreviewPane())
+	}
+	if len(m.liveNotices()) > 0 {
+		b.WriteString(m.renderNoticeLine())
+		b.WriteString("\n")
+	}
+	if m.spawnActive {
+		b.WriteString(m.renderSpawnPicker())
+	}
 
 	b.WriteString(m.renderFooter())
 
@@ -139,9 +276,17 @@ func (m model) renderListView() string {
 
 func (m model) renderHeader() string {
 	crumb := " opencode > sessions"
-	if m.filterText != "" {
+	if dir := scopeDir(); dir != "" {
+		crumb += " > " + shortPath(dir, 30)
+	}
+	if m.presetName != "" {
+		crumb += " > [" + m.presetName + "]"
+	} else if m.filterText != "" {
 		crumb += " > /" + m.filterText
 	}
+	if m.inboxMode {
+		crumb += fmt.Sprintf(" > needs reply (%d)", len(m.getVisibleSessions()))
+	}
 	right := time.Now().Format("15:04:05") + " "
 	pad := max(0, m.width-len(crumb)-len(right))
 	line := crumb + strings.Repeat(" ", pad) + right
@@ -151,12 +296,28 @@ func (m model) renderHeader() string {
 	return headerStyle.Render(line)
 }
 
+// -- db error banner --
+
+// renderDBErrorBanner shows the first db error (joined errors are one
+// per line) and how many more there were.
+func (m model) renderDBErrorBanner() string {
+	errLines := strings.Split(m.dbErr.Error(), "\n")
+	text := " db error: " + errLines[0]
+	if len(errLines) > 1 {
+		text += fmt.Sprintf(" (+%d more)", len(errLines)-1)
+	}
+	return errorStyle.Bold(true).Render(truncOrPad(text, m.width))
+}
+
 // -- stats bar --
 
 func (m model) renderStatsBar() string {
 	activeCount := 0
 	toolCount := 0
 	for _, cs := range m.sessions {
+		if !sessionInScope(cs) {
+			continue
+		}
 		if cs.session != nil && !cs.process.isToolProcess {
 			activeCount++
 		}
@@ -170,7 +331,7 @@ func (m model) renderStatsBar() string {
 		running += fmt.Sprintf(" (+%d bg)", toolCount)
 	}
 
-	sortLabel := columns[m.sortColIdx].label
+	sortLabel := m.sortLabel()
 	sortDir := "asc"
 	if m.sortReverse {
 		sortDir = "desc"
@@ -197,48 +358,27 @@ func (m model) renderColumnHeaders() string {
 	activeKey := columns[m.sortColIdx].key
 
 	// header-to-sort-key mapping
-	row1Cols := []struct {
-		label, key string
-		width      int
-	}{
-		{"TITLE", "title", tw},
-		{"STATUS", "status", colStatus},
-		{"SID", "sid", colSID},
-		{"UP", "uptime", colUp},
-		{"CPU", "cpu", colCPU},
-		{"CTX", "tokens", colCtx},
-		{"MODEL", "model", colModel},
-	}
-	row2Cols := []struct {
-		label, key string
-		width      int
-	}{
-		{"LAST", "last", tw},
-		{"MSGS", "msgs", colStatus},
-		{"PID", "pid", colSID},
-		{"ROUND", "round", colUp},
-		{"MEM", "mem", colCPU},
-		{"OUT", "tokens", colCtx},
-		{"TTY", "tty", colModel},
-	}
-
-	renderHdrRow := func(cols []struct {
-		label, key string
-		width      int
-	}) string {
-		var parts []string
-		for _, c := range cols {
-			text := truncOrPad(c.label, c.width)
-			if c.key == activeKey {
-				parts = append(parts, sortHiStyle.Render(text))
-			} else {
-				parts = append(parts, hdrDimBold.Render(text))
-			}
+	sortKeys := map[string]string{
+		"TITLE": "title", "STATUS": "status", "SID": "sid", "UP": "uptime",
+		"CPU": "cpu", "CTX": "tokens", "MODEL": "model",
+		"LAST": "last", "MSGS": "msgs", "PID": "pid", "ROUND": "round",
+		"MEM": "mem", "OUT": "tokens", "TTY": "tty",
+	}
+	label := func(text string, width int) string {
+		padded := truncOrPad(text, width)
+		if sortKeys[text] == activeKey {
+			return sortHiStyle.Render(padded)
 		}
-		return "  " + strings.Join(parts, "  ") + "\n"
+		return hdrDimBold.Render(padded)
 	}
 
-	return renderHdrRow(row1Cols) + renderHdrRow(row2Cols)
+	row1 := "  " + label("TITLE", tw)
+	row2 := "  " + label("LAST", tw)
+	for _, sl := range m.visibleGridSlots() {
+		row1 += "  " + label(sl.top, sl.width)
+		row2 += "  " + label(sl.bottom, sl.width)
+	}
+	return row1 + "\n" + row2 + "\n"
 }
 
 // -- session rows --
@@ -248,37 +388,39 @@ func (m model) renderSessionRow1(cs correlatedSession, selected bool) string {
 	nowMS := time.Now().UnixMilli()
 
 	if cs.session == nil {
-		text := "  " + truncOrPad(cs.process.cmdline, tw) +
-			"  " + truncOrPad("no-session", colStatus) +
-			"  " + truncOrPad("", colSID) +
-			"  " + truncOrPad("", colUp) +
-			"  " + truncOrPad("", colCPU) +
-			"  " + truncOrPad("", colCtx) +
-			"  " + truncOrPad("", colModel)
+		text := m.gridLine(truncOrPad(containerTag(cs.process, cs.process.cmdline), tw), map[string]string{
+			"STATUS": truncOrPad("no-session", colStatus),
+			"SID":    truncOrPad("", colSID),
+			"UP":     truncOrPad("", colUp),
+			"CPU":    truncOrPad("", colCPU),
+			"CTX":    truncOrPad("", colCtx),
+			"MODEL":  truncOrPad("", colModel),
+		})
 		if selected {
-			return selectStyle.Width(m.width).MaxWidth(m.width).Render(text)
+			return m.renderRow(cs, selectStyle, text)
 		}
-		return dimStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, dimStyle, text)
 	}
 
 	status := inferStatus(cs.session, cs.process.cpuPercent)
-	uptimeMS := int64(0)
+	uptime := formatDuration(0)
 	if cs.process.startTimeMS > 0 {
-		uptimeMS = nowMS - cs.process.startTimeMS
+		uptime = formatSince(cs.process.startTimeMS, nowMS)
 	}
 
-	text := "  " + truncOrPad(cs.session.title, tw) +
-		"  " + truncOrPad(status, colStatus) +
-		"  " + truncOrPad(cs.session.sessionID, colSID) +
-		"  " + truncOrPad(formatDuration(uptimeMS), colUp) +
-		"  " + truncOrPad(fmt.Sprintf("%.1f%%", cs.process.cpuPercent), colCPU) +
-		"  " + truncOrPad(formatTokens(cs.session.totalInputTokens), colCtx) +
-		"  " + truncOrPad(shortModel(cs.session.model), colModel)
+	text := m.gridLine(m.highlightCell("title", truncOrPad(m.unseenPrefix(cs)+m.ambiguousPrefix(cs)+containerTag(cs.process, sessionLabel(cs.session)), tw)), map[string]string{
+		"STATUS": truncOrPad(status, colStatus),
+		"SID":    sessionLink(cs.session.sessionID, truncOrPad(cs.session.sessionID, colSID)),
+		"UP":     truncOrPad(uptime, colUp),
+		"CPU":    truncOrPad(fmt.Sprintf("%.1f%%", cs.process.totalCPU()), colCPU),
+		"CTX":    truncOrPad(formatTokens(cs.session.totalInputTokens), colCtx),
+		"MODEL":  m.highlightCell("model", truncOrPad(shortModel(cs.session.model), colModel)),
+	})
 
 	if selected {
-		return selectStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, selectStyle, text)
 	}
-	return statusStyleFor(status).Width(m.width).MaxWidth(m.width).Render(text)
+	return m.renderRow(cs, statusStyleFor(status), text)
 }
 
 func (m model) renderSessionRow2(cs correlatedSession, selected bool) string {
@@ -286,42 +428,47 @@ func (m model) renderSessionRow2(cs correlatedSession, selected bool) string {
 	nowMS := time.Now().UnixMilli()
 
 	if cs.session == nil {
-		text := "  " + truncOrPad(shortPath(cs.process.cwd, tw), tw) +
-			"  " + truncOrPad("", colStatus) +
-			"  " + truncOrPad(fmt.Sprintf("%d", cs.process.pid), colSID) +
-			"  " + truncOrPad("", colUp) +
-			"  " + truncOrPad("", colCPU) +
-			"  " + truncOrPad("", colCtx) +
-			"  " + truncOrPad(cs.process.tty, colModel)
+		text := m.gridLine(dirLink(cs.process.cwd, m.highlightCell("cwd", truncOrPad(shortPath(cs.process.cwd, tw), tw))), map[string]string{
+			"STATUS": truncOrPad("", colStatus),
+			"SID":    truncOrPad(fmt.Sprintf("%d", cs.process.pid), colSID),
+			"UP":     truncOrPad("", colUp),
+			"CPU":    truncOrPad("", colCPU),
+			"CTX":    truncOrPad("", colCtx),
+			"MODEL":  truncOrPad(cs.process.tty, colModel),
+		})
 		if selected {
-			return selectStyle.Width(m.width).MaxWidth(m.width).Render(text)
+			return m.renderRow(cs, selectStyle, text)
 		}
-		return dimStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, dimStyle, text)
 	}
 
-	roundMS := int64(0)
+	round := formatDuration(0)
 	if cs.session.roundStartTime > 0 {
-		roundMS = nowMS - cs.session.roundStartTime
+		round = formatSince(cs.session.roundStartTime, nowMS)
 	}
 
-	text := "  " + truncOrPad(cs.session.lastOutput, tw) +
-		"  " + truncOrPad(fmt.Sprintf("%d", cs.session.messageCount), colStatus) +
-		"  " + truncOrPad(fmt.Sprintf("%d", cs.process.pid), colSID) +
-		"  " + truncOrPad(formatDuration(roundMS), colUp) +
-		"  " + truncOrPad(fmt.Sprintf("%.0fM", cs.process.memMB), colCPU) +
-		"  " + truncOrPad(formatTokens(cs.session.totalOutputTokens), colCtx) +
-		"  " + truncOrPad(cs.process.tty, colModel)
+	text := m.gridLine(truncOrPad(compactionPrefix(cs.session)+cs.session.lastOutput, tw), map[string]string{
+		"STATUS": truncOrPad(fmt.Sprintf("%d", cs.session.messageCount), colStatus),
+		"SID":    truncOrPad(fmt.Sprintf("%d", cs.process.pid), colSID),
+		"UP":     truncOrPad(round, colUp),
+		"CPU":    truncOrPad(fmt.Sprintf("%.0fM", cs.process.totalMemMB()), colCPU),
+		"CTX":    truncOrPad(formatTokens(cs.session.totalOutputTokens), colCtx),
+		"MODEL":  truncOrPad(cs.process.tty, colModel),
+	})
 
 	if selected {
-		return selectStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, selectStyle, text)
 	}
-	return dimStyle.Width(m.width).MaxWidth(m.width).Render(text)
+	return m.renderRow(cs, dimStyle, text)
 }
 
 // -- one-line mode rendering --
 
 // listOverhead returns the number of non-session lines in the list view.
 func (m model) listOverhead() int {
+	if m.tiny() {
+		return 1 // footer only
+	}
 	lines := 1 // footer
 	if display.showHeader {
 		lines++
@@ -329,6 +476,15 @@ func (m model) listOverhead() int {
 	if display.showAggregateStats {
 		lines++
 	}
+	if budgetEnabled() {
+		lines++ // budget line
+	}
+	if m.schemaErr != nil {
+		lines++ // schema banner
+	}
+	if m.dbErr != nil {
+		lines++ // db error banner
+	}
 	if display.showColumnHeaders {
 		if display.oneLine {
 			lines += 2 // header row + separator
@@ -339,12 +495,65 @@ func (m model) listOverhead() int {
 	if m.selectMode {
 		lines++ // detail line
 	}
+	if m.archivedCount() > 0 {
+		lines++ // stale row
+	}
 	if m.showTodos || m.showMCPs {
 		lines += 8
 	}
+	if m.showUsage {
+		lines += usageRows
+	}
+	if m.showModelUsage {
+		lines += m.modelUsagePanelRows()
+	}
+	if m.showHeatmap {
+		lines += heatmapRows
+	}
+	if m.previewMode {
+		lines += m.previewHeight()
+	}
+	if len(m.liveNotices()) > 0 {
+		lines++ // status-change notice line
+	}
+	if m.spawnActive {
+		lines += spawnPickerRows
+	}
+	if m.grouping() {
+		lines += m.groupHeaderLines(m.height - lines) // headers on this page
+	}
 	return lines
 }
 
+// groupHeaderLines returns how many of the avail lines left for the
+// list go to anything but rows: the group headers on the page starting
+// at scrollOffset, plus what's left over when another row and its
+// header wouldn't fit. the page is the most rows that fit with their
+// headers, so the page size worked out from the overhead is exactly it.
+func (m model) groupHeaderLines(avail int) int {
+	linesPerSession := 3
+	if display.oneLine {
+		linesPerSession = 1
+	}
+	visible := m.getVisibleSessions()
+	rows, used := 0, 0
+	for i := m.scrollOffset; i < len(visible); i++ {
+		need := linesPerSession
+		if i == m.scrollOffset || tmuxGroup(visible[i-1]) != tmuxGroup(visible[i]) {
+			need++
+		}
+		if used+need > avail && rows > 0 {
+			break
+		}
+		used += need
+		rows++
+	}
+	if m.scrollOffset+rows < len(visible) {
+		return max(0, avail-rows*linesPerSession)
+	}
+	return used - rows*linesPerSession
+}
+
 // oneLineFlexWidth computes the width for flexible columns (width=0).
 // splits remaining space evenly among all flexible columns.
 func (m model) oneLineFlexWidth(cols []oneLineColSpec) int {
@@ -375,18 +584,53 @@ func resolvedOneLineColumns(visible []correlatedSession) []oneLineColSpec {
 		if c.width == 0 {
 			continue // flexible columns stay flexible
 		}
-		maxW := len(c.label)
+		maxW := lipgloss.Width(c.label)
 		for _, cs := range visible {
-			val := columnValue(c.key, cs)
-			if len(val) > maxW {
-				maxW = len(val)
-			}
+			maxW = max(maxW, lipgloss.Width(columnValue(c.key, cs)))
 		}
 		cols[i].width = maxW
 	}
 	return cols
 }
 
+// fitOneLineColumns drops columns in oneLineDropOrder until the fixed
+// widths leave each flexible column at least minTitleWidth (or, with no
+// flexible columns, until the row fits). dropped columns come back as soon
+// as the terminal is wide enough again, since this runs every render.
+func (m model) fitOneLineColumns(cols []oneLineColSpec) []oneLineColSpec {
+	if m.width <= 0 {
+		return cols
+	}
+	fits := func(cols []oneLineColSpec) bool {
+		fixed := 2 // leading indent
+		flexCount := 0
+		for i, c := range cols {
+			if c.width > 0 {
+				fixed += c.width
+			} else {
+				flexCount++
+			}
+			if i > 0 {
+				fixed += colGap
+			}
+		}
+		return fixed+flexCount*minTitleWidth <= m.width
+	}
+	for _, drop := range oneLineDropOrder {
+		if fits(cols) {
+			break
+		}
+		var kept []oneLineColSpec
+		for _, c := range cols {
+			if c.key != drop {
+				kept = append(kept, c)
+			}
+		}
+		cols = kept
+	}
+	return cols
+}
+
 func (m model) renderOneLineHeaders(cols []oneLineColSpec, flexWidth int) string {
 	if len(cols) == 0 {
 		return ""
@@ -399,7 +643,7 @@ func (m model) renderOneLineHeaders(cols []oneLineColSpec, flexWidth int) string
 		if w == 0 {
 			w = flexWidth
 		}
-		text := truncOrPad(c.label, w)
+		text := alignPad(c.label, w, display.columnFormats[c.key].alignRight)
 		if c.key == activeKey {
 			parts = append(parts, sortHiStyle.Render(text))
 		} else {
@@ -421,23 +665,40 @@ func (m model) renderSessionOneLine(cs correlatedSession, selected bool, cols []
 			w = flexWidth
 		}
 		val := columnValue(c.key, cs)
+		if c.key == "title" {
+			val = m.unseenPrefix(cs) + m.ambiguousPrefix(cs) + val
+		}
+		if c.key == "version" && m.versionBehind(cs) {
+			val += staleVersionMarker
+		}
 		if c.key == "last" && display.ticker.rateMS > 0 {
 			parts = append(parts, tickerSlice(val, w, display.ticker.rateMS))
 		} else {
-			parts = append(parts, truncOrPad(val, w))
+			cell := alignPad(val, w, display.columnFormats[c.key].alignRight)
+			switch {
+			case c.key == "title":
+				cell = m.highlightCell("title", cell)
+			case c.key == "model":
+				cell = m.highlightCell("model", cell)
+			case c.key == "sid" && cs.session != nil:
+				cell = sessionLink(cs.session.sessionID, cell)
+			case c.key == "last" && cs.session == nil:
+				cell = dirLink(cs.process.cwd, m.highlightCell("cwd", cell))
+			}
+			parts = append(parts, cell)
 		}
 	}
 
 	text := "  " + strings.Join(parts, "  ")
 
 	if selected {
-		return selectStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, selectStyle, text)
 	}
 	if cs.session == nil {
-		return dimStyle.Width(m.width).MaxWidth(m.width).Render(text)
+		return m.renderRow(cs, dimStyle, text)
 	}
 	status := inferStatus(cs.session, cs.process.cpuPercent)
-	return statusStyleFor(status).Width(m.width).MaxWidth(m.width).Render(text)
+	return m.renderRow(cs, statusStyleFor(status), text)
 }
 
 // -- detail line (cwd of selected) --
@@ -449,7 +710,7 @@ func (m model) renderDetailLine() string {
 	}
 	cs := visible[m.cursor]
 	cwdDisplay := shortPath(cs.process.cwd, max(10, m.width-4))
-	return dimStyle.Render(" " + cwdDisplay)
+	return dimStyle.Render(" " + dirLink(cs.process.cwd, m.highlightCell("cwd", cwdDisplay)))
 }
 
 // -- panels --
@@ -459,13 +720,25 @@ func (m model) renderTodosPanel() string {
 	b.WriteString(dimStyle.Render(strings.Repeat("\u2500", m.width)))
 	b.WriteString("\n")
 	b.WriteString(panelStyle.Render(" TODOS (selected session)"))
-	b.WriteString("\n")
 
 	visible := m.getVisibleSessions()
 	if m.cursor < len(visible) {
 		if s := visible[m.cursor].session; s != nil && len(s.activeTodos) > 0 {
-			limit := min(6, len(s.activeTodos))
-			for _, todo := range s.activeTodos[:limit] {
+			done, total := todoProgress(s.activeTodos)
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %d/%d done ", done, total)))
+			b.WriteString(activeStyle.Render(progressBar(done, total, 10)))
+
+			todos := m.panelTodos(s)
+			if m.hideDoneTodos {
+				b.WriteString(dimStyle.Render("  (done hidden)"))
+			}
+			start := m.todoOffset(s.sessionID, len(todos))
+			end := min(start+todoPanelRows, len(todos))
+			if start > 0 || end < len(todos) {
+				b.WriteString(dimStyle.Render(fmt.Sprintf("  %d-%d of %d, J/K scroll", start+1, end, len(todos))))
+			}
+			b.WriteString("\n")
+			for _, todo := range todos[start:end] {
 				statusChar := map[string]string{
 					"completed":   "x",
 					"in_progress": ">",
@@ -491,9 +764,12 @@ func (m model) renderTodosPanel() string {
 				b.WriteString("\n")
 			}
 		} else {
+			b.WriteString("\n")
 			b.WriteString(dimStyle.Render("  (no todos)"))
 			b.WriteString("\n")
 		}
+	} else {
+		b.WriteString("\n")
 	}
 
 	return b.String()
@@ -504,9 +780,15 @@ func (m model) renderMCPsPanel() string {
 	b.WriteString(dimStyle.Render(strings.Repeat("\u2500", m.width)))
 	b.WriteString("\n")
 	b.WriteString(panelStyle.Render(" MCP SERVERS"))
-	b.WriteString("\n")
+	if m.mcpHealthDir != "" {
+		b.WriteString(dimStyle.Render(" · " + shortPath(m.mcpHealthDir, 40)))
+	}
+	if slices.ContainsFunc(m.mcpHealth, func(h mcpServerHealth) bool { return h.local != "" }) {
+		b.WriteString(dimStyle.Render("  * = project config"))
+	}
 
-	if len(m.mcpConfig) == 0 {
+	if len(m.mcpConfig) == 0 && len(m.mcpHealth) == 0 {
+		b.WriteString("\n")
 		b.WriteString(dimStyle.Render("  (no config found)"))
 		b.WriteString("\n")
 		return b.String()
@@ -526,6 +808,27 @@ func (m model) renderMCPsPanel() string {
 		}
 	}
 
+	// probed health, once the first probe is back: one row per server,
+	// failing ones first so they survive the row limit
+	if len(m.mcpHealth) > 0 {
+		rows := slices.Clone(m.mcpHealth)
+		slices.SortStableFunc(rows, func(a, b mcpServerHealth) int {
+			return cmp.Compare(mcpStatusRank(a.status), mcpStatusRank(b.status))
+		})
+		const maxRows = 6
+		if len(rows) > maxRows {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %d more not shown", len(rows)-maxRows)))
+			rows = rows[:maxRows]
+		}
+		b.WriteString("\n")
+		for _, h := range rows {
+			b.WriteString(m.renderMCPHealthRow(h))
+			b.WriteString("\n")
+		}
+		return b.String()
+	}
+	b.WriteString("\n")
+
 	if len(enabled) > 0 {
 		line := "  enabled: " + strings.Join(enabled, ", ")
 		if len(line) > m.width && m.width > 0 {
@@ -553,6 +856,15 @@ func (m model) renderMCPsPanel() string {
 // -- footer --
 
 func (m model) renderFooter() string {
+	if m.once {
+		return ""
+	}
+	if m.promptActive {
+		return m.renderPromptBar()
+	}
+	if m.spawnActive {
+		return m.renderSpawnBar()
+	}
 	if m.filterActive {
 		prompt := " /" + m.filterText
 		return headerStyle.Width(m.width).Render(prompt)
@@ -562,6 +874,7 @@ func (m model) renderFooter() string {
 		{"q", "quit"},
 		{"enter", "view"},
 		{"r", "refresh"},
+		{"space", "pause"},
 		{"y", "yank"},
 		{">/<", "sort"},
 		{"s", "flip"},
@@ -571,7 +884,17 @@ func (m model) renderFooter() string {
 		{"p", "procs"},
 		{"t", "todos"},
 		{"m", "mcps"},
+		{"v", "preview"},
+		{"C", "columns"},
 		{"j/k", "select"},
+		{"e", "edit"},
+		{"W", "inbox"},
+	}
+	if paneCaptureAvailable() {
+		binds = append(binds,
+			struct{ key, desc string }{"o", "jump"},
+			struct{ key, desc string }{"w", "wall"},
+		)
 	}
 
 	var parts []string
@@ -579,6 +902,12 @@ func (m model) renderFooter() string {
 		parts = append(parts, keyStyle.Render(b.key)+" "+helpStyle.Render(b.desc))
 	}
 	bar := " " + strings.Join(parts, "  ")
+	switch display.footer.mode {
+	case "format":
+		bar = dimStyle.Render(m.renderFooterTemplate())
+	case "minimal":
+		bar = ""
+	}
 
 	// flash message overlay
 	if m.flashMsg != "" && time.Since(m.flashTime) < 1500*time.Millisecond {
@@ -592,9 +921,42 @@ func (m model) renderFooter() string {
 		}
 	}
 
-	// subtle mode indicator, right-aligned
+	// subtle mode indicators, right-aligned
+	var indicators []string
+	if m.inboxMode {
+		indicators = append(indicators, transStyle.Render("needs reply"))
+	}
+	if pos := m.listScrollPosition(); pos != "" {
+		indicators = append(indicators, dimStyle.Render(pos))
+	}
+	if n := m.staleVersionCount(); n > 0 {
+		indicators = append(indicators, transStyle.Render(fmt.Sprintf("%d on old opencode", n)))
+	}
+	if dir := scopeDir(); dir != "" {
+		indicators = append(indicators, dimStyle.Render("cwd:"+shortPath(dir, 24)))
+	}
+	// fetch latency, only when collection can't keep up with the tick
+	if m.fetchQueued || m.lastFetchDur > refreshInterval {
+		indicators = append(indicators, transStyle.Render("fetch "+m.lastFetchDur.Round(100*time.Millisecond).String()))
+	}
+	if n := len(m.otherInstances); n > 0 {
+		label := fmt.Sprintf("otop also running (pid %d)", m.otherInstances[0])
+		if n > 1 {
+			label = fmt.Sprintf("%d other otops running", n)
+		}
+		indicators = append(indicators, transStyle.Render(label))
+	}
+	if footerMinimal() {
+		indicators = nil
+	}
+	if m.paused {
+		indicators = append([]string{transStyle.Render("paused")}, indicators...)
+	}
 	if m.selectMode {
-		indicator := dimStyle.Render("select")
+		indicators = append(indicators, dimStyle.Render("select"))
+	}
+	if len(indicators) > 0 {
+		indicator := strings.Join(indicators, " ")
 		barWidth := lipgloss.Width(bar)
 		indWidth := lipgloss.Width(indicator)
 		if barWidth+indWidth+2 < m.width {
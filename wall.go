@@ -0,0 +1,124 @@
+// wall mode: mission-control grid of live pane captures.
+//
+// pressing w tiles the visible sessions into a grid sized to the
+// terminal (2x2 on small screens, up to 3x3 on large ones). each cell
+// shows a title line colored by status and the tail of the pane capture.
+// captures refresh on the regular tick.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// wallGrid returns the number of columns and rows for the current size.
+func (m model) wallGrid() (cols, rows int) {
+	cols, rows = 2, 2
+	if m.width >= 180 {
+		cols = 3
+	}
+	if m.width < 80 {
+		cols = 1
+	}
+	if m.height >= 60 {
+		rows = 3
+	}
+	return cols, rows
+}
+
+// wallSessions returns the sessions that get a cell, in list order.
+func (m model) wallSessions() []correlatedSession {
+	cols, rows := m.wallGrid()
+	visible := m.getVisibleSessions()
+	return visible[:min(len(visible), cols*rows)]
+}
+
+func (m model) renderWallView() string {
+	cols, rows := m.wallGrid()
+	cells := m.wallSessions()
+	if len(cells) == 0 {
+		return "\n  no sessions\n"
+	}
+
+	sep := dimStyle.Render("│")
+	cellW := max(10, (m.width-(cols-1))/cols)
+	cellH := max(3, (m.height-1)/rows)
+
+	var b strings.Builder
+	for r := 0; r < rows; r++ {
+		start := r * cols
+		if start >= len(cells) {
+			break
+		}
+		var rendered [][]string
+		for c := 0; c < cols; c++ {
+			var cell []string
+			if i := start + c; i < len(cells) {
+				cell = m.renderWallCell(cells[i], cellW, cellH)
+			} else {
+				cell = make([]string, cellH)
+				for j := range cell {
+					cell[j] = strings.Repeat(" ", cellW)
+				}
+			}
+			rendered = append(rendered, cell)
+		}
+		for line := 0; line < cellH; line++ {
+			var parts []string
+			for _, cell := range rendered {
+				parts = append(parts, cell[line])
+			}
+			b.WriteString(strings.Join(parts, sep))
+			b.WriteString("\n")
+		}
+	}
+
+	hidden := len(m.getVisibleSessions()) - len(cells)
+	footer := " " +
+		keyStyle.Render("esc") + " " + helpStyle.Render("back") + "  " +
+		keyStyle.Render("r") + " " + helpStyle.Render("refresh")
+	if hidden > 0 {
+		footer += "  " + dimStyle.Render(fmt.Sprintf("+%d more", hidden))
+	}
+	b.WriteString(footer)
+
+	return b.String()
+}
+
+// renderWallCell renders one grid cell as exactly height lines of width.
+func (m model) renderWallCell(cs correlatedSession, width, height int) []string {
+	fit := lipgloss.NewStyle().Width(width).MaxWidth(width).MaxHeight(1)
+
+	title := cs.process.cmdline
+	status := "no-session"
+	style := dimStyle
+	if cs.session != nil {
+		title = sessionTitle(cs.session)
+		status = inferStatus(cs.session, cs.process.cpuPercent)
+		style = statusStyleFor(status)
+	}
+	header := style.Bold(true).Inherit(fit).Render(truncOrPad(" "+status+" · "+title, width))
+
+	lines := m.wallCaptures[cs.process.pid]
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if lines == nil {
+		lines = []string{dimStyle.Render("  (no pane)")}
+	}
+
+	cell := []string{header}
+	contentRows := height - 1
+	start := max(0, len(lines)-contentRows)
+	for i := start; i < start+contentRows; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		cell = append(cell, fit.Render(line))
+	}
+	return cell
+}
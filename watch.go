@@ -0,0 +1,109 @@
+// watch filters (--dir, --session): restrict otop to matching sessions
+// for the whole run. unlike the c scope they can't be toggled off, and
+// non-matching processes are dropped at collection time, so they never
+// reach the list, notices, the socket, or `otop sessions`.
+//
+// --dir takes a directory or a glob (`~/src/*`) and matches sessions in
+// it or below it; --session takes a session ID. both repeat, and a
+// session passes if it matches any of each kind given.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// watchDirs and watchSessions are set once from flags before the first
+// fetch and only read afterwards.
+var (
+	watchDirs     []string // absolute, cleaned patterns
+	watchSessions []string
+)
+
+// listFlag collects a repeatable string flag.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+// setWatch installs the --dir and --session filters.
+func setWatch(dirs, sessions []string) {
+	home, _ := os.UserHomeDir()
+	for _, d := range dirs {
+		if d == "~" || strings.HasPrefix(d, "~/") {
+			d = filepath.Join(home, d[1:])
+		}
+		if abs, err := filepath.Abs(d); err == nil {
+			d = abs
+		}
+		watchDirs = append(watchDirs, filepath.Clean(d))
+	}
+	watchSessions = sessions
+}
+
+// watchingSession reports whether a process's session ID passes
+// --session. processes without a known ID pass only when no --session
+// was given.
+func watchingSession(sessionID string) bool {
+	return len(watchSessions) == 0 || slices.Contains(watchSessions, sessionID)
+}
+
+// watchingDir reports whether dir, or one of its parents, matches a
+// --dir pattern.
+func watchingDir(dir string) bool {
+	if len(watchDirs) == 0 {
+		return true
+	}
+	if dir == "" || dir == "?" {
+		return false
+	}
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		for _, pattern := range watchDirs {
+			if ok, _ := filepath.Match(pattern, d); ok {
+				return true
+			}
+		}
+		if filepath.Dir(d) == d {
+			return false
+		}
+	}
+}
+
+// watching reports whether a collected session passes both filters.
+func watching(cs correlatedSession) bool {
+	sid := cs.process.sessionID
+	dir := cs.process.cwd
+	if cs.session != nil {
+		sid = cs.session.sessionID
+		if cs.session.directory != "" {
+			dir = cs.session.directory
+		}
+	}
+	return watchingSession(sid) && watchingDir(dir)
+}
+
+// watchSQL returns a condition on the session table for the filters,
+// or "" without any. sqlite's GLOB lets * cross slashes, so a glob can
+// count a little more in the stats than it shows in the list.
+func watchSQL() (string, []any) {
+	var conds []string
+	var args []any
+	if len(watchDirs) > 0 {
+		var ors []string
+		for _, pattern := range watchDirs {
+			ors = append(ors, "s.directory GLOB ? OR s.directory GLOB ?")
+			args = append(args, pattern, pattern+string(filepath.Separator)+"*")
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	if len(watchSessions) > 0 {
+		conds = append(conds, "s.id IN (?"+strings.Repeat(", ?", len(watchSessions)-1)+")")
+		for _, sid := range watchSessions {
+			args = append(args, sid)
+		}
+	}
+	return strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,36 @@
+// stuck-session watchdog.
+//
+// a hung stream looks healthy from the db: the last assistant message
+// has no finish yet, so the session reads as generating. the watchdog
+// calls it "stuck" instead when the process is also near-idle on CPU, no
+// tool call is running, and no message or part row has appeared for
+// display.watchdog.threshold.
+// stuck sessions render red and, with watchdog.notify, raise a notice
+// like other status changes (see notify.go).
+
+package main
+
+import "time"
+
+// watchdogConfig tunes stuck detection.
+type watchdogConfig struct {
+	enabled   bool
+	threshold time.Duration // no new rows for this long
+	cpu       float64       // below this CPU% counts as near-idle
+	notify    bool          // raise a notice when a session becomes stuck
+}
+
+// sessionStuck reports whether a mid-response session looks hung.
+// callers check that the last assistant message has no finish. a tool
+// call still running writes no rows either, but a ten-minute test run
+// isn't a hang, so those never count.
+func sessionStuck(session *sessionInfo, cpuPercent float64) bool {
+	if !display.watchdog.enabled || cpuPercent >= display.watchdog.cpu || session.pendingTool != "" {
+		return false
+	}
+	lastRow := max(session.lastMessageTime, session.lastPartTime)
+	if lastRow == 0 {
+		return false
+	}
+	return time.Since(time.UnixMilli(lastRow)) > display.watchdog.threshold
+}
@@ -0,0 +1,322 @@
+// WebSocket push: GET /ws upgrades and sends the /sessions payload on
+// connect, then again whenever a collection changes something, instead
+// of clients polling /sessions.
+//
+// messages are JSON text frames:
+//
+//	{"type": "snapshot", ...the /sessions payload...}
+//	{"type": "diff", "changed": [...session entries...], "removed": ["ses_..."], "today": ..., "global": ...}
+//
+// full snapshots are the default; ?diff=1 sends the first snapshot and
+// diffs after it. changes in uptime, round time, cpu, and memory alone
+// don't count, so idle dashboards stay quiet. the server pings every
+// 30s and drops clients that send nothing (pongs included) for 75s.
+//
+// this is a minimal RFC 6455 server (text out; ping, pong, and close
+// in), enough for browsers and the mobile client.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wsGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsPingInterval = 30 * time.Second
+	wsReadTimeout  = 75 * time.Second
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// wsVolatileFields change on every collection without anything
+// happening; they're ignored when deciding whether to push.
+var wsVolatileFields = []string{"uptime_ms", "uptime_human", "round_ms", "round_human", "cpu_percent", "mem_mb",
+	"children_cpu_percent", "children_mem_mb"}
+
+// wsOriginAllowed guards against cross-site WebSocket hijacking: browsers
+// let any page open a socket to localhost, sending its own Origin. requests
+// without one (the mobile client, curl) and same-origin ones pass, as do
+// origins listed with --allow-origin.
+func wsOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.Contains(serveOpts.allowOrigins, origin)
+}
+
+// handleWS upgrades the connection and streams snapshots until the
+// client goes away.
+func handleWS(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return
+	}
+	if !wsOriginAllowed(r) {
+		http.Error(w, "cross-origin websocket not allowed", http.StatusForbidden)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket unsupported", http.StatusInternalServerError)
+		return
+	}
+	diffMode, _ := strconv.ParseBool(r.URL.Query().Get("diff"))
+
+	snap := latestSnapshot(r.Context())
+	if snap == nil {
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if rw.Flush() != nil {
+		return
+	}
+
+	// the bus delivers synchronously, so the subscriber only swaps in
+	// the newest snapshot; the write loop below does the work
+	updates := make(chan *serveSnapshot, 1)
+	unsubscribe := bus.subscribe(func(ev event) {
+		s, ok := ev.(snapshotEvent)
+		if !ok {
+			return
+		}
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- snapshotFromResult(s.result)
+	})
+	defer unsubscribe()
+
+	pings := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go wsReadLoop(conn, rw.Reader, pings, closed)
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	payload := sessionsPayload(snap)
+	payload["type"] = "snapshot"
+	if wsWrite(conn, wsOpText, payload) != nil {
+		return
+	}
+	last := payload
+
+	for {
+		select {
+		case snap := <-updates:
+			payload := sessionsPayload(snap)
+			msg := wsChange(last, payload, diffMode)
+			if msg == nil {
+				continue
+			}
+			if wsWrite(conn, wsOpText, msg) != nil {
+				return
+			}
+			last = payload
+		case data := <-pings:
+			if wsWriteFrame(conn, wsOpPong, data) != nil {
+				return
+			}
+		case <-ticker.C:
+			if wsWriteFrame(conn, wsOpPing, nil) != nil {
+				return
+			}
+		case <-closed:
+			_ = wsWriteFrame(conn, wsOpClose, nil)
+			return
+		}
+	}
+}
+
+// wsChange returns the message to send for next given the last payload
+// sent, or nil when nothing meaningful changed.
+func wsChange(last, next map[string]any, diffMode bool) map[string]any {
+	prev := wsSessionsByID(last)
+	cur := wsSessionsByID(next)
+
+	var changed []map[string]any
+	var removed []string
+	for id, entry := range cur {
+		if old, ok := prev[id]; !ok || !wsSameEntry(old, entry) {
+			changed = append(changed, entry)
+		}
+	}
+	for id := range prev {
+		if _, ok := cur[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	statsChanged := !reflect.DeepEqual(last["today"], next["today"]) ||
+		!reflect.DeepEqual(last["global"], next["global"]) ||
+		last["db_error"] != next["db_error"]
+	if len(changed) == 0 && len(removed) == 0 && !statsChanged {
+		return nil
+	}
+
+	if !diffMode {
+		next["type"] = "snapshot"
+		return next
+	}
+	return map[string]any{
+		"type":         "diff",
+		"timestamp":    next["timestamp"],
+		"collected_at": next["collected_at"],
+		"changed":      changed,
+		"removed":      removed,
+		"today":        next["today"],
+		"global":       next["global"],
+		"db_error":     next["db_error"],
+	}
+}
+
+func wsSessionsByID(payload map[string]any) map[string]map[string]any {
+	byID := make(map[string]map[string]any)
+	sessions, _ := payload["sessions"].([]map[string]any)
+	for _, entry := range sessions {
+		if id, ok := entry["session_id"].(string); ok {
+			byID[id] = entry
+		}
+	}
+	return byID
+}
+
+// wsSameEntry compares two session entries, ignoring wsVolatileFields.
+func wsSameEntry(a, b map[string]any) bool {
+	strip := func(m map[string]any) map[string]any {
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = v
+		}
+		for _, k := range wsVolatileFields {
+			delete(out, k)
+		}
+		return out
+	}
+	return reflect.DeepEqual(strip(a), strip(b))
+}
+
+// wsReadLoop reads client frames: pings are answered through pings,
+// pongs and other frames just extend the deadline, and a close frame,
+// read error, or timeout closes closed.
+func wsReadLoop(conn net.Conn, r *bufio.Reader, pings chan<- []byte, closed chan<- struct{}) {
+	defer close(closed)
+	for {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		op, data, err := wsReadFrame(r)
+		if err != nil || op == wsOpClose {
+			return
+		}
+		if op == wsOpPing {
+			select {
+			case pings <- data:
+			default:
+			}
+		}
+	}
+}
+
+// wsReadFrame reads one (masked, client-to-server) frame.
+// fragmented messages are returned frame by frame; otop ignores
+// client data frames anyway.
+func wsReadFrame(r *bufio.Reader) (op byte, data []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > 1<<20 {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	data = make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	return op, data, nil
+}
+
+// wsWrite sends v as a JSON text frame.
+func wsWrite(conn net.Conn, op byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return wsWriteFrame(conn, op, data)
+}
+
+// wsWriteFrame sends one unfragmented, unmasked (server-to-client) frame.
+func wsWriteFrame(conn net.Conn, op byte, data []byte) error {
+	head := []byte{0x80 | op}
+	switch n := len(data); {
+	case n < 126:
+		head = append(head, byte(n))
+	case n <= 0xFFFF:
+		head = append(head, 126)
+		head = binary.BigEndian.AppendUint16(head, uint16(n))
+	default:
+		head = append(head, 127)
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := conn.Write(append(head, data...))
+	return err
+}
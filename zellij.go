@@ -0,0 +1,147 @@
+// zellij pane provider.
+//
+// zellij doesn't expose pane TTYs, so processes are located through the
+// ZELLIJ_SESSION_NAME / ZELLIJ_PANE_ID variables every pane inherits.
+// `zellij action dump-screen` can only dump the focused pane, so capture
+// first checks `list-clients` and skips panes that aren't focused rather
+// than showing some other pane's content.
+//
+// a process's environment is fixed once it starts, so each PID's pane is
+// read once and kept; locate forgets PIDs that are no longer running, so
+// a reused PID is read afresh.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+type zellijProvider struct{}
+
+// zellijInstalled is checked once; without zellij every call is a no-op.
+var zellijInstalled = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("zellij")
+	return err == nil
+})
+
+func (zellijProvider) name() string    { return "zellij" }
+func (zellijProvider) available() bool { return zellijInstalled() }
+
+// zellijPaneRef is a process's zellij session and pane ID.
+type zellijPaneRef struct {
+	session, paneID string
+}
+
+var (
+	zellijPanesMu sync.Mutex
+	zellijPanes   = make(map[int]zellijPaneRef) // by PID
+)
+
+// zellijPane reads the zellij session and pane ID from a process's env,
+// once per PID.
+func zellijPane(pid int) (session, paneID string) {
+	zellijPanesMu.Lock()
+	defer zellijPanesMu.Unlock()
+	ref, ok := zellijPanes[pid]
+	if !ok {
+		env := processEnv(pid)
+		ref = zellijPaneRef{env["ZELLIJ_SESSION_NAME"], env["ZELLIJ_PANE_ID"]}
+		zellijPanes[pid] = ref
+	}
+	return ref.session, ref.paneID
+}
+
+// forgetZellijPanes drops cached panes of PIDs not in procs.
+func forgetZellijPanes(procs []processInfo) {
+	running := make(map[int]bool, len(procs))
+	for _, p := range procs {
+		running[p.pid] = true
+	}
+	zellijPanesMu.Lock()
+	defer zellijPanesMu.Unlock()
+	for pid := range zellijPanes {
+		if !running[pid] {
+			delete(zellijPanes, pid)
+		}
+	}
+}
+
+func (zellijProvider) locate(procs []processInfo) map[int]paneLocation {
+	if !zellijInstalled() {
+		return nil
+	}
+	forgetZellijPanes(procs)
+	result := make(map[int]paneLocation)
+	for _, proc := range procs {
+		session, pane := zellijPane(proc.pid)
+		if session == "" {
+			continue
+		}
+		result[proc.pid] = paneLocation{provider: "zellij", session: session, window: "pane " + pane}
+	}
+	return result
+}
+
+func (zellijProvider) capture(proc processInfo) []string {
+	if !zellijInstalled() {
+		return nil
+	}
+	session, pane := zellijPane(proc.pid)
+	if session == "" || !zellijPaneFocused(session, pane) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp("", "otop-zellij-*.txt")
+	if err != nil {
+		return nil
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if exec.CommandContext(ctx, "zellij", "--session", session, "action", "dump-screen", path).Run() != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// zellijPaneFocused reports whether any client of the session is focused
+// on the given terminal pane. list-clients output:
+//
+//	CLIENT_ID ZELLIJ_PANE_ID RUNNING_COMMAND
+//	1         terminal_3     opencode
+func zellijPaneFocused(session, paneID string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "zellij", "--session", session, "action", "list-clients").Output()
+	if err != nil {
+		return false
+	}
+	want := "terminal_" + paneID
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == want {
+			return true
+		}
+	}
+	return false
+}
+
+// focus isn't supported: zellij has no action to focus a pane by ID
+// from outside the session.
+func (zellijProvider) focus(proc processInfo) bool {
+	return false
+}